@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"yoyichat/api/handler"
 	"yoyichat/api/router"
 	"yoyichat/api/rpc"
+	"yoyichat/auth"
 	"yoyichat/config"
 
 	"net/http"
@@ -29,6 +31,15 @@ func (c *Chat) Run() {
 	//init rpc client
 	// 初始化logic层客户端
 	rpc.InitLogicRpcClient()
+	// jwt 认证模式下本地验签，需要签名密钥和吊销列表
+	if err := auth.Init(nil); err != nil {
+		logrus.Panicf("api init auth fail,err:%s", err.Error())
+	}
+	// 初始化附件存储
+	if err := handler.InitBlobStore(); err != nil {
+		logrus.Panicf("api init blob store fail,err:%s", err.Error())
+	}
+	go handler.SweepOrphanAttachments()
 
 	// gin 引擎注册
 	r := router.Register()
@@ -58,7 +69,7 @@ func (c *Chat) Run() {
 	}()
 	// if have two quit signal , this signal will priority capture ,also can graceful shutdown
 	// 创建信号通道
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 
 	// 注册信号通知
 	signal.Notify(quit,
@@ -76,7 +87,7 @@ func (c *Chat) Run() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		logrus.Errorf("Server Shutdown:", err)
+		logrus.Errorf("Server Shutdown:%s", err.Error())
 	}
 	logrus.Infof("Server exiting")
 	os.Exit(0)
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+	"yoyichat/api/rpc"
+	"yoyichat/blob"
+	"yoyichat/config"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 附件存储，api启动时初始化
+var blobStore blob.Store
+
+func InitBlobStore() (err error) {
+	blobStore, err = blob.NewStore()
+	return
+}
+
+// 发消息时附件只需要带ID，元信息由logic层补全
+func attachmentRefs(attachmentIds []int64) (attachments []*logic_pb.Attachment) {
+	for _, id := range attachmentIds {
+		attachments = append(attachments, &logic_pb.Attachment{Id: id})
+	}
+	return
+}
+
+func uploadMaxSize() int64 {
+	if maxSize := config.Conf.Api.ApiUpload.MaxSize; maxSize > 0 {
+		return maxSize
+	}
+	return config.AttachmentDefaultLimit
+}
+
+func uploadUserQuota() int64 {
+	if quota := config.Conf.Api.ApiUpload.UserQuota; quota > 0 {
+		return quota
+	}
+	return config.AttachmentDefaultQuota
+}
+
+func uploadOrphanTTL() time.Duration {
+	if ttl := config.Conf.Api.ApiUpload.OrphanTTL; ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return config.AttachmentOrphanTTL
+}
+
+// 定时清理上传后一直没随消息发出的附件，logic层删记录，这里删文件
+func SweepOrphanAttachments() {
+	ticker := time.NewTicker(config.AttachmentSweepEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		sweepOrphanAttachments()
+	}
+}
+
+func sweepOrphanAttachments() {
+	req := &logic_pb.OrphanAttachmentRequest{
+		Before: time.Now().Add(-uploadOrphanTTL()).UnixMilli(),
+		Limit:  config.AttachmentSweepBatch,
+	}
+	for {
+		code, storageKeys, msg := rpc.RpcLogicObj.ClaimOrphanAttachments(req)
+		if code == tools.CodeFail {
+			logrus.Warnf("api,sweepOrphanAttachments claim err:%s", msg)
+			return
+		}
+		for _, storageKey := range storageKeys {
+			if err := blobStore.Delete(storageKey); err != nil {
+				logrus.Warnf("api,sweepOrphanAttachments delete blob %s err:%s", storageKey, err.Error())
+			}
+		}
+		if len(storageKeys) < config.AttachmentSweepBatch {
+			return
+		}
+	}
+}
+
+// 类型按文件内容识别，不信任客户端给的 Content-Type
+func uploadTypeAllowed(mimeType string) bool {
+	allowedTypes := config.Conf.Api.ApiUpload.AllowedTypes
+	if len(allowedTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range allowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// 存储key：按天分目录，文件名用随机串，不用用户给的文件名
+func newStorageKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", time.Now().Format("20060102"), hex.EncodeToString(b)), nil
+}
+
+// 文件名只保留最后一段，太长的按字符截断
+func attachmentName(filename string) string {
+	name := filepath.Base(filepath.ToSlash(filename))
+	if name == "." || name == "/" {
+		name = "file"
+	}
+	for len(name) > config.AttachmentNameMaxLen {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// 限制上传请求体的大小，要挂在认证中间件前面，认证时可能要解析表单里的 authToken
+func UploadSizeLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 留一点给表单里的其他字段
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, uploadMaxSize()+1<<20)
+		c.Next()
+	}
+}
+
+// 上传附件：multipart 表单的 file 字段，返回附件信息，附件ID随消息发出
+func UploadAttachment(c *gin.Context) {
+	maxSize := uploadMaxSize()
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		tools.FailWithMsg(c, "file required or too large")
+		return
+	}
+	if fileHeader.Size > maxSize {
+		tools.FailWithMsg(c, fmt.Sprintf("file too large, max %d bytes", maxSize))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	defer file.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	mimeType := http.DetectContentType(head[:n])
+	if !uploadTypeAllowed(mimeType) {
+		tools.FailWithMsg(c, "file type not allowed: "+mimeType)
+		return
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	storageKey, err := newStorageKey()
+	if err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	// 边写边算校验和
+	hash := sha256.New()
+	if err = blobStore.Put(storageKey, io.TeeReader(file, hash), fileHeader.Size, mimeType); err != nil {
+		logrus.Errorf("api,UploadAttachment put blob err:%s", err.Error())
+		tools.FailWithMsg(c, "store file fail")
+		return
+	}
+	req := &logic_pb.AttachmentRequest{
+		UserId: int32(userId),
+		Attachment: &logic_pb.Attachment{
+			Name:     attachmentName(fileHeader.Filename),
+			Size:     fileHeader.Size,
+			MimeType: mimeType,
+			Checksum: hex.EncodeToString(hash.Sum(nil)),
+		},
+		StorageKey: storageKey,
+		Quota:      uploadUserQuota(),
+	}
+	code, attachment, _, msg := rpc.RpcLogicObj.Attachment("CreateAttachment", req)
+	if code == tools.CodeFail {
+		if err = blobStore.Delete(storageKey); err != nil {
+			logrus.Warnf("api,UploadAttachment delete blob err:%s", err.Error())
+		}
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", attachment)
+}
+
+// 下载附件：上传者本人或者能看到附件所在消息的人才能下载
+type FormDownloadAttachment struct {
+	AttachmentId int64 `form:"attachmentId" json:"attachmentId" binding:"required"`
+}
+
+func DownloadAttachment(c *gin.Context) {
+	var formDownload FormDownloadAttachment
+	if err := bindForm(c, &formDownload); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.AttachmentRequest{
+		UserId:     int32(userId),
+		Attachment: &logic_pb.Attachment{Id: formDownload.AttachmentId},
+	}
+	code, attachment, storageKey, msg := rpc.RpcLogicObj.Attachment("GetAttachment", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	reader, err := blobStore.Get(storageKey)
+	if err != nil {
+		logrus.Errorf("api,DownloadAttachment get blob %s err:%s", storageKey, err.Error())
+		tools.FailWithMsg(c, "file not found")
+		return
+	}
+	defer reader.Close()
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.MimeType, reader, map[string]string{
+		"Content-Disposition": "attachment; filename*=UTF-8''" + url.PathEscape(attachment.Name),
+		"ETag":                `"` + attachment.Checksum + `"`,
+	})
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"net/http"
+	"yoyichat/tools"
+)
+
+// 认证中间件解析出的用户放在 gin 的上下文里，handler 从这里取，不再自己查令牌
+const (
+	ContextUserId    = "userId"
+	ContextUserName  = "userName"
+	ContextAuthToken = "authToken"
+)
+
+func SetAuthUser(c *gin.Context, authToken string, userId int, userName string) {
+	c.Set(ContextAuthToken, authToken)
+	c.Set(ContextUserId, userId)
+	c.Set(ContextUserName, userName)
+}
+
+// 当前用户ID，路由没挂认证中间件时返回失败并写好响应
+func authUserId(c *gin.Context) (userId int, ok bool) {
+	userId = c.GetInt(ContextUserId)
+	if userId <= 0 {
+		tools.ResponseWithCode(c, tools.CodeSessionError, nil, nil)
+		return 0, false
+	}
+	return userId, true
+}
+
+func authUserName(c *gin.Context) string {
+	return c.GetString(ContextUserName)
+}
+
+// GET 请求从查询参数绑定，其他请求从 JSON 请求体绑定
+// 令牌放在请求头里时请求体可能是空的，空请求体按没有参数处理
+func bindForm(c *gin.Context, obj interface{}) error {
+	if c.Request.Method == http.MethodGet {
+		return c.ShouldBindQuery(obj)
+	}
+	err := c.ShouldBindBodyWith(obj, binding.JSON)
+	if err == io.EOF {
+		return binding.Validator.ValidateStruct(obj)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"yoyichat/api/rpc"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 联系人操作，对方可以给用户ID也可以给用户名
+type FormContact struct {
+	TargetUserId   int    `form:"targetUserId" json:"targetUserId"`
+	TargetUserName string `form:"targetUserName" json:"targetUserName"`
+	RequestId      int64  `form:"requestId" json:"requestId"` // 好友申请ID，接受/拒绝时使用
+	Message        string `form:"message" json:"message"`     // 申请附言
+}
+
+// 发送好友申请
+func FriendRequest(c *gin.Context) {
+	contactOperate(c, "SendFriendRequest")
+}
+
+// 接受好友申请
+func AcceptFriend(c *gin.Context) {
+	contactOperate(c, "AcceptFriendRequest")
+}
+
+// 拒绝好友申请
+func DeclineFriend(c *gin.Context) {
+	contactOperate(c, "DeclineFriendRequest")
+}
+
+// 删除好友
+func RemoveContact(c *gin.Context) {
+	contactOperate(c, "RemoveContact")
+}
+
+// 拉黑
+func BlockUser(c *gin.Context) {
+	contactOperate(c, "BlockUser")
+}
+
+// 取消拉黑
+func UnblockUser(c *gin.Context) {
+	contactOperate(c, "UnblockUser")
+}
+
+func contactOperate(c *gin.Context, method string) {
+	var formContact FormContact
+	if err := bindForm(c, &formContact); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.ContactRequest{
+		UserId:         int32(userId),
+		TargetUserId:   int32(formContact.TargetUserId),
+		TargetUserName: formContact.TargetUserName,
+		RequestId:      formContact.RequestId,
+		Message:        formContact.Message,
+	}
+	code, msg := rpc.RpcLogicObj.ContactOperate(method, req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", nil)
+}
+
+// 联系人列表，带上待处理的好友申请
+func ContactList(c *gin.Context) {
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	code, contacts, requests, msg := rpc.RpcLogicObj.GetContacts(&logic_pb.ContactListRequest{UserId: int32(userId)})
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"contacts": contacts,
+		"requests": requests,
+	})
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"yoyichat/api/rpc"
+	"yoyichat/config"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 翻页方向：before 往前翻（默认），after 往后翻
+const (
+	directionBefore = "before"
+	directionAfter  = "after"
+)
+
+// 单聊历史消息
+type FormSingleHistory struct {
+	ToUserId   int    `form:"toUserId" json:"toUserId" binding:"required"`
+	CursorSeq  int64  `form:"cursorSeq" json:"cursorSeq"`   // seq游标
+	CursorTime int64  `form:"cursorTime" json:"cursorTime"` // 毫秒时间戳游标
+	Direction  string `form:"direction" json:"direction"`
+	Limit      int    `form:"limit" json:"limit"`
+}
+
+func SingleHistory(c *gin.Context) {
+	var formHistory FormSingleHistory
+	if err := bindForm(c, &formHistory); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	direction, ok := parseDirection(formHistory.Direction)
+	if !ok {
+		tools.FailWithMsg(c, "direction must be before or after")
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.HistoryRequest{
+		UserId:     int32(userId),
+		ToUserId:   int32(formHistory.ToUserId),
+		CursorSeq:  formHistory.CursorSeq,
+		CursorTime: formHistory.CursorTime,
+		Direction:  direction,
+		Limit:      int32(formHistory.Limit),
+	}
+	code, msgs, hasMore, msg := rpc.RpcLogicObj.GetSingleHistory(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"list":    msgs,
+		"hasMore": hasMore,
+	})
+}
+
+// 群聊历史消息
+type FormRoomHistory struct {
+	RoomId     int    `form:"roomId" json:"roomId" binding:"required"`
+	CursorSeq  int64  `form:"cursorSeq" json:"cursorSeq"`
+	CursorTime int64  `form:"cursorTime" json:"cursorTime"`
+	Direction  string `form:"direction" json:"direction"`
+	Limit      int    `form:"limit" json:"limit"`
+}
+
+func RoomHistory(c *gin.Context) {
+	var formHistory FormRoomHistory
+	if err := bindForm(c, &formHistory); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	direction, ok := parseDirection(formHistory.Direction)
+	if !ok {
+		tools.FailWithMsg(c, "direction must be before or after")
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.HistoryRequest{
+		UserId:     int32(userId),
+		RoomId:     int32(formHistory.RoomId),
+		CursorSeq:  formHistory.CursorSeq,
+		CursorTime: formHistory.CursorTime,
+		Direction:  direction,
+		Limit:      int32(formHistory.Limit),
+	}
+	code, msgs, hasMore, msg := rpc.RpcLogicObj.GetRoomHistory(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"list":    msgs,
+		"hasMore": hasMore,
+	})
+}
+
+// 话题：根消息和分页的回复
+type FormThreadHistory struct {
+	ThreadId   int64  `form:"threadId" json:"threadId" binding:"required"`
+	CursorSeq  int64  `form:"cursorSeq" json:"cursorSeq"`
+	CursorTime int64  `form:"cursorTime" json:"cursorTime"`
+	Direction  string `form:"direction" json:"direction"`
+	Limit      int    `form:"limit" json:"limit"`
+}
+
+func ThreadHistory(c *gin.Context) {
+	var formHistory FormThreadHistory
+	if err := bindForm(c, &formHistory); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	direction, ok := parseDirection(formHistory.Direction)
+	if !ok {
+		tools.FailWithMsg(c, "direction must be before or after")
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.HistoryRequest{
+		UserId:     int32(userId),
+		ThreadId:   formHistory.ThreadId,
+		CursorSeq:  formHistory.CursorSeq,
+		CursorTime: formHistory.CursorTime,
+		Direction:  direction,
+		Limit:      int32(formHistory.Limit),
+	}
+	code, root, msgs, hasMore, msg := rpc.RpcLogicObj.GetThreadHistory(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"root":    root,
+		"list":    msgs,
+		"hasMore": hasMore,
+	})
+}
+
+// 房间里的话题列表，按最后回复时间倒序，cursorTime 传上一页最后一条的 lastReplyTime
+type FormRoomThreads struct {
+	RoomId     int   `form:"roomId" json:"roomId" binding:"required"`
+	CursorTime int64 `form:"cursorTime" json:"cursorTime"`
+	Limit      int   `form:"limit" json:"limit"`
+}
+
+func RoomThreads(c *gin.Context) {
+	var formThreads FormRoomThreads
+	if err := bindForm(c, &formThreads); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.HistoryRequest{
+		UserId:     int32(userId),
+		RoomId:     int32(formThreads.RoomId),
+		CursorTime: formThreads.CursorTime,
+		Limit:      int32(formThreads.Limit),
+	}
+	code, msgs, hasMore, msg := rpc.RpcLogicObj.GetRoomThreads(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"list":    msgs,
+		"hasMore": hasMore,
+	})
+}
+
+// 关注、取消关注话题，关注后话题有新回复会收到通知
+type FormThreadFollow struct {
+	ThreadId int64 `form:"threadId" json:"threadId" binding:"required"`
+}
+
+func FollowThread(c *gin.Context) {
+	threadFollow(c, "FollowThread")
+}
+
+func UnfollowThread(c *gin.Context) {
+	threadFollow(c, "UnfollowThread")
+}
+
+func threadFollow(c *gin.Context, method string) {
+	var formFollow FormThreadFollow
+	if err := bindForm(c, &formFollow); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.ThreadFollowRequest{
+		UserId: int32(userId),
+		MsgId:  formFollow.ThreadId,
+	}
+	code, threadId, following, msg := rpc.RpcLogicObj.ThreadFollow(method, req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"threadId":  threadId,
+		"following": following,
+	})
+}
+
+// 自己被@的消息，按时间倒序，cursorId 传上一页最后一条的 id
+type FormMentions struct {
+	CursorId int64 `form:"cursorId" json:"cursorId"`
+	Limit    int   `form:"limit" json:"limit"`
+}
+
+func Mentions(c *gin.Context) {
+	var formMentions FormMentions
+	if err := bindForm(c, &formMentions); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.MentionRequest{
+		UserId:   int32(userId),
+		CursorId: formMentions.CursorId,
+		Limit:    int32(formMentions.Limit),
+	}
+	code, mentions, hasMore, msg := rpc.RpcLogicObj.GetMentions(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"list":    mentions,
+		"hasMore": hasMore,
+	})
+}
+
+// 各会话未读数，给客户端显示角标
+func Unread(c *gin.Context) {
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.UnreadRequest{UserId: int32(userId)}
+	code, counts, msg := rpc.RpcLogicObj.GetUnreadCount(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", counts)
+}
+
+func parseDirection(direction string) (int32, bool) {
+	switch direction {
+	case "", directionBefore:
+		return config.HistoryDirectionBefore, true
+	case directionAfter:
+		return config.HistoryDirectionAfter, true
+	}
+	return 0, false
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"yoyichat/api/rpc"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 手动设置状态：away / dnd，online 表示恢复自动
+type FormSetPresence struct {
+	Status string `form:"status" json:"status" binding:"required"`
+}
+
+func SetPresence(c *gin.Context) {
+	var formSetPresence FormSetPresence
+	if err := bindForm(c, &formSetPresence); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.PresenceRequest{
+		UserId: int32(userId),
+		Status: formSetPresence.Status,
+	}
+	code, presences, msg := rpc.RpcLogicObj.PresenceOperate("SetPresence", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", presences)
+}
+
+// 批量查询在线状态和最后在线时间，只能查自己、好友和同房间的人
+type FormQueryPresence struct {
+	UserIds []int32 `form:"userIds" json:"userIds" binding:"required"`
+}
+
+func QueryPresence(c *gin.Context) {
+	var formQueryPresence FormQueryPresence
+	if err := bindForm(c, &formQueryPresence); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.PresenceRequest{
+		UserId:  int32(userId),
+		UserIds: formQueryPresence.UserIds,
+	}
+	code, presences, msg := rpc.RpcLogicObj.PresenceOperate("GetPresence", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", presences)
+}
@@ -1,8 +1,9 @@
 package handler
 
 import (
+	"encoding/json"
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/encoding/protojson"
 	"yoyichat/api/rpc"
 	"yoyichat/config"
 	"yoyichat/pb/logic_pb"
@@ -13,10 +14,13 @@ import (
 
 // 单聊消息推送
 type FormPush struct {
-	Msg       string `form:"msg" json:"msg" binding:"required"`
-	ToUserId  string `form:"toUserId" json:"toUserId" binding:"required"`
-	RoomId    int    `form:"roomId" json:"roomId" binding:"required"`
-	AuthToken string `form:"authToken" json:"authToken" binding:"required"`
+	Msg      string `form:"msg" json:"msg"`
+	ToUserId string `form:"toUserId" json:"toUserId" binding:"required"`
+	ReplyTo  int64  `form:"replyTo" json:"replyTo"` // 回复的消息ID，发到该消息的话题里
+	// 上传后拿到的附件ID，带附件时消息内容可以为空
+	AttachmentIds []int64 `form:"attachmentIds" json:"attachmentIds"`
+	// 结构化消息体，如 {"markdown":{"markdown":"**hi**"}}，不传按 msg 发纯文本
+	Payload json.RawMessage `form:"payload" json:"payload"`
 }
 
 // 单聊消息推送
@@ -25,16 +29,32 @@ func Push(c *gin.Context) {
 	var formPush FormPush
 
 	// 用于将请求数据绑定到结构体上，这不就是和反序列化吗
-	if err := c.ShouldBindBodyWith(&formPush, binding.JSON); err != nil {
+	if err := bindForm(c, &formPush); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
-	authToken := formPush.AuthToken
+	if formPush.Msg == "" && len(formPush.Payload) == 0 && len(formPush.AttachmentIds) == 0 {
+		tools.FailWithMsg(c, "msg, payload or attachments required")
+		return
+	}
+	payload, err := parsePayload(formPush.Payload)
+	if err != nil {
+		tools.FailWithMsg(c, "invalid payload: "+err.Error())
+		return
+	}
+	fromUserId, ok := authUserId(c)
+	if !ok {
+		return
+	}
 	msg := formPush.Msg
 	toUserId := formPush.ToUserId
 
 	// 获取接收者信息
 	toUserIdInt, _ := strconv.Atoi(toUserId)
+	if toUserIdInt <= 0 || toUserIdInt == fromUserId {
+		tools.FailWithMsg(c, "toUserId invalid")
+		return
+	}
 	getUserNameReq := &logic_pb.GetUserInfoRequest{UserId: int32(toUserIdInt)}
 
 	// 亏贼，这也能调用logic层的方法啊，获取接收者信息（logic层查库）
@@ -44,14 +64,8 @@ func Push(c *gin.Context) {
 		return
 	}
 
-	// 验证发送者身份，掉logic层RPC，token放进去，如果存在就返回元信息，不存在就返回CodeFail了
-	checkAuthReq := &logic_pb.CheckAuthRequest{AuthToken: authToken}
-	code, fromUserId, fromUserName := rpc.RpcLogicObj.CheckAuth(checkAuthReq)
-	if code == tools.CodeFail {
-		tools.FailWithMsg(c, "rpc fail get self info")
-		return
-	}
-	roomId := formPush.RoomId
+	// 发送者身份由认证中间件解析好放在上下文里
+	fromUserName := authUserName(c)
 
 	// 构造推送请求
 	req := &logic_pb.SendMsg{
@@ -60,8 +74,10 @@ func Push(c *gin.Context) {
 		FromUserName: fromUserName,
 		ToUserId:     int32(toUserIdInt),
 		ToUserName:   toUserName,
-		RoomId:       int32(roomId),
 		Op:           config.OpSingleSend,
+		ReplyTo:      formPush.ReplyTo,
+		Attachments:  attachmentRefs(formPush.AttachmentIds),
+		Payload:      payload,
 	}
 	// 调用logic层 把信息发到消息队列中，此处已经和代码逻辑中断了，因为用到了中间件，而task自己也是从中间件消费消息
 	code, rpcMsg := rpc.RpcLogicObj.Push(req)
@@ -75,35 +91,47 @@ func Push(c *gin.Context) {
 
 // 群聊消息
 type FormRoom struct {
-	AuthToken string `form:"authToken" json:"authToken" binding:"required"`
-	Msg       string `form:"msg" json:"msg" binding:"required"`
-	RoomId    int    `form:"roomId" json:"roomId" binding:"required"`
+	Msg           string          `form:"msg" json:"msg"`
+	RoomId        int             `form:"roomId" json:"roomId" binding:"required"`
+	ReplyTo       int64           `form:"replyTo" json:"replyTo"` // 回复的消息ID，发到该消息的话题里
+	AttachmentIds []int64         `form:"attachmentIds" json:"attachmentIds"`
+	Payload       json.RawMessage `form:"payload" json:"payload"`
 }
 
 func PushRoom(c *gin.Context) {
 	var formRoom FormRoom
 	// 反序
-	if err := c.ShouldBindBodyWith(&formRoom, binding.JSON); err != nil {
+	if err := bindForm(c, &formRoom); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
-	authToken := formRoom.AuthToken
+	if formRoom.Msg == "" && len(formRoom.Payload) == 0 && len(formRoom.AttachmentIds) == 0 {
+		tools.FailWithMsg(c, "msg, payload or attachments required")
+		return
+	}
+	payload, err := parsePayload(formRoom.Payload)
+	if err != nil {
+		tools.FailWithMsg(c, "invalid payload: "+err.Error())
+		return
+	}
 	msg := formRoom.Msg
 	roomId := formRoom.RoomId
 
-	// 检查认证
-	checkAuthReq := &logic_pb.CheckAuthRequest{AuthToken: authToken}
-	authCode, fromUserId, fromUserName := rpc.RpcLogicObj.CheckAuth(checkAuthReq)
-	if authCode == tools.CodeFail {
-		tools.FailWithMsg(c, "rpc fail get self info")
+	// 发送者身份由认证中间件解析好放在上下文里
+	fromUserId, ok := authUserId(c)
+	if !ok {
 		return
 	}
+	fromUserName := authUserName(c)
 	req := &logic_pb.SendMsg{
 		Msg:          msg,
 		FromUserId:   int32(fromUserId),
 		FromUserName: fromUserName,
 		RoomId:       int32(roomId),
 		Op:           config.OpRoomSend,
+		ReplyTo:      formRoom.ReplyTo,
+		Attachments:  attachmentRefs(formRoom.AttachmentIds),
+		Payload:      payload,
 	}
 
 	// 发队列
@@ -124,7 +152,7 @@ type FormCount struct {
 // 人数无需验证
 func Count(c *gin.Context) {
 	var formCount FormCount
-	if err := c.ShouldBindBodyWith(&formCount, binding.JSON); err != nil {
+	if err := bindForm(c, &formCount); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
@@ -149,7 +177,7 @@ type FormRoomInfo struct {
 // 获取房间信息
 func GetRoomInfo(c *gin.Context) {
 	var formRoomInfo FormRoomInfo
-	if err := c.ShouldBindBodyWith(&formRoomInfo, binding.JSON); err != nil {
+	if err := bindForm(c, &formRoomInfo); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
@@ -166,3 +194,71 @@ func GetRoomInfo(c *gin.Context) {
 	tools.SuccessWithMsg(c, "ok", msg)
 	return
 }
+
+// 编辑消息，只能编辑自己发的
+type FormEditMsg struct {
+	MsgId int64  `form:"msgId" json:"msgId" binding:"required"`
+	Msg   string `form:"msg" json:"msg" binding:"required"`
+}
+
+func EditMsg(c *gin.Context) {
+	var formEditMsg FormEditMsg
+	if err := bindForm(c, &formEditMsg); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.MsgUpdateRequest{
+		UserId:  int32(userId),
+		MsgId:   formEditMsg.MsgId,
+		Content: formEditMsg.Msg,
+	}
+	code, sendMsg, msg := rpc.RpcLogicObj.MsgUpdate("EditMessage", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", sendMsg)
+}
+
+// 删除消息，房主和管理员可以删房间里别人的消息
+type FormDeleteMsg struct {
+	MsgId int64 `form:"msgId" json:"msgId" binding:"required"`
+}
+
+func DeleteMsg(c *gin.Context) {
+	var formDeleteMsg FormDeleteMsg
+	if err := bindForm(c, &formDeleteMsg); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.MsgUpdateRequest{
+		UserId: int32(userId),
+		MsgId:  formDeleteMsg.MsgId,
+	}
+	code, sendMsg, msg := rpc.RpcLogicObj.MsgUpdate("DeleteMessage", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", sendMsg)
+}
+
+// 结构化消息体按 proto 的 JSON 格式解析，内容在 logic 层检查
+func parsePayload(raw json.RawMessage) (*logic_pb.MsgPayload, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	payload := &logic_pb.MsgPayload{}
+	if err := protojson.Unmarshal(raw, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
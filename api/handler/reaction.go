@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"yoyichat/api/rpc"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 添加、取消表情回应，返回该消息当前的回应汇总
+type FormReaction struct {
+	MsgId int64  `form:"msgId" json:"msgId" binding:"required"`
+	Emoji string `form:"emoji" json:"emoji" binding:"required"`
+}
+
+func AddReaction(c *gin.Context) {
+	reaction(c, "AddReaction")
+}
+
+func RemoveReaction(c *gin.Context) {
+	reaction(c, "RemoveReaction")
+}
+
+func reaction(c *gin.Context, method string) {
+	var formReaction FormReaction
+	if err := bindForm(c, &formReaction); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.ReactionRequest{
+		UserId: int32(userId),
+		MsgId:  formReaction.MsgId,
+		Emoji:  formReaction.Emoji,
+	}
+	code, reactions, msg := rpc.RpcLogicObj.Reaction(method, req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", reactions)
+}
+
+// 查询一条消息的表情回应汇总
+type FormGetReactions struct {
+	MsgId int64 `form:"msgId" json:"msgId" binding:"required"`
+}
+
+func GetReactions(c *gin.Context) {
+	var formGetReactions FormGetReactions
+	if err := bindForm(c, &formGetReactions); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.ReactionRequest{
+		UserId: int32(userId),
+		MsgId:  formGetReactions.MsgId,
+	}
+	code, reactions, msg := rpc.RpcLogicObj.Reaction("GetReactions", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", reactions)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"yoyichat/api/rpc"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 创建房间
+type FormCreateRoom struct {
+	Name       string `form:"name" json:"name" binding:"required"`
+	Topic      string `form:"topic" json:"topic"`
+	Visibility string `form:"visibility" json:"visibility"` // public / private，默认public
+}
+
+func CreateRoom(c *gin.Context) {
+	var formCreateRoom FormCreateRoom
+	if err := bindForm(c, &formCreateRoom); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.RoomRequest{
+		UserId:     int32(userId),
+		Name:       formCreateRoom.Name,
+		Topic:      formCreateRoom.Topic,
+		Visibility: formCreateRoom.Visibility,
+	}
+	code, room, msg := rpc.RpcLogicObj.RoomOperate("CreateRoom", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", room)
+}
+
+// 加入、退出房间
+type FormRoomMember struct {
+	RoomId int `form:"roomId" json:"roomId" binding:"required"`
+}
+
+func JoinRoom(c *gin.Context) {
+	roomMemberOperate(c, "JoinRoom")
+}
+
+func LeaveRoom(c *gin.Context) {
+	roomMemberOperate(c, "LeaveRoom")
+}
+
+func roomMemberOperate(c *gin.Context, method string) {
+	var formRoomMember FormRoomMember
+	if err := bindForm(c, &formRoomMember); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.RoomRequest{
+		UserId: int32(userId),
+		RoomId: int32(formRoomMember.RoomId),
+	}
+	code, room, msg := rpc.RpcLogicObj.RoomOperate(method, req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", room)
+}
+
+// 管理成员：设置角色、踢人、封禁、解封，需要房主或管理员权限
+type FormRoomManage struct {
+	RoomId       int    `form:"roomId" json:"roomId" binding:"required"`
+	TargetUserId int    `form:"targetUserId" json:"targetUserId" binding:"required"`
+	Role         string `form:"role" json:"role"` // admin / member / muted，设置角色时使用
+}
+
+func SetRoomRole(c *gin.Context) {
+	roomManageOperate(c, "SetRoomRole")
+}
+
+func KickRoomMember(c *gin.Context) {
+	roomManageOperate(c, "KickRoomMember")
+}
+
+func BanRoomMember(c *gin.Context) {
+	roomManageOperate(c, "BanRoomMember")
+}
+
+func UnbanRoomMember(c *gin.Context) {
+	roomManageOperate(c, "UnbanRoomMember")
+}
+
+func roomManageOperate(c *gin.Context, method string) {
+	var formRoomManage FormRoomManage
+	if err := bindForm(c, &formRoomManage); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.RoomRequest{
+		UserId:       int32(userId),
+		RoomId:       int32(formRoomManage.RoomId),
+		TargetUserId: int32(formRoomManage.TargetUserId),
+		Role:         formRoomManage.Role,
+	}
+	code, room, msg := rpc.RpcLogicObj.RoomOperate(method, req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", room)
+}
+
+// 改房间名或话题，空房间名表示不改；不带 topic 表示不改，带了空的 topic 表示清空
+type FormUpdateRoom struct {
+	RoomId int     `form:"roomId" json:"roomId" binding:"required"`
+	Name   string  `form:"name" json:"name"`
+	Topic  *string `form:"topic" json:"topic"`
+}
+
+func UpdateRoom(c *gin.Context) {
+	var formUpdateRoom FormUpdateRoom
+	if err := bindForm(c, &formUpdateRoom); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.RoomRequest{
+		UserId: int32(userId),
+		RoomId: int32(formUpdateRoom.RoomId),
+		Name:   formUpdateRoom.Name,
+	}
+	if formUpdateRoom.Topic != nil {
+		req.Topic = *formUpdateRoom.Topic
+		req.TopicSet = true
+	}
+	code, room, msg := rpc.RpcLogicObj.RoomOperate("UpdateRoom", req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", room)
+}
+
+// 我加入的房间
+func RoomList(c *gin.Context) {
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	code, rooms, msg := rpc.RpcLogicObj.GetRooms("ListRooms", &logic_pb.RoomRequest{UserId: int32(userId)})
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", rooms)
+}
+
+// 搜索公开房间，按房间名和话题匹配
+type FormRoomSearch struct {
+	Keyword string `form:"keyword" json:"keyword"`
+}
+
+func SearchRoom(c *gin.Context) {
+	var formRoomSearch FormRoomSearch
+	if err := bindForm(c, &formRoomSearch); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	code, rooms, msg := rpc.RpcLogicObj.GetRooms("SearchRooms", &logic_pb.RoomRequest{Keyword: formRoomSearch.Keyword})
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", rooms)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"yoyichat/api/rpc"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 搜索消息，可以按房间、发送者、时间范围 (毫秒时间戳) 和有无附件过滤
+// attachment 0:不限 1:带附件 2:不带附件；翻页时 cursorId 传上一页最后一条的 msgId
+type FormSearch struct {
+	Keyword    string `form:"keyword" json:"keyword" binding:"required"`
+	RoomId     int    `form:"roomId" json:"roomId"`
+	FromUserId int    `form:"fromUserId" json:"fromUserId"`
+	StartTime  int64  `form:"startTime" json:"startTime"`
+	EndTime    int64  `form:"endTime" json:"endTime"`
+	Attachment int    `form:"attachment" json:"attachment"`
+	CursorId   int64  `form:"cursorId" json:"cursorId"`
+	Limit      int    `form:"limit" json:"limit"`
+}
+
+func Search(c *gin.Context) {
+	var formSearch FormSearch
+	if err := bindForm(c, &formSearch); err != nil {
+		tools.FailWithMsg(c, err.Error())
+		return
+	}
+	userId, ok := authUserId(c)
+	if !ok {
+		return
+	}
+	req := &logic_pb.SearchRequest{
+		UserId:     int32(userId),
+		Keyword:    formSearch.Keyword,
+		RoomId:     int32(formSearch.RoomId),
+		FromUserId: int32(formSearch.FromUserId),
+		StartTime:  formSearch.StartTime,
+		EndTime:    formSearch.EndTime,
+		Attachment: int32(formSearch.Attachment),
+		CursorId:   formSearch.CursorId,
+		Limit:      int32(formSearch.Limit),
+	}
+	code, hits, hasMore, msg := rpc.RpcLogicObj.SearchMessages(req)
+	if code == tools.CodeFail {
+		tools.FailWithMsg(c, msg)
+		return
+	}
+	tools.SuccessWithMsg(c, "ok", gin.H{
+		"list":    hits,
+		"hasMore": hasMore,
+	})
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/gin-gonic/gin/binding"
 	"yoyichat/api/rpc"
 	"yoyichat/pb/logic_pb"
 	"yoyichat/tools"
@@ -11,88 +10,102 @@ import (
 type FormLogin struct {
 	UserName string `form:"userName" json:"userName" binding:"required"`
 	Password string `form:"passWord" json:"passWord" binding:"required"`
+	Device   string `form:"device" json:"device"` // 设备标识，多设备同时在线时区分各自的令牌
 }
 
 func Login(c *gin.Context) {
 	var formLogin FormLogin
-	if err := c.ShouldBindBodyWith(&formLogin, binding.JSON); err != nil {
+	if err := bindForm(c, &formLogin); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
 	req := &logic_pb.LoginRequest{
 		Name:     formLogin.UserName,
-		Password: tools.Sha1(formLogin.Password),
+		Password: formLogin.Password,
+		Device:   formLogin.Device,
 	}
-	code, authToken, msg := rpc.RpcLogicObj.Login(req)
+	code, authToken, refreshToken, expiresAt, msg := rpc.RpcLogicObj.Login(req)
 	if code == tools.CodeFail || authToken == "" {
 		tools.FailWithMsg(c, msg)
 		return
 	}
-	tools.SuccessWithMsg(c, "login success", authToken)
+	tools.SuccessWithMsg(c, "login success", authTokenData(authToken, refreshToken, expiresAt))
 }
 
 type FormRegister struct {
 	UserName string `form:"userName" json:"userName" binding:"required"`
 	Password string `form:"passWord" json:"passWord" binding:"required"`
+	Device   string `form:"device" json:"device"`
 }
 
 func Register(c *gin.Context) {
 	var formRegister FormRegister
-	if err := c.ShouldBindBodyWith(&formRegister, binding.JSON); err != nil {
+	if err := bindForm(c, &formRegister); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
 	req := &logic_pb.RegisterRequest{
 		Name:     formRegister.UserName,
-		Password: tools.Sha1(formRegister.Password),
+		Password: formRegister.Password,
+		Device:   formRegister.Device,
 	}
-	code, authToken, msg := rpc.RpcLogicObj.Register(req)
+	code, authToken, refreshToken, expiresAt, msg := rpc.RpcLogicObj.Register(req)
 	if code == tools.CodeFail || authToken == "" {
 		tools.FailWithMsg(c, msg)
 		return
 	}
-	tools.SuccessWithMsg(c, "register success", authToken)
+	tools.SuccessWithMsg(c, "register success", authTokenData(authToken, refreshToken, expiresAt))
 }
 
-type FormCheckAuth struct {
-	AuthToken string `form:"authToken" json:"authToken" binding:"required"`
+// 会话模式还是只返回令牌字符串，jwt 模式返回访问令牌、刷新令牌和访问令牌的过期时间
+func authTokenData(authToken string, refreshToken string, expiresAt int64) interface{} {
+	if refreshToken == "" {
+		return authToken
+	}
+	return gin.H{
+		"authToken":    authToken,
+		"refreshToken": refreshToken,
+		"expiresAt":    expiresAt,
+	}
 }
 
-func CheckAuth(c *gin.Context) {
-	var formCheckAuth FormCheckAuth
-	if err := c.ShouldBindBodyWith(&formCheckAuth, binding.JSON); err != nil {
+// jwt 模式下访问令牌过期前用刷新令牌换一对新的，刷新令牌只能用一次
+type FormRefreshToken struct {
+	RefreshToken string `form:"refreshToken" json:"refreshToken" binding:"required"`
+}
+
+func RefreshToken(c *gin.Context) {
+	var formRefresh FormRefreshToken
+	if err := bindForm(c, &formRefresh); err != nil {
 		tools.FailWithMsg(c, err.Error())
 		return
 	}
-	authToken := formCheckAuth.AuthToken
-	req := &logic_pb.CheckAuthRequest{
-		AuthToken: authToken,
+	req := &logic_pb.RefreshTokenRequest{RefreshToken: formRefresh.RefreshToken}
+	code, authToken, refreshToken, expiresAt, msg := rpc.RpcLogicObj.RefreshToken(req)
+	if code == tools.CodeFail || authToken == "" {
+		tools.FailWithMsg(c, msg)
+		return
 	}
-	code, userId, userName := rpc.RpcLogicObj.CheckAuth(req)
-	if code == tools.CodeFail {
-		tools.FailWithMsg(c, "auth fail")
+	tools.SuccessWithMsg(c, "refresh success", authTokenData(authToken, refreshToken, expiresAt))
+}
+
+// 令牌已经由认证中间件检查过，直接返回当前用户
+func CheckAuth(c *gin.Context) {
+	userId, ok := authUserId(c)
+	if !ok {
 		return
 	}
 	var jsonData = map[string]interface{}{
 		"userId":   userId,
-		"userName": userName,
+		"userName": authUserName(c),
 	}
 	tools.SuccessWithMsg(c, "auth success", jsonData)
 }
 
-type FormLogout struct {
-	AuthToken string `form:"authToken" json:"authToken" binding:"required"`
-}
-
+// 退出当前令牌所在的设备
 func Logout(c *gin.Context) {
-	var formLogout FormLogout
-	if err := c.ShouldBindBodyWith(&formLogout, binding.JSON); err != nil {
-		tools.FailWithMsg(c, err.Error())
-		return
-	}
-	authToken := formLogout.AuthToken
 	logoutReq := &logic_pb.LogoutRequest{
-		AuthToken: authToken,
+		AuthToken: c.GetString(ContextAuthToken),
 	}
 	code := rpc.RpcLogicObj.Logout(logoutReq)
 	if code == tools.CodeFail {
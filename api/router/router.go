@@ -9,6 +9,7 @@ import (
 	"yoyichat/tools"
 
 	"net/http"
+	"strings"
 )
 
 func Register() *gin.Engine {
@@ -19,6 +20,18 @@ func Register() *gin.Engine {
 	initUserRouter(r)
 	// 初始化推送路由
 	initPushRouter(r)
+	// 初始化历史消息路由
+	initHistoryRouter(r)
+	// 初始化联系人路由
+	initContactRouter(r)
+	// 初始化房间路由
+	initRoomRouter(r)
+	// 初始化在线状态路由
+	initPresenceRouter(r)
+	// 初始化附件路由
+	initAttachmentRouter(r)
+	// 初始化搜索路由
+	initSearchRouter(r)
 
 	// 自定义404处理
 	r.NoRoute(func(c *gin.Context) {
@@ -31,9 +44,11 @@ func initUserRouter(r *gin.Engine) {
 	userGroup := r.Group("/user")
 	userGroup.POST("/login", handler.Login)
 	userGroup.POST("/register", handler.Register)
+	userGroup.POST("/refresh", handler.RefreshToken)
 	userGroup.Use(CheckSessionId())
 	{
 		userGroup.POST("/checkAuth", handler.CheckAuth)
+		userGroup.GET("/checkAuth", handler.CheckAuth)
 		userGroup.POST("/logout", handler.Logout)
 	}
 
@@ -46,30 +61,115 @@ func initPushRouter(r *gin.Engine) {
 		pushGroup.POST("/push", handler.Push)
 		pushGroup.POST("/pushRoom", handler.PushRoom)
 		pushGroup.POST("/count", handler.Count)
+		pushGroup.GET("/count", handler.Count)
 		pushGroup.POST("/getRoomInfo", handler.GetRoomInfo)
+		pushGroup.GET("/getRoomInfo", handler.GetRoomInfo)
+		pushGroup.POST("/edit", handler.EditMsg)
+		pushGroup.POST("/delete", handler.DeleteMsg)
+		pushGroup.POST("/reaction/add", handler.AddReaction)
+		pushGroup.POST("/reaction/remove", handler.RemoveReaction)
+		pushGroup.POST("/reaction/list", handler.GetReactions)
+		pushGroup.GET("/reaction/list", handler.GetReactions)
 	}
 
 }
 
+func initHistoryRouter(r *gin.Engine) {
+	historyGroup := r.Group("/history")
+	historyGroup.Use(CheckSessionId())
+	{
+		historyGroup.POST("/single", handler.SingleHistory)
+		historyGroup.GET("/single", handler.SingleHistory)
+		historyGroup.POST("/room", handler.RoomHistory)
+		historyGroup.GET("/room", handler.RoomHistory)
+		historyGroup.POST("/unread", handler.Unread)
+		historyGroup.GET("/unread", handler.Unread)
+		historyGroup.POST("/thread", handler.ThreadHistory)
+		historyGroup.GET("/thread", handler.ThreadHistory)
+		historyGroup.POST("/roomThreads", handler.RoomThreads)
+		historyGroup.GET("/roomThreads", handler.RoomThreads)
+		historyGroup.POST("/thread/follow", handler.FollowThread)
+		historyGroup.POST("/thread/unfollow", handler.UnfollowThread)
+		historyGroup.POST("/mentions", handler.Mentions)
+		historyGroup.GET("/mentions", handler.Mentions)
+	}
+}
+
+func initContactRouter(r *gin.Engine) {
+	contactGroup := r.Group("/contact")
+	contactGroup.Use(CheckSessionId())
+	{
+		contactGroup.POST("/list", handler.ContactList)
+		contactGroup.GET("/list", handler.ContactList)
+		contactGroup.POST("/request", handler.FriendRequest)
+		contactGroup.POST("/accept", handler.AcceptFriend)
+		contactGroup.POST("/decline", handler.DeclineFriend)
+		contactGroup.POST("/remove", handler.RemoveContact)
+		contactGroup.POST("/block", handler.BlockUser)
+		contactGroup.POST("/unblock", handler.UnblockUser)
+	}
+}
+
+func initRoomRouter(r *gin.Engine) {
+	roomGroup := r.Group("/room")
+	roomGroup.Use(CheckSessionId())
+	{
+		roomGroup.POST("/create", handler.CreateRoom)
+		roomGroup.POST("/list", handler.RoomList)
+		roomGroup.GET("/list", handler.RoomList)
+		roomGroup.POST("/search", handler.SearchRoom)
+		roomGroup.GET("/search", handler.SearchRoom)
+		roomGroup.POST("/join", handler.JoinRoom)
+		roomGroup.POST("/leave", handler.LeaveRoom)
+		roomGroup.POST("/update", handler.UpdateRoom)
+		roomGroup.POST("/role", handler.SetRoomRole)
+		roomGroup.POST("/kick", handler.KickRoomMember)
+		roomGroup.POST("/ban", handler.BanRoomMember)
+		roomGroup.POST("/unban", handler.UnbanRoomMember)
+	}
+}
+
+func initPresenceRouter(r *gin.Engine) {
+	presenceGroup := r.Group("/presence")
+	presenceGroup.Use(CheckSessionId())
+	{
+		presenceGroup.POST("/set", handler.SetPresence)
+		presenceGroup.POST("/query", handler.QueryPresence)
+		presenceGroup.GET("/query", handler.QueryPresence)
+	}
+}
+
+func initSearchRouter(r *gin.Engine) {
+	r.POST("/search", CheckSessionId(), handler.Search)
+	r.GET("/search", CheckSessionId(), handler.Search)
+}
+
+// 上传先限制请求体大小再认证，令牌在请求头或者表单的 authToken 字段里
+func initAttachmentRouter(r *gin.Engine) {
+	attachmentGroup := r.Group("/attachment")
+	attachmentGroup.POST("/upload", handler.UploadSizeLimit(), CheckSessionId(), handler.UploadAttachment)
+	attachmentGroup.POST("/download", CheckSessionId(), handler.DownloadAttachment)
+	attachmentGroup.GET("/download", CheckSessionId(), handler.DownloadAttachment)
+}
+
 type FormCheckSessionId struct {
-	AuthToken string `form:"authToken" json:"authToken" binding:"required"`
+	AuthToken string `form:"authToken" json:"authToken"`
 }
 
-// 中间件：会话认证
+// 中间件：会话认证，解析出的用户放进上下文，handler 不用再查一遍
 func CheckSessionId() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var formCheckSessionId FormCheckSessionId
-		if err := c.ShouldBindBodyWith(&formCheckSessionId, binding.JSON); err != nil {
+		authToken := getAuthToken(c)
+		if authToken == "" {
 			c.Abort()
 			tools.ResponseWithCode(c, tools.CodeSessionError, nil, nil)
 			return
 		}
-		authToken := formCheckSessionId.AuthToken
 		req := &logic_pb.CheckAuthRequest{
 			AuthToken: authToken,
 		}
 
-		// 调logic rpc
+		// 调logic rpc，jwt 模式下本地验签
 		code, userId, userName := rpc.RpcLogicObj.CheckAuth(req)
 		if code == tools.CodeFail || userId <= 0 || userName == "" {
 			c.Abort()
@@ -78,21 +178,45 @@ func CheckSessionId() gin.HandlerFunc {
 		}
 
 		// 认证通过，后续处理
+		handler.SetAuthUser(c, authToken, userId, userName)
 		c.Next()
 		return
 	}
 }
 
+// 令牌优先从 Authorization: Bearer 请求头里取
+// 兼容原来的客户端：表单请求从 authToken 字段取，其他请求从 JSON 请求体的 authToken 取
+func getAuthToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	if c.Request.Method == http.MethodGet {
+		return ""
+	}
+	switch c.ContentType() {
+	case binding.MIMEMultipartPOSTForm, binding.MIMEPOSTForm:
+		return c.PostForm("authToken")
+	}
+	var formCheckSessionId FormCheckSessionId
+	if err := c.ShouldBindBodyWith(&formCheckSessionId, binding.JSON); err != nil {
+		return ""
+	}
+	return formCheckSessionId.AuthToken
+}
+
 // 跨域中间件
 func CorsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		method := c.Request.Method
 		var openCorsFlag = true
 		if openCorsFlag {
-			c.Header("Access-Control-Allow-Origin", "*")                                               // 允许所有源？允许所有域名访问资源，*表示允许所有源
-			c.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept") // 允许客户端发送额外请求头
-			c.Header("Access-Control-Allow-Methods", "GET, OPTIONS, POST, PUT, DELETE")                // 允许的HTTP方法
-			c.Set("content-type", "application/json")                                                  // 强制设置响应内容为JSON
+			c.Header("Access-Control-Allow-Origin", "*")                                                              // 允许所有源？允许所有域名访问资源，*表示允许所有源
+			c.Header("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Authorization") // 允许客户端发送额外请求头
+			c.Header("Access-Control-Allow-Methods", "GET, OPTIONS, POST, PUT, DELETE")                               // 允许的HTTP方法
+			c.Set("content-type", "application/json")                                                                 // 强制设置响应内容为JSON
 		}
 		if method == "OPTIONS" {
 			c.JSON(http.StatusOK, nil)
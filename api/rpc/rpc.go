@@ -6,6 +6,7 @@ import (
 	etcdV3 "github.com/rpcxio/rpcx-etcd/client"
 	"github.com/sirupsen/logrus"
 	"github.com/smallnest/rpcx/client"
+	"yoyichat/auth"
 	"yoyichat/config"
 	"yoyichat/pb/logic_pb"
 	"yoyichat/pb/task_pb"
@@ -51,7 +52,8 @@ func InitLogicRpcClient() {
 	}
 }
 
-func (rpc *RpcLogic) Login(req *logic_pb.LoginRequest) (code int, authToken string, msg string) {
+// 会话模式下只有 authToken，jwt 模式下还有刷新令牌和访问令牌的过期时间
+func (rpc *RpcLogic) Login(req *logic_pb.LoginRequest) (code int, authToken string, refreshToken string, expiresAt int64, msg string) {
 	reply := &logic_pb.LoginResponse{}
 	err := LogicRpcClient.Call(context.Background(), "Login", req, reply)
 	if err != nil {
@@ -59,10 +61,12 @@ func (rpc *RpcLogic) Login(req *logic_pb.LoginRequest) (code int, authToken stri
 	}
 	code = int(reply.Code)
 	authToken = reply.AuthToken
+	refreshToken = reply.RefreshToken
+	expiresAt = reply.ExpiresAt
 	return
 }
 
-func (rpc *RpcLogic) Register(req *logic_pb.RegisterRequest) (code int, authToken string, msg string) {
+func (rpc *RpcLogic) Register(req *logic_pb.RegisterRequest) (code int, authToken string, refreshToken string, expiresAt int64, msg string) {
 	reply := &logic_pb.RegisterReply{}
 	err := LogicRpcClient.Call(context.Background(), "Register", req, reply)
 	if err != nil {
@@ -70,6 +74,8 @@ func (rpc *RpcLogic) Register(req *logic_pb.RegisterRequest) (code int, authToke
 	}
 	code = int(reply.Code)
 	authToken = reply.AuthToken
+	refreshToken = reply.RefreshToken
+	expiresAt = reply.ExpiresAt
 	return
 }
 
@@ -81,7 +87,15 @@ func (rpc *RpcLogic) GetUserNameByUserId(req *logic_pb.GetUserInfoRequest) (code
 	return
 }
 
+// jwt 模式下本地验签，不调 logic
 func (rpc *RpcLogic) CheckAuth(req *logic_pb.CheckAuthRequest) (code int, userId int, userName string) {
+	if auth.Enabled() {
+		claims, err := auth.Parse(req.AuthToken)
+		if err != nil {
+			return config.FailReplyCode, 0, ""
+		}
+		return config.SuccessReplyCode, claims.UserId, claims.UserName
+	}
 	reply := &logic_pb.CheckAuthResponse{}
 	LogicRpcClient.Call(context.Background(), "CheckAuth", req, reply)
 	code = int(reply.Code)
@@ -128,3 +142,222 @@ func (rpc *RpcLogic) GetRoomInfo(req *logic_pb.SendMsg) (code int, msg string) {
 	msg = reply.Msg
 	return
 }
+
+func (rpc *RpcLogic) GetSingleHistory(req *logic_pb.HistoryRequest) (code int, msgs []*logic_pb.SendMsg, hasMore bool, msg string) {
+	reply := &logic_pb.HistoryReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetSingleHistory", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	msgs = reply.Msgs
+	hasMore = reply.HasMore
+	return
+}
+
+func (rpc *RpcLogic) GetRoomHistory(req *logic_pb.HistoryRequest) (code int, msgs []*logic_pb.SendMsg, hasMore bool, msg string) {
+	reply := &logic_pb.HistoryReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetRoomHistory", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	msgs = reply.Msgs
+	hasMore = reply.HasMore
+	return
+}
+
+// 查话题，返回根消息和分页的回复
+func (rpc *RpcLogic) GetThreadHistory(req *logic_pb.HistoryRequest) (code int, root *logic_pb.SendMsg, msgs []*logic_pb.SendMsg, hasMore bool, msg string) {
+	reply := &logic_pb.HistoryReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetThreadHistory", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	root = reply.Root
+	msgs = reply.Msgs
+	hasMore = reply.HasMore
+	return
+}
+
+func (rpc *RpcLogic) GetRoomThreads(req *logic_pb.HistoryRequest) (code int, msgs []*logic_pb.SendMsg, hasMore bool, msg string) {
+	reply := &logic_pb.HistoryReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetRoomThreads", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	msgs = reply.Msgs
+	hasMore = reply.HasMore
+	return
+}
+
+// 关注或取消关注话题，method 为 FollowThread / UnfollowThread
+func (rpc *RpcLogic) ThreadFollow(method string, req *logic_pb.ThreadFollowRequest) (code int, threadId int64, following bool, msg string) {
+	reply := &logic_pb.ThreadFollowReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	threadId = reply.ThreadId
+	following = reply.Following
+	return
+}
+
+func (rpc *RpcLogic) GetMentions(req *logic_pb.MentionRequest) (code int, mentions []*logic_pb.Mention, hasMore bool, msg string) {
+	reply := &logic_pb.MentionReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetMentions", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	mentions = reply.Mentions
+	hasMore = reply.HasMore
+	return
+}
+
+// 登记或查询附件，method 为 CreateAttachment / GetAttachment
+func (rpc *RpcLogic) Attachment(method string, req *logic_pb.AttachmentRequest) (code int, attachment *logic_pb.Attachment, storageKey string, msg string) {
+	reply := &logic_pb.AttachmentReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	attachment = reply.Attachment
+	storageKey = reply.StorageKey
+	return
+}
+
+// 认领过期的孤儿附件，返回要删除的文件
+func (rpc *RpcLogic) ClaimOrphanAttachments(req *logic_pb.OrphanAttachmentRequest) (code int, storageKeys []string, msg string) {
+	reply := &logic_pb.OrphanAttachmentReply{}
+	err := LogicRpcClient.Call(context.Background(), "ClaimOrphanAttachments", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	storageKeys = reply.StorageKeys
+	return
+}
+
+func (rpc *RpcLogic) SearchMessages(req *logic_pb.SearchRequest) (code int, hits []*logic_pb.SearchHit, hasMore bool, msg string) {
+	reply := &logic_pb.SearchReply{}
+	err := LogicRpcClient.Call(context.Background(), "SearchMessages", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	hits = reply.Hits
+	hasMore = reply.HasMore
+	return
+}
+
+func (rpc *RpcLogic) GetUnreadCount(req *logic_pb.UnreadRequest) (code int, counts []*logic_pb.UnreadCount, msg string) {
+	reply := &logic_pb.UnreadReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetUnreadCount", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	counts = reply.Counts
+	return
+}
+
+// 联系人操作，method 为 SendFriendRequest / AcceptFriendRequest / DeclineFriendRequest / RemoveContact / BlockUser / UnblockUser
+func (rpc *RpcLogic) ContactOperate(method string, req *logic_pb.ContactRequest) (code int, msg string) {
+	reply := &logic_pb.ContactReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	return
+}
+
+func (rpc *RpcLogic) GetContacts(req *logic_pb.ContactListRequest) (code int, contacts []*logic_pb.Contact, requests []*logic_pb.FriendRequest, msg string) {
+	reply := &logic_pb.ContactListReply{}
+	err := LogicRpcClient.Call(context.Background(), "GetContacts", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	contacts = reply.Contacts
+	requests = reply.Requests
+	return
+}
+
+// 房间操作，method 为 CreateRoom / JoinRoom / LeaveRoom / UpdateRoom / SetRoomRole / KickRoomMember / BanRoomMember / UnbanRoomMember
+func (rpc *RpcLogic) RoomOperate(method string, req *logic_pb.RoomRequest) (code int, room *logic_pb.Room, msg string) {
+	reply := &logic_pb.RoomReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	room = reply.Room
+	return
+}
+
+// 房间列表，method 为 ListRooms / SearchRooms
+func (rpc *RpcLogic) GetRooms(method string, req *logic_pb.RoomRequest) (code int, rooms []*logic_pb.Room, msg string) {
+	reply := &logic_pb.RoomListReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	rooms = reply.Rooms
+	return
+}
+
+// 在线状态，method 为 SetPresence / GetPresence
+func (rpc *RpcLogic) PresenceOperate(method string, req *logic_pb.PresenceRequest) (code int, presences []*logic_pb.Presence, msg string) {
+	reply := &logic_pb.PresenceReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	presences = reply.Presences
+	return
+}
+
+// 表情回应，method 为 AddReaction / RemoveReaction / GetReactions
+func (rpc *RpcLogic) Reaction(method string, req *logic_pb.ReactionRequest) (code int, reactions []*logic_pb.ReactionCount, msg string) {
+	reply := &logic_pb.ReactionReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	reactions = reply.Reactions
+	return
+}
+
+// 编辑或删除消息，method 为 EditMessage / DeleteMessage
+func (rpc *RpcLogic) MsgUpdate(method string, req *logic_pb.MsgUpdateRequest) (code int, sendMsg *logic_pb.SendMsg, msg string) {
+	reply := &logic_pb.MsgUpdateReply{}
+	err := LogicRpcClient.Call(context.Background(), method, req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	sendMsg = reply.Msg
+	return
+}
+
+func (rpc *RpcLogic) RefreshToken(req *logic_pb.RefreshTokenRequest) (code int, authToken string, refreshToken string, expiresAt int64, msg string) {
+	reply := &logic_pb.RefreshTokenReply{}
+	err := LogicRpcClient.Call(context.Background(), "RefreshToken", req, reply)
+	if err != nil {
+		msg = err.Error()
+	}
+	code = int(reply.Code)
+	authToken = reply.AuthToken
+	refreshToken = reply.RefreshToken
+	expiresAt = reply.ExpiresAt
+	return
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+	"yoyichat/config"
+	"yoyichat/tools"
+)
+
+// jwt 认证模式：访问令牌是 HS256 签名的 jwt，有效期短，api、connect、logic 各自本地验签，不用查 redis
+// 刷新令牌存在 redis 里，由 logic 签发和轮换；退出登录等需要提前作废的访问令牌记在吊销列表里
+// 认证模式由 common.toml 的 [common-auth] mode 决定，默认还是原来的会话令牌
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrTokenRevoked = errors.New("token revoked")
+)
+
+// 访问令牌里带的用户信息
+type Claims struct {
+	Issuer    string `json:"iss"`
+	Id        string `json:"jti"`
+	UserId    int    `json:"uid"`
+	UserName  string `json:"name"`
+	Device    string `json:"dev"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// 是否是 jwt 认证模式
+func Enabled() bool {
+	return config.Conf.Common.CommonAuth.Mode == config.AuthModeJwt
+}
+
+// 检查密钥配置并开始同步吊销列表，jwt 模式下各层启动时调用
+// redisClient 为空时按 common.toml 的 redis 配置取一个
+func Init(redisClient *redis.Client) (err error) {
+	if !Enabled() {
+		return
+	}
+	authConfig := config.Conf.Common.CommonAuth
+	if _, ok := authConfig.SigningKeys[authConfig.SigningKid]; !ok {
+		return fmt.Errorf("signing key %s not configured", authConfig.SigningKid)
+	}
+	for kid, secret := range authConfig.SigningKeys {
+		if len(secret) < minSecretLen {
+			return fmt.Errorf("signing key %s shorter than %d bytes", kid, minSecretLen)
+		}
+	}
+	if redisClient == nil {
+		redisClient = tools.GetRedisInstance(tools.RedisOption{
+			Address:  config.Conf.Common.CommonRedis.RedisAddress,
+			Password: config.Conf.Common.CommonRedis.RedisPassword,
+			Db:       config.Conf.Common.CommonRedis.Db,
+		})
+	}
+	return startRevokeSync(redisClient)
+}
+
+// 签发访问令牌
+func Issue(userId int, userName string, device string) (token string, claims *Claims, err error) {
+	now := time.Now()
+	claims = &Claims{
+		Issuer:    config.JwtIssuer,
+		Id:        tools.GetRandomToken(16),
+		UserId:    userId,
+		UserName:  userName,
+		Device:    device,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(AccessTokenTTL()).Unix(),
+	}
+	token, err = sign(claims)
+	return
+}
+
+// 验证访问令牌：签名、签发方、有效期，以及是否已被吊销
+func Parse(token string) (claims *Claims, err error) {
+	claims = &Claims{}
+	if err = verify(token, claims); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != config.JwtIssuer || claims.UserId <= 0 || claims.Id == "" {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	if isRevoked(claims.Id) {
+		return nil, ErrTokenRevoked
+	}
+	return
+}
+
+func AccessTokenTTL() time.Duration {
+	if ttl := config.Conf.Common.CommonAuth.AccessTokenTTL; ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return config.AccessTokenDefaultTTL * time.Second
+}
+
+func RefreshTokenTTL() time.Duration {
+	if ttl := config.Conf.Common.CommonAuth.RefreshTokenTTL; ttl > 0 {
+		return time.Duration(ttl) * time.Second
+	}
+	return config.RefreshTokenDefaultTTL * time.Second
+}
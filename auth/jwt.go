@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"yoyichat/config"
+)
+
+// HS256 的签名密钥至少32字节
+const minSecretLen = 32
+
+const algHS256 = "HS256"
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// 用当前的签名密钥签名，令牌头里带上 kid
+func sign(claims *Claims) (string, error) {
+	authConfig := config.Conf.Common.CommonAuth
+	headerBytes, err := json.Marshal(header{Alg: algHS256, Typ: "JWT", Kid: authConfig.SigningKid})
+	if err != nil {
+		return "", err
+	}
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodeSegment(headerBytes) + "." + encodeSegment(claimsBytes)
+	signature := hmacSHA256(authConfig.SigningKeys[authConfig.SigningKid], signingInput)
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// 按令牌头里的 kid 找密钥验签，换下来但还没删的旧密钥签的令牌也能通过
+func verify(token string, claims *Claims) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidToken
+	}
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != algHS256 {
+		return ErrInvalidToken
+	}
+	secret, ok := config.Conf.Common.CommonAuth.SigningKeys[h.Kid]
+	if !ok {
+		return ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, hmacSHA256(secret, parts[0]+"."+parts[1])) {
+		return ErrInvalidToken
+	}
+	if err = decodeSegment(parts[1], claims); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func hmacSHA256(secret string, input string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"yoyichat/config"
+)
+
+const (
+	testSecretOld = "old-secret-0123456789abcdefghijklmnop"
+	testSecretNew = "new-secret-0123456789abcdefghijklmnop"
+)
+
+// 换成测试用的 jwt 配置，测试结束后还原
+func setupAuthConfig(t *testing.T) {
+	t.Helper()
+	old := config.Conf.Common.CommonAuth
+	config.Conf.Common.CommonAuth = config.CommonAuth{
+		Mode:           config.AuthModeJwt,
+		AccessTokenTTL: 60,
+		SigningKid:     "k2",
+		SigningKeys:    map[string]string{"k1": testSecretOld, "k2": testSecretNew},
+	}
+	t.Cleanup(func() { config.Conf.Common.CommonAuth = old })
+}
+
+// 按给定的令牌头和密钥手工拼一个令牌，用来构造各种不合法的令牌
+func makeToken(t *testing.T, h header, claims *Claims, secret string) string {
+	t.Helper()
+	headerBytes, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := encodeSegment(headerBytes) + "." + encodeSegment(claimsBytes)
+	return signingInput + "." + encodeSegment(hmacSHA256(secret, signingInput))
+}
+
+func validClaims() *Claims {
+	now := time.Now()
+	return &Claims{
+		Issuer:    config.JwtIssuer,
+		Id:        "jti-valid",
+		UserId:    1,
+		UserName:  "alice",
+		Device:    "pc",
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestIssueAndParse(t *testing.T) {
+	setupAuthConfig(t)
+	token, issued, err := Issue(7, "bob", "phone")
+	if err != nil {
+		t.Fatalf("Issue err: %s", err)
+	}
+	claims, err := Parse(token)
+	if err != nil {
+		t.Fatalf("Parse err: %s", err)
+	}
+	if claims.UserId != 7 || claims.UserName != "bob" || claims.Device != "phone" || claims.Id != issued.Id {
+		t.Errorf("Parse claims = %+v, want %+v", claims, issued)
+	}
+	if want := time.Now().Add(AccessTokenTTL()).Unix(); claims.ExpiresAt > want || claims.ExpiresAt < want-5 {
+		t.Errorf("ExpiresAt = %d, want about %d", claims.ExpiresAt, want)
+	}
+}
+
+func TestParseAlgPinning(t *testing.T) {
+	setupAuthConfig(t)
+	claims := validClaims()
+	noneToken := makeToken(t, header{Alg: "none", Typ: "JWT", Kid: "k2"}, claims, testSecretNew)
+	noneToken = noneToken[:strings.LastIndex(noneToken, ".")+1]
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"alg none without signature", noneToken},
+		{"alg none with signature", makeToken(t, header{Alg: "none", Typ: "JWT", Kid: "k2"}, claims, testSecretNew)},
+		{"alg RS256", makeToken(t, header{Alg: "RS256", Typ: "JWT", Kid: "k2"}, claims, testSecretNew)},
+		{"alg HS512", makeToken(t, header{Alg: "HS512", Typ: "JWT", Kid: "k2"}, claims, testSecretNew)},
+		{"alg lower case", makeToken(t, header{Alg: "hs256", Typ: "JWT", Kid: "k2"}, claims, testSecretNew)},
+		{"alg empty", makeToken(t, header{Typ: "JWT", Kid: "k2"}, claims, testSecretNew)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Parse err = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestParseKid(t *testing.T) {
+	setupAuthConfig(t)
+	claims := validClaims()
+	tests := []struct {
+		name    string
+		token   string
+		removed string // 验签前删掉的密钥，模拟旧密钥轮换下线
+		wantErr error
+	}{
+		{"current kid", makeToken(t, header{Alg: algHS256, Kid: "k2"}, claims, testSecretNew), "", nil},
+		{"rotated kid still configured", makeToken(t, header{Alg: algHS256, Kid: "k1"}, claims, testSecretOld), "", nil},
+		{"rotated kid removed", makeToken(t, header{Alg: algHS256, Kid: "k1"}, claims, testSecretOld), "k1", ErrInvalidToken},
+		{"unknown kid", makeToken(t, header{Alg: algHS256, Kid: "k9"}, claims, testSecretNew), "", ErrInvalidToken},
+		{"empty kid", makeToken(t, header{Alg: algHS256}, claims, testSecretNew), "", ErrInvalidToken},
+		{"kid of another key", makeToken(t, header{Alg: algHS256, Kid: "k2"}, claims, testSecretOld), "", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupAuthConfig(t)
+			if tt.removed != "" {
+				delete(config.Conf.Common.CommonAuth.SigningKeys, tt.removed)
+			}
+			if _, err := Parse(tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseClaims(t *testing.T) {
+	setupAuthConfig(t)
+	token := makeToken(t, header{Alg: algHS256, Kid: "k2"}, validClaims(), testSecretNew)
+	parts := strings.Split(token, ".")
+	tampered := validClaims()
+	tampered.UserId = 2
+	tamperedBytes, _ := json.Marshal(tampered)
+	tests := []struct {
+		name    string
+		modify  func(c *Claims)
+		token   string // 不为空时直接用这个令牌
+		wantErr error
+	}{
+		{"valid", func(c *Claims) {}, "", nil},
+		{"expired", func(c *Claims) { c.ExpiresAt = time.Now().Add(-time.Second).Unix() }, "", ErrTokenExpired},
+		{"expires now", func(c *Claims) { c.ExpiresAt = time.Now().Unix() }, "", ErrTokenExpired},
+		{"wrong issuer", func(c *Claims) { c.Issuer = "other" }, "", ErrInvalidToken},
+		{"no user", func(c *Claims) { c.UserId = 0 }, "", ErrInvalidToken},
+		{"no jti", func(c *Claims) { c.Id = "" }, "", ErrInvalidToken},
+		{"tampered payload", nil, parts[0] + "." + encodeSegment(tamperedBytes) + "." + parts[2], ErrInvalidToken},
+		{"two segments", nil, parts[0] + "." + parts[1], ErrInvalidToken},
+		{"garbage", nil, "not-a-token", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token
+			if token == "" {
+				claims := validClaims()
+				tt.modify(claims)
+				token = makeToken(t, header{Alg: algHS256, Kid: "k2"}, claims, testSecretNew)
+			}
+			if _, err := Parse(token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
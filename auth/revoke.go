@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"sync"
+	"time"
+	"yoyichat/config"
+)
+
+// 吊销列表：提前作废的访问令牌记在 redis 的 zset 里，分数是令牌的过期时间，过期后顺手清掉
+// 各层定时拉一份到内存里验签时查，所以吊销最多延迟一个同步间隔生效
+
+var revokeClient *redis.Client
+
+var revoked = struct {
+	sync.RWMutex
+	jtis map[string]int64 // jti => 过期时间
+}{jtis: make(map[string]int64)}
+
+var revokeSyncOnce sync.Once
+
+func startRevokeSync(redisClient *redis.Client) (err error) {
+	revokeClient = redisClient
+	if err = syncRevoked(); err != nil {
+		return
+	}
+	revokeSyncOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(revokeSyncPeriod())
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := syncRevoked(); err != nil {
+					logrus.Warnf("auth sync revoked tokens err:%s", err.Error())
+				}
+			}
+		}()
+	})
+	return
+}
+
+// 吊销访问令牌，本进程立即生效，其他进程下次同步时生效
+func Revoke(claims *Claims) error {
+	if claims.ExpiresAt <= time.Now().Unix() {
+		return nil
+	}
+	if err := revokeClient.ZAdd(config.RedisJwtRevokedKey, redis.Z{
+		Score:  float64(claims.ExpiresAt),
+		Member: claims.Id,
+	}).Err(); err != nil {
+		return err
+	}
+	revoked.Lock()
+	revoked.jtis[claims.Id] = claims.ExpiresAt
+	revoked.Unlock()
+	return nil
+}
+
+func isRevoked(jti string) bool {
+	revoked.RLock()
+	_, ok := revoked.jtis[jti]
+	revoked.RUnlock()
+	return ok
+}
+
+func syncRevoked() error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := revokeClient.ZRemRangeByScore(config.RedisJwtRevokedKey, "-inf", now).Err(); err != nil {
+		return err
+	}
+	list, err := revokeClient.ZRangeByScoreWithScores(config.RedisJwtRevokedKey, redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	if err != nil {
+		return err
+	}
+	jtis := make(map[string]int64, len(list))
+	for _, z := range list {
+		if jti, ok := z.Member.(string); ok {
+			jtis[jti] = int64(z.Score)
+		}
+	}
+	revoked.Lock()
+	revoked.jtis = jtis
+	revoked.Unlock()
+	return nil
+}
+
+func revokeSyncPeriod() time.Duration {
+	if sec := config.Conf.Common.CommonAuth.RevokeSyncSec; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return config.RevokeSyncDefaultPeriod * time.Second
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"slices"
+	"testing"
+	"time"
+	"yoyichat/config"
+)
+
+func setupRevokeRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	if err := startRevokeSync(client); err != nil {
+		t.Fatalf("startRevokeSync err: %s", err)
+	}
+	return mr
+}
+
+func revokedInRedis(t *testing.T, mr *miniredis.Miniredis, jti string) bool {
+	t.Helper()
+	members, _ := mr.ZMembers(config.RedisJwtRevokedKey)
+	return slices.Contains(members, jti)
+}
+
+func TestRevoke(t *testing.T) {
+	setupAuthConfig(t)
+	mr := setupRevokeRedis(t)
+
+	token, claims, err := Issue(1, "alice", "pc")
+	if err != nil {
+		t.Fatalf("Issue err: %s", err)
+	}
+	if err = Revoke(claims); err != nil {
+		t.Fatalf("Revoke err: %s", err)
+	}
+	// 本进程立即生效
+	if _, err = Parse(token); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Parse err = %v, want %v", err, ErrTokenRevoked)
+	}
+	score, err := mr.ZScore(config.RedisJwtRevokedKey, claims.Id)
+	if err != nil {
+		t.Fatalf("revoked jti not in zset: %s", err)
+	}
+	if int64(score) != claims.ExpiresAt {
+		t.Errorf("zset score = %d, want expiresAt %d", int64(score), claims.ExpiresAt)
+	}
+
+	// 已经过期的令牌不用进吊销列表
+	expired := &Claims{Id: "jti-expired", ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+	if err = Revoke(expired); err != nil {
+		t.Fatalf("Revoke expired err: %s", err)
+	}
+	if revokedInRedis(t, mr, expired.Id) {
+		t.Errorf("expired jti should not be added to zset")
+	}
+}
+
+func TestSyncRevoked(t *testing.T) {
+	setupAuthConfig(t)
+	mr := setupRevokeRedis(t)
+
+	otherToken, otherClaims, err := Issue(2, "bob", "pc")
+	if err != nil {
+		t.Fatalf("Issue err: %s", err)
+	}
+	keptToken, _, err := Issue(3, "carol", "pc")
+	if err != nil {
+		t.Fatalf("Issue err: %s", err)
+	}
+	// 其他进程吊销的令牌，同步之前本进程还认
+	if _, err = mr.ZAdd(config.RedisJwtRevokedKey, float64(otherClaims.ExpiresAt), otherClaims.Id); err != nil {
+		t.Fatal(err)
+	}
+	staleJti := "jti-stale"
+	if _, err = mr.ZAdd(config.RedisJwtRevokedKey, float64(time.Now().Add(-time.Minute).Unix()), staleJti); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Parse(otherToken); err != nil {
+		t.Fatalf("Parse before sync err: %s", err)
+	}
+	if err = syncRevoked(); err != nil {
+		t.Fatalf("syncRevoked err: %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"revoked by another process", otherToken, ErrTokenRevoked},
+		{"not revoked", keptToken, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	// 过期的吊销记录同步时清掉
+	if revokedInRedis(t, mr, staleJti) {
+		t.Errorf("stale jti should be removed from zset")
+	}
+	if isRevoked(staleJti) {
+		t.Errorf("stale jti should not be kept in memory")
+	}
+}
@@ -0,0 +1,32 @@
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"yoyichat/config"
+)
+
+// 附件等文件的存储抽象，api层上传下载只通过 Store 读写
+// 具体用哪种实现由 api.toml 的 [api-upload] store 决定，默认存在本地磁盘
+
+var ErrNotFound = errors.New("blob not found")
+
+type Store interface {
+	// 写入文件，key 已存在时覆盖
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// 读取文件，不存在时返回 ErrNotFound
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+func NewStore() (Store, error) {
+	uploadConfig := config.Conf.Api.ApiUpload
+	switch uploadConfig.Store {
+	case config.BlobStoreLocal, "":
+		return newLocalStore(uploadConfig.LocalDir)
+	case config.BlobStoreS3:
+		return newS3Store(uploadConfig.S3Bucket)
+	}
+	return nil, fmt.Errorf("unknown blob store:%s", uploadConfig.Store)
+}
@@ -0,0 +1,48 @@
+package blob
+
+import (
+	"errors"
+	"io"
+)
+
+// S3 兼容存储，不直接依赖某个SDK：接入时实现 S3Client 并在启动前调用 SetS3Client
+// aws-sdk、minio-go 之类的客户端包一层就能用
+type S3Client interface {
+	PutObject(bucket, key string, r io.Reader, size int64, contentType string) error
+	// 对象不存在时返回 ErrNotFound
+	GetObject(bucket, key string) (io.ReadCloser, error)
+	DeleteObject(bucket, key string) error
+}
+
+var s3Client S3Client
+
+func SetS3Client(client S3Client) {
+	s3Client = client
+}
+
+type s3Store struct {
+	client S3Client
+	bucket string
+}
+
+func newS3Store(bucket string) (*s3Store, error) {
+	if s3Client == nil {
+		return nil, errors.New("blob s3 client not set")
+	}
+	if bucket == "" {
+		return nil, errors.New("blob s3 bucket empty")
+	}
+	return &s3Store{client: s3Client, bucket: bucket}, nil
+}
+
+func (s *s3Store) Put(key string, r io.Reader, size int64, contentType string) error {
+	return s.client.PutObject(s.bucket, key, r, size, contentType)
+}
+
+func (s *s3Store) Get(key string) (io.ReadCloser, error) {
+	return s.client.GetObject(s.bucket, key)
+}
+
+func (s *s3Store) Delete(key string) error {
+	return s.client.DeleteObject(s.bucket, key)
+}
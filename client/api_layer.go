@@ -7,6 +7,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 )
@@ -87,9 +88,12 @@ func handleLogin() (string, string) {
 	wg.Wait()
 
 	// 4. 准备登录请求 (对应时序图步骤1)
+	// 设备标识用主机名，同一台机器重复登录只顶掉自己，不影响其他设备
+	hostname, _ := os.Hostname()
 	loginData, _ := json.Marshal(map[string]string{
 		"userName": model.username.Value(),
 		"passWord": model.password.Value(),
+		"device":   "terminal-" + hostname,
 	})
 
 	// 5. 发送认证请求 (对应时序图步骤2)
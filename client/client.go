@@ -20,6 +20,15 @@ const (
 	pushRoomPath    = "/push/pushRoom"
 	countPath       = "/path/count"
 
+	// /contact 路由 联系人相关
+	contactListPath    = "/contact/list"
+	contactRequestPath = "/contact/request"
+	contactAcceptPath  = "/contact/accept"
+	contactDeclinePath = "/contact/decline"
+	contactRemovePath  = "/contact/remove"
+	contactBlockPath   = "/contact/block"
+	contactUnblockPath = "/contact/unblock"
+
 	// ws
 	connectPath = "/ws"
 )
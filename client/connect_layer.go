@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
 	"net/http"
 	"time"
+	"yoyichat/config"
 	"yoyichat/pb/logic_pb"
 )
 
@@ -30,9 +32,10 @@ func (m *model) initWebSocket() {
 		AuthToken: m.token,
 		ServerId:  m.serverId, // 如果需要
 		RoomId:    m.roomId,   // 如果需要加入房间
+		Ack:       true,       // 收到单聊消息会回确认，服务端据此跟踪重传
 	}
 
-	msgData, err := json.Marshal(authReq)
+	msgData, err := json.Marshal(&authReq)
 	if err != nil {
 		m.err = fmt.Errorf("认证消息序列化失败: %v", err)
 		return
@@ -68,9 +71,8 @@ func (m *model) receiveMessages() {
 			continue
 		}
 
-		// 解析为消息或在线用户更新
-		var msg Message
-		if err := json.Unmarshal(msgBytes, &msg); err == nil {
+		// connect层写过来的是 protobuf，带消息体的推送按 SendMsg 解析，其余的当作在线用户更新
+		if msg, ok := decodeMessage(msgBytes); ok {
 			// 处理消息
 			m.processMessage(msg)
 		} else {
@@ -83,8 +85,75 @@ func (m *model) receiveMessages() {
 	}
 }
 
-// 处理收到的消息
+// 解析connect层推来的消息体，只有单聊、群聊、编辑、删除、话题回复、@提醒带 SendMsg
+// 其他事件的结构不一样，解析出来的op对不上，直接跳过
+func decodeMessage(body []byte) (msg Message, ok bool) {
+	sendMsg := &logic_pb.SendMsg{}
+	if err := proto.Unmarshal(body, sendMsg); err != nil {
+		return
+	}
+	switch sendMsg.Op {
+	case config.OpSingleSend, config.OpRoomSend, config.OpMsgEdit, config.OpMsgDelete, config.OpThreadReply, config.OpMention:
+	default:
+		return
+	}
+	msg = Message{
+		MsgId:     sendMsg.MsgId,
+		Op:        int(sendMsg.Op),
+		Sender:    sendMsg.FromUserName,
+		Content:   sendMsg.Msg,
+		Timestamp: time.UnixMilli(sendMsg.Timestamp),
+		Edited:    sendMsg.Edited,
+		Deleted:   sendMsg.Deleted,
+	}
+	if sendMsg.RoomId == 0 {
+		msg.Recipient = sendMsg.ToUserName
+	}
+	return msg, true
+}
+
+// 回送达确认，服务端收不到确认会重传
+func (m *model) sendAck(msgId int64) {
+	ack := logic_pb.SendTcpMsg{
+		Op:    config.OpMsgAck,
+		MsgId: msgId,
+	}
+	ackData, err := json.Marshal(&ack)
+	if err != nil {
+		return
+	}
+	if err := m.wsConn.WriteMessage(websocket.TextMessage, ackData); err != nil {
+		m.status = "发送确认失败: " + err.Error()
+	}
+}
+
+// 处理收到的消息，编辑、删除事件更新已有的消息
+// 单聊消息先回确认；重传、离线重推可能收到同一条消息多次，按消息ID去重
 func (m *model) processMessage(msg Message) {
+	if msg.Op == config.OpSingleSend && msg.MsgId > 0 {
+		m.sendAck(msg.MsgId)
+	}
+	if msg.MsgId > 0 && (msg.Op == config.OpSingleSend || msg.Op == config.OpRoomSend) {
+		if m.seenMsgIds[msg.MsgId] {
+			return
+		}
+		m.seenMsgIds[msg.MsgId] = true
+	}
+	if msg.Op == config.OpMention {
+		m.status = msg.Sender + " 在房间里@了你: " + msg.Content
+		return
+	}
+	if msg.Op == config.OpMsgEdit || msg.Op == config.OpMsgDelete {
+		for i := range m.messages {
+			if m.messages[i].MsgId == msg.MsgId {
+				m.messages[i].Content = msg.Content
+				m.messages[i].Edited = msg.Edited
+				m.messages[i].Deleted = msg.Deleted
+				return
+			}
+		}
+		return
+	}
 	m.messages = append(m.messages, msg)
 }
 
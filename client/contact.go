@@ -0,0 +1,165 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// 联系人，字段和api层返回的json一致
+type contact struct {
+	UserId   int32  `json:"user_id"`
+	UserName string `json:"user_name"`
+	Status   string `json:"status"`
+	Online   bool   `json:"online"`
+}
+
+type friendRequest struct {
+	Id           int64  `json:"id"`
+	FromUserName string `json:"from_user_name"`
+	ToUserName   string `json:"to_user_name"`
+	Message      string `json:"message"`
+}
+
+// 联系人列表拉取结果，交给 Update 更新界面
+type rosterMsg struct {
+	contacts []contact
+	requests []friendRequest
+	err      error
+}
+
+// 联系人操作结果
+type contactResultMsg struct {
+	status string
+	err    error
+}
+
+// 调用api层，返回data字段
+func (m *model) postApi(path string, body map[string]interface{}, data interface{}) error {
+	body["authToken"] = m.token
+	reqData, _ := json.Marshal(body)
+	req, err := http.NewRequest("POST", apiBase+path, strings.NewReader(string(reqData)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Code    int             `json:"code"`
+		Message interface{}     `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return errors.New(fmt.Sprint(result.Message))
+	}
+	if data != nil && len(result.Data) > 0 {
+		return json.Unmarshal(result.Data, data)
+	}
+	return nil
+}
+
+// 拉取联系人列表
+func (m *model) fetchRoster() tea.Cmd {
+	return func() tea.Msg {
+		var data struct {
+			Contacts []contact       `json:"contacts"`
+			Requests []friendRequest `json:"requests"`
+		}
+		err := m.postApi(contactListPath, map[string]interface{}{}, &data)
+		return rosterMsg{contacts: data.Contacts, requests: data.Requests, err: err}
+	}
+}
+
+// 按用户名找好友的用户ID，找不到返回0
+func (m *model) contactId(userName string) int32 {
+	for _, c := range m.roster {
+		if c.UserName == userName && c.Status == "friend" {
+			return c.UserId
+		}
+	}
+	return 0
+}
+
+// 处理联系人命令：/add 用户 [附言]、/accept 用户、/decline 用户、/remove 用户、/block 用户、/unblock 用户
+func (m *model) contactCommand(content string) (tea.Cmd, bool) {
+	parts := strings.SplitN(content, " ", 3)
+	if len(parts) < 2 {
+		return nil, false
+	}
+	cmd, userName := parts[0], parts[1]
+	body := map[string]interface{}{"targetUserName": userName}
+	var path string
+	switch cmd {
+	case "/add":
+		path = contactRequestPath
+		if len(parts) == 3 {
+			body["message"] = parts[2]
+		}
+	case "/accept", "/decline":
+		path = contactAcceptPath
+		if cmd == "/decline" {
+			path = contactDeclinePath
+		}
+		requestId := int64(0)
+		for _, r := range m.requests {
+			if r.FromUserName == userName {
+				requestId = r.Id
+			}
+		}
+		if requestId == 0 {
+			m.status = "没有 " + userName + " 的好友申请"
+			return nil, true
+		}
+		body["requestId"] = requestId
+	case "/remove":
+		path = contactRemovePath
+	case "/block":
+		path = contactBlockPath
+	case "/unblock":
+		path = contactUnblockPath
+	default:
+		return nil, false
+	}
+	return func() tea.Msg {
+		if err := m.postApi(path, body, nil); err != nil {
+			return contactResultMsg{err: err}
+		}
+		return contactResultMsg{status: cmd[1:] + " " + userName + " 成功"}
+	}, true
+}
+
+// 联系人视图
+func (m model) rosterView() string {
+	view := userListStyle.Render(" 联系人:\n\n")
+	for _, c := range m.roster {
+		state := "离线"
+		if c.Status == "blocked" {
+			state = "已拉黑"
+		} else if c.Online {
+			state = "在线"
+		}
+		view += fmt.Sprintf("• %s (%s)\n", c.UserName, state)
+	}
+	var incoming []string
+	for _, r := range m.requests {
+		if r.ToUserName == m.username {
+			incoming = append(incoming, fmt.Sprintf("• %s: %s", r.FromUserName, r.Message))
+		}
+	}
+	if len(incoming) > 0 {
+		view += "\n 好友申请 (/accept 用户 接受, /decline 用户 拒绝):\n" + strings.Join(incoming, "\n") + "\n"
+	}
+	return view
+}
@@ -7,22 +7,23 @@ import (
 	"strconv"
 	"strings"
 	"time"
-	"yoyichat/logic/dao"
+	"yoyichat/tools"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // api层的push请求体
 type formPush struct {
 	Msg       string `form:"msg" json:"msg" binding:"required"`
 	ToUserId  string `form:"toUserId" json:"toUserId" binding:"required"`
-	RoomId    int    `form:"roomId" json:"roomId" binding:"required"`
 	AuthToken string `form:"authToken" json:"authToken" binding:"required"`
 }
 
 // 发送消息
-func (m *model) pushMessage() {
+func (m *model) pushMessage() tea.Cmd {
 	content := strings.TrimSpace(m.input.Value())
 	if content == "" {
-		return
+		return nil
 	}
 
 	// 处理退出命令
@@ -30,14 +31,30 @@ func (m *model) pushMessage() {
 		os.Exit(0)
 	}
 
-	// 处理私聊消息
+	// 处理联系人命令
+	if cmd, ok := m.contactCommand(content); ok {
+		m.input.SetValue("")
+		return cmd
+	}
+
+	// 在房间里时 "@用户 内容" 是群聊里的@提及，原样发到房间，由服务端解析提醒
+	if m.roomId > 0 {
+		return m.pushRoomMessage(content)
+	}
+
+	// 不在房间里时 "@好友 内容" 是私聊，和服务端用同一套解析
 	recipient := ""
-	if strings.HasPrefix(content, "@") {
-		parts := strings.SplitN(content[1:], " ", 2)
-		if len(parts) == 2 {
-			recipient = parts[0]
-			content = parts[1]
-		}
+	if name, rest, ok := tools.SplitLeadingMention(content); ok {
+		recipient = name
+		content = rest
+	}
+	// 没有@时发给当前私聊对象
+	if recipient == "" {
+		recipient = m.recipient
+	}
+	if recipient == "" {
+		m.status = "私聊请输入 '@好友 内容'"
+		return nil
 	}
 
 	// 本地显示：
@@ -48,13 +65,16 @@ func (m *model) pushMessage() {
 		Timestamp: time.Now(),
 	}
 
-	// 请求体发送：
-	toUserIdStr := strconv.Itoa(new(dao.User).GetUserIdByUserName(m.recipient))
+	// 请求体发送：私聊对象从联系人列表里找用户ID
+	if m.contactId(recipient) == 0 {
+		m.status = recipient + " 不是你的好友，先用 /add " + recipient + " 加好友"
+		return nil
+	}
+	toUserIdStr := strconv.Itoa(int(m.contactId(recipient)))
 	fp := &formPush{
 		Msg:       content,
 		ToUserId:  toUserIdStr,
-		RoomId:    0,
-		AuthToken: "",
+		AuthToken: m.token,
 	}
 	msgData, _ := json.Marshal(fp)
 
@@ -72,14 +92,13 @@ func (m *model) pushMessage() {
 	}()
 
 	// 如果是私聊消息，在UI中保存
-	if recipient != "" {
-		m.recipient = recipient
-	}
+	m.recipient = recipient
 
 	// 添加消息到本地显示
 
 	m.messages = append(m.messages, msg)
 	m.input.SetValue("")
+	return nil
 }
 
 // 群聊消息
@@ -88,3 +107,28 @@ type formRoom struct {
 	Msg       string `form:"msg" json:"msg" binding:"required"`
 	RoomId    int    `form:"roomId" json:"roomId" binding:"required"`
 }
+
+// 发送群聊消息，@提及留在内容里
+func (m *model) pushRoomMessage(content string) tea.Cmd {
+	fr := &formRoom{
+		AuthToken: m.token,
+		Msg:       content,
+		RoomId:    int(m.roomId),
+	}
+	msgData, _ := json.Marshal(fr)
+
+	go func() {
+		req, _ := http.NewRequest("POST", apiBase+pushRoomPath, strings.NewReader(string(msgData)))
+		req.Header.Set("Authorization", "Bearer "+m.token)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	// 群聊消息会广播回来，不在本地重复添加
+	m.input.SetValue("")
+	return nil
+}
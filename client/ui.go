@@ -13,10 +13,25 @@ import (
 
 // 消息模型
 type Message struct {
+	MsgId     int64     `json:"msgId"`
+	Op        int       `json:"op"`
 	Sender    string    `json:"sender"`
 	Recipient string    `json:"recipient"`
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+	Edited    bool      `json:"edited"`
+	Deleted   bool      `json:"deleted"`
+}
+
+// 消息显示的内容，编辑过的加标记，删除的只显示提示
+func (msg Message) displayContent() string {
+	if msg.Deleted {
+		return "message deleted"
+	}
+	if msg.Edited {
+		return msg.Content + " (edited)"
+	}
+	return msg.Content
 }
 
 // UI 状态类型
@@ -25,6 +40,8 @@ type model struct {
 	input       textinput.Model // 输入框
 	recipient   string          // 当前聊天对象
 	onlineUsers []string        // 在线用户列表
+	roster      []contact       // 联系人列表
+	requests    []friendRequest // 待处理的好友申请
 	status      string          // 状态信息
 	err         error           // 错误信息
 	width       int             // 终端宽度
@@ -37,12 +54,13 @@ type model struct {
 	userId      string          // 用户ID
 	serverId    string          // connect层ID
 	roomId      int32           // 房间号
+	seenMsgIds  map[int64]bool  // 已经收到过的消息ID，去掉重传的重复消息
 }
 
 // 初始化客户端
 func NewIMClient(token, username string) model {
 	ti := textinput.New()
-	ti.Placeholder = "输入消息 (输入 '@用户 内容' 私聊, 输入 '/exit' 退出)"
+	ti.Placeholder = "输入消息 (输入 '@好友 内容' 私聊, 房间里是@提及, '/add 用户' 加好友, 输入 '/exit' 退出)"
 	ti.Focus()
 	ti.CharLimit = 256
 	ti.Prompt = ">>> "
@@ -52,6 +70,7 @@ func NewIMClient(token, username string) model {
 		input:      ti,
 		status:     "正在连接聊天服务器...",
 		activeChat: "chat", // 默认聊天区域
+		seenMsgIds: make(map[int64]bool),
 		token:      token,
 		username:   username,
 		loading:    true,
@@ -65,7 +84,7 @@ func NewIMClient(token, username string) model {
 
 // BubbleTea UI 模型初始化
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, m.fetchRoster())
 }
 
 // BubbleTea UI 更新
@@ -87,16 +106,32 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			// 发送消息
 			if !m.loading {
-				m.pushMessage()
+				cmd = m.pushMessage()
 			}
 		case "tab":
-			// 切换聊天区域
+			// 切换聊天区域，切到联系人时顺便刷新
 			if m.activeChat == "chat" {
 				m.activeChat = "users"
+				cmd = m.fetchRoster()
 			} else {
 				m.activeChat = "chat"
 			}
 		}
+	case rosterMsg:
+		if msg.err != nil {
+			m.status = "获取联系人失败: " + msg.err.Error()
+		} else {
+			m.roster = msg.contacts
+			m.requests = msg.requests
+		}
+		return m, nil
+	case contactResultMsg:
+		if msg.err != nil {
+			m.status = msg.err.Error()
+			return m, nil
+		}
+		m.status = msg.status
+		return m, m.fetchRoster()
 	case tea.WindowSizeMsg:
 		// 更新终端尺寸
 		m.width = msg.Width
@@ -107,7 +142,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	if !m.loading && m.activeChat == "chat" {
-		m.input, cmd = m.input.Update(msg)
+		var inputCmd tea.Cmd
+		m.input, inputCmd = m.input.Update(msg)
+		cmd = tea.Batch(cmd, inputCmd)
 	}
 
 	return m, cmd
@@ -132,14 +169,8 @@ func (m model) View() string {
 
 	switch m.activeChat {
 	case "users":
-		// 用户列表视图
-		users := userListStyle.Render(" 在线用户:\n\n")
-		for _, user := range m.onlineUsers {
-			if user != m.username {
-				users += fmt.Sprintf("• %s\n", user)
-			}
-		}
-		body = userListContainerStyle.Render(users)
+		// 联系人视图
+		body = userListContainerStyle.Render(m.rosterView())
 	default:
 		// 聊天视图
 		chatHeader := "群聊"
@@ -171,7 +202,7 @@ func (m model) View() string {
 				continue
 			}
 
-			msgLine := fmt.Sprintf("[%s] %s: %s", t, sender, msg.Content)
+			msgLine := fmt.Sprintf("[%s] %s: %s", t, sender, msg.displayContent())
 
 			if sender == "你" {
 				msgLine = myMessageStyle.Render(msgLine)
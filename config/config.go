@@ -7,6 +7,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 var once sync.Once
@@ -15,18 +16,202 @@ var Conf *Config
 const (
 	SuccessReplyCode      = 0
 	FailReplyCode         = 1
+	OfflineReplyCode      = 2 // 用户不在线，connect层找不到对应的Channel
 	SuccessReplyMsg       = "success"
 	QueueName             = "yoyichat_queue"
-	RedisBaseValidTime    = 86400 // 这是有效时间吗，难道是Redis中消息队列的有效时间？
+	QueueStreamName       = "yoyichat_stream" // logic->task 的redis stream
+	QueueStreamField      = "msg"             // stream 消息体所在的字段
+	RedisBaseValidTime    = 86400             // 这是有效时间吗，难道是Redis中消息队列的有效时间？
 	RedisPrefix           = "yoyichat_"
 	RedisRoomPrefix       = "yoyichat_room_"
 	RedisRoomOnlinePrefix = "yoyichat_room_online_count_"
-	MsgVersion            = 1
-	OpSingleSend          = 2 // single user
-	OpRoomSend            = 3 // send to room
-	OpRoomCountSend       = 4 // get online user count
-	OpRoomInfoSend        = 5 // send info to room
-	OpBuildTcpConn        = 6 // build tcp conn
+	RedisOfflinePrefix    = "yoyichat_offline_"    // 离线消息收件箱
+	RedisDevicePrefix     = "yoyichat_device_"     // 用户在线设备，设备 => connect层serverId
+	RedisDeliveredPrefix  = "yoyichat_delivered_"  // 单聊消息已经被接收方某个设备确认
+	RedisRoomConnPrefix   = "yoyichat_room_conn_"  // 房间里每个用户的连接数，多设备都退出才算离开房间
+	RedisPresencePrefix   = "yoyichat_presence_"   // 用户在线状态：手动状态、最后在线时间、各设备心跳
+	RedisPresenceHbKey    = "yoyichat_presence_hb" // 所有在线设备的最后心跳 zset: <uid>_<device> -> 毫秒
+	RedisTypingPrefix     = "yoyichat_typing_"     // 输入状态节流
+	DefaultDevice         = "default"              // 老令牌没有设备标识时使用
+	RedisDeadLetterKey    = "yoyichat_dead_letter" // 推送多次失败的死信 hash: id -> DeadLetter
+	MsgVersion            = 2
+	OpSingleSend          = 2  // single user
+	OpRoomSend            = 3  // send to room
+	OpRoomCountSend       = 4  // get online user count
+	OpRoomInfoSend        = 5  // send info to room
+	OpBuildTcpConn        = 6  // build tcp conn
+	OpMsgAck              = 7  // client ack a single msg
+	OpDeliveryState       = 8  // push delivery state to sender
+	OpReadReceipt         = 9  // read up to seq, report and fan out
+	OpContact             = 10 // friend request / accept event
+	OpRoomSubscribe       = 11 // subscribe a room on an existing conn
+	OpRoomUnsubscribe     = 12 // unsubscribe a room on an existing conn
+	OpPresence            = 13 // presence change event
+	OpTyping              = 14 // typing started / stopped, ephemeral
+	OpMsgEdit             = 15 // a sent msg was edited
+	OpMsgDelete           = 16 // a sent msg was deleted
+	OpMsgReaction         = 17 // a reaction was added to / removed from a msg
+	OpThreadReply         = 18 // new reply in a followed thread
+	OpMention             = 19 // you were mentioned in a room msg
+)
+
+// 在线状态：有设备在线且有操作为online，设备都空闲为away，dnd和away可以手动设置
+// 设备心跳超过 PresenceTimeout 没有上报就当作已经掉线
+const (
+	PresenceOnline     = "online"
+	PresenceAway       = "away"
+	PresenceDnd        = "dnd"
+	PresenceOffline    = "offline"
+	PresenceTimeout    = 3 * time.Minute
+	PresenceIdleAfter  = 5 * time.Minute // 客户端多久没发东西算空闲
+	PresenceQueryLimit = 200
+	PresenceSweepEvery = time.Minute // 多久扫一次心跳超时的设备，connect层挂掉时靠它把设备下线
+)
+
+// 输入状态：同一会话里开始输入的事件节流发送，客户端超过 TypingExpire 没收到新事件就自动清掉
+const (
+	TypingThrottle = 3 * time.Second
+	TypingExpire   = 6 * time.Second
+)
+
+// 表情回应：每人对同一条消息的同一个表情只算一次
+const (
+	ReactionActionAdd    = "add"
+	ReactionActionRemove = "remove"
+	ReactionEmojiMaxLen  = 32 // 字节数，够放带肤色、组合的emoji
+)
+
+// @提及：一条消息最多提醒的人数，@all 只有房主和管理员能用，其他人发的 @all 当普通文字
+const (
+	MentionMaxUsers     = 50
+	MentionDefaultLimit = 20
+	MentionMaxLimit     = 100
+)
+
+// 认证模式：session 是不透明的会话令牌，每次校验都查 redis
+// jwt 是签名的短期访问令牌，api、connect、logic 本地验签，过期后用服务端存的刷新令牌换新的
+const (
+	AuthModeSession         = "session"
+	AuthModeJwt             = "jwt"
+	JwtIssuer               = "yoyichat"
+	RedisRefreshPrefix      = "yoyichat_refresh_"      // 刷新令牌 => 用户、设备、当前访问令牌
+	RedisRefreshUsedPrefix  = "yoyichat_refresh_used_" // 轮换掉的刷新令牌，再被使用说明泄露了
+	RedisJwtRevokedKey      = "yoyichat_jwt_revoked"   // 吊销的访问令牌 zset: jti -> 过期时间
+	AccessTokenDefaultTTL   = 900                      // 秒
+	RefreshTokenDefaultTTL  = 30 * 86400
+	RevokeSyncDefaultPeriod = 5 // 各层从 redis 同步吊销列表的间隔，秒
+)
+
+// 密码哈希算法，早期存的是不加盐的 sha1 (算法字段为空)，登录成功时换成 bcrypt
+const (
+	PasswordAlgoSha1   = "sha1"
+	PasswordAlgoBcrypt = "bcrypt"
+	PasswordBcryptCost = 12
+	PasswordMaxLen     = 72 // bcrypt 只用前72个字节，更长的密码直接拒绝
+)
+
+// 房间可见性：公开房间可以被搜索和直接加入，私有房间不出现在搜索结果里，也不能直接加入
+const (
+	RoomVisibilityPublic  = "public"
+	RoomVisibilityPrivate = "private"
+	RoomSearchLimit       = 50
+)
+
+// 房间成员角色，房主和管理员可以踢人、禁言、封禁、改名、改话题，只有房主能任免管理员
+const (
+	RoomRoleOwner  = "owner"
+	RoomRoleAdmin  = "admin"
+	RoomRoleMember = "member"
+	RoomRoleMuted  = "muted" // 禁言，不能在房间里发消息
+)
+
+// 房间事件，通过房间信息广播推给房间里的人
+const (
+	RoomEventRole   = "role"
+	RoomEventKick   = "kick"
+	RoomEventBan    = "ban"
+	RoomEventRename = "rename"
+	RoomEventTopic  = "topic"
+	RoomEventLeave  = "leave" // 成员自己退出，和被踢一样移出房间的在线连接
+)
+
+// 联系人关系与好友申请状态
+const (
+	ContactStatusFriend         = "friend"
+	ContactStatusBlocked        = "blocked"
+	FriendRequestStatusPending  = "pending"
+	FriendRequestStatusAccepted = "accepted"
+	FriendRequestStatusDeclined = "declined"
+	ContactEventRequest         = "request" // 收到好友申请
+	ContactEventAccept          = "accept"  // 好友申请被接受
+)
+
+// 消息搜索：编译时带 sqlite_fts5 标签用FTS5全文索引，否则用LIKE
+// 关键词在结果片段里用 SearchHighlightStart / SearchHighlightEnd 包起来
+const (
+	SearchDefaultLimit      = 20
+	SearchMaxLimit          = 50
+	SearchKeywordMaxLen     = 100
+	SearchSnippetRunes      = 20 // 片段里关键词前后各保留多少个字符
+	SearchHighlightStart    = "**"
+	SearchHighlightEnd      = "**"
+	SearchAttachmentAny     = 0 // 不按附件过滤
+	SearchAttachmentWith    = 1 // 只查带附件的
+	SearchAttachmentWithout = 2 // 只查不带附件的
+)
+
+// 协议版本：MsgVersion 开始 SendMsg 带结构化的 payload，之前的老客户端只认 SendMsg.msg 文本
+const MsgVersionText = 1
+
+// 结构化消息体的类型，落库时记在 message.payload_type
+const (
+	PayloadText         = "text"
+	PayloadMarkdown     = "markdown"
+	PayloadAttachment   = "attachment"
+	PayloadSystem       = "system"
+	PayloadLocation     = "location"
+	PayloadCustom       = "custom"
+	PayloadCustomMaxLen = 8 << 10 // 自定义消息JSON的大小上限
+)
+
+// 附件存储实现
+const (
+	BlobStoreLocal         = "local" // 本地磁盘
+	BlobStoreS3            = "s3"    // S3 兼容的对象存储
+	AttachmentMaxPerMsg    = 10      // 一条消息最多带的附件数
+	AttachmentNameMaxLen   = 255
+	AttachmentDefaultLimit = 10 << 20 // api.toml 没配置大小限制时用 10MB
+	AttachmentDefaultQuota = 1 << 30  // api.toml 没配置用户配额时用 1GB
+)
+
+// 孤儿附件：上传后一直没随消息发出的附件，api层定时清掉记录和文件
+const (
+	AttachmentOrphanTTL  = 24 * time.Hour // api.toml 没配置 orphanTtl 时用
+	AttachmentSweepEvery = time.Hour
+	AttachmentSweepBatch = 100 // 每次认领多少个，认领满了接着清
+)
+
+// logic->task 消息队列实现
+const (
+	QueueTypeRedisList   = "redisList"   // LPUSH/BRPOP，没有确认机制
+	QueueTypeRedisStream = "redisStream" // 消费组 + XACK
+	QueueTypeMemory      = "memory"      // 进程内channel，logic和task同进程运行时使用
+	// 广播消息已经推成功的connect层，redis stream 的消息被接管重投时跳过
+	RedisTaskPushedPrefix = "yoyichat_task_pushed_"
+)
+
+// 消息投递状态
+const (
+	DeliveryStateSent      = "sent"      // 服务端已接收并入队
+	DeliveryStateDelivered = "delivered" // 接收方客户端已确认
+)
+
+// 历史消息分页
+const (
+	HistoryDirectionBefore = 0 // 往前翻，查比游标更早的消息
+	HistoryDirectionAfter  = 1 // 往后翻，查比游标更新的消息
+	HistoryDefaultLimit    = 20
+	HistoryMaxLimit        = 100
 )
 
 // 差个站点层
@@ -149,9 +334,27 @@ type CommonRedis struct {
 	Db            int    `mapstructure:"db"`
 }
 
+type CommonQueue struct {
+	Type       string `mapstructure:"type"`
+	MemorySize int    `mapstructure:"memorySize"` // 进程内队列的缓冲大小
+}
+
+// jwt 模式的签名密钥按 kid 配置，签发用 signingKid，验签时按令牌头里的 kid 找密钥
+// 换密钥时先加新密钥、改 signingKid，等旧令牌都过期后再删旧密钥；kid 要用小写
+type CommonAuth struct {
+	Mode            string            `mapstructure:"mode"`
+	AccessTokenTTL  int               `mapstructure:"accessTokenTtl"`  // 访问令牌有效期，秒
+	RefreshTokenTTL int               `mapstructure:"refreshTokenTtl"` // 刷新令牌有效期，秒
+	SigningKid      string            `mapstructure:"signingKid"`
+	SigningKeys     map[string]string `mapstructure:"signingKeys"`
+	RevokeSyncSec   int               `mapstructure:"revokeSyncSec"` // 同步吊销列表的间隔，秒
+}
+
 type Common struct {
 	CommonEtcd  CommonEtcd  `mapstructure:"common-etcd"`
 	CommonRedis CommonRedis `mapstructure:"common-redis"`
+	CommonQueue CommonQueue `mapstructure:"common-queue"`
+	CommonAuth  CommonAuth  `mapstructure:"common-auth"`
 }
 
 // 这是干啥的
@@ -226,16 +429,50 @@ type TaskBase struct {
 	PushChanSize  int    `mapstructure:"pushChanSize"`
 }
 
+// redis stream 消费组配置
+type TaskStream struct {
+	Group         string `mapstructure:"group"`         // 消费组，多个task实例共用一个组
+	Consumer      string `mapstructure:"consumer"`      // 消费者名，为空时用 主机名-进程号
+	BatchSize     int64  `mapstructure:"batchSize"`     // 每次 XREADGROUP 最多读取条数
+	BlockMs       int    `mapstructure:"blockMs"`       // XREADGROUP 阻塞时间（毫秒）
+	ClaimMinIdle  int    `mapstructure:"claimMinIdle"`  // pending 消息空闲多久（秒）后被其他消费者接管
+	ClaimInterval int    `mapstructure:"claimInterval"` // 扫描 pending 列表的间隔（秒）
+	MaxLen        int64  `mapstructure:"maxLen"`        // stream 保留的大致长度，0 表示不裁剪
+	TrimInterval  int    `mapstructure:"trimInterval"`  // 裁剪间隔（秒）
+}
+
+// 推送connect层失败后的重试策略，间隔按 backoffBase * 2^(n-1) 增长，不超过 backoffMax
+// 总重试时间要小于 task-stream 的 claimMinIdle，否则重试中的消息会被重复接管
+type TaskRetry struct {
+	MaxAttempts int `mapstructure:"maxAttempts"` // 最多尝试次数，用完进死信
+	BackoffBase int `mapstructure:"backoffBase"` // 首次重试间隔（毫秒）
+	BackoffMax  int `mapstructure:"backoffMax"`  // 最大重试间隔（毫秒）
+}
+
 type TaskConfig struct {
-	TaskBase TaskBase `mapstructure:"task-base"`
+	TaskBase   TaskBase   `mapstructure:"task-base"`
+	TaskStream TaskStream `mapstructure:"task-stream"`
+	TaskRetry  TaskRetry  `mapstructure:"task-retry"`
 }
 
 type ApiBase struct {
 	ListenPort int `mapstructure:"listenPort"`
 }
 
+// 附件上传，大小单位是字节，allowedTypes 为空时不限制类型
+type ApiUpload struct {
+	Store        string   `mapstructure:"store"`
+	LocalDir     string   `mapstructure:"localDir"`
+	S3Bucket     string   `mapstructure:"s3Bucket"`
+	MaxSize      int64    `mapstructure:"maxSize"`
+	UserQuota    int64    `mapstructure:"userQuota"` // 每个用户所有附件加起来的大小上限
+	OrphanTTL    int      `mapstructure:"orphanTtl"` // 上传后多久没随消息发出就删掉，秒
+	AllowedTypes []string `mapstructure:"allowedTypes"`
+}
+
 type ApiConfig struct {
-	ApiBase ApiBase `mapstructure:"api-base"`
+	ApiBase   ApiBase   `mapstructure:"api-base"`
+	ApiUpload ApiUpload `mapstructure:"api-upload"`
 }
 
 type ClientBase struct {
@@ -0,0 +1,136 @@
+package connect
+
+import (
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"time"
+	"yoyichat/config"
+	"yoyichat/pb/connect_pb"
+	"yoyichat/pb/logic_pb"
+)
+
+// 送达确认：单聊消息推给客户端后记在Channel上，客户端回 OpMsgAck 才算送达
+// 超时没确认就重传，重传次数用完就不再跟踪，连接断开时剩下的未确认消息交给logic层转存离线
+// 只有建连时声明了 ack 的客户端才跟踪，老客户端不会回确认，跟踪了只会被重复推送
+// 群聊消息量大且没有单个发送方需要回执，不做确认
+
+type pendingAck struct {
+	msg    *connect_pb.Msg
+	sentAt time.Time
+	retry  int
+}
+
+// 推给单个用户，单聊消息需要记录下来等客户端确认，投递状态等事件直接推
+func (ch *Channel) PushSingle(msg *connect_pb.Msg) (err error) {
+	if msg.Op == config.OpSingleSend {
+		ch.trackAck(msg)
+	}
+	return ch.Push(msg)
+}
+
+// 建连时客户端声明会回确认才开启，maxPending 限制同时等待确认的消息数
+func (ch *Channel) enableAck(maxPending int) {
+	ch.ackLock.Lock()
+	ch.ackMaxPending = maxPending
+	ch.ackLock.Unlock()
+}
+
+func (ch *Channel) trackAck(msg *connect_pb.Msg) {
+	ch.ackLock.Lock()
+	defer ch.ackLock.Unlock()
+	if ch.ackMaxPending <= 0 {
+		return
+	}
+	sendMsg := &logic_pb.SendMsg{}
+	if err := proto.Unmarshal(msg.Body, sendMsg); err != nil || sendMsg.MsgId == 0 {
+		return
+	}
+	// 等待确认的太多了，客户端大概已经不回确认了，这条不再跟踪，历史消息里仍然能查到
+	if _, ok := ch.unacked[sendMsg.MsgId]; !ok && len(ch.unacked) >= ch.ackMaxPending {
+		logrus.Warnf("user %d has %d unacked msgs, skip tracking msg %d", ch.userId, len(ch.unacked), sendMsg.MsgId)
+		return
+	}
+	ch.unacked[sendMsg.MsgId] = &pendingAck{msg: msg, sentAt: time.Now()}
+}
+
+// 客户端确认，返回这条消息之前是否在等待确认
+func (ch *Channel) Ack(msgId int64) bool {
+	ch.ackLock.Lock()
+	defer ch.ackLock.Unlock()
+	if _, ok := ch.unacked[msgId]; !ok {
+		return false
+	}
+	delete(ch.unacked, msgId)
+	return true
+}
+
+// 取出确认超时需要重传的消息，重传次数用完还没确认的不再跟踪
+func (ch *Channel) expiredUnacked(timeout time.Duration, maxRetry int) (msgs []*connect_pb.Msg) {
+	now := time.Now()
+	ch.ackLock.Lock()
+	defer ch.ackLock.Unlock()
+	for msgId, p := range ch.unacked {
+		if now.Sub(p.sentAt) < timeout {
+			continue
+		}
+		if p.retry >= maxRetry {
+			delete(ch.unacked, msgId)
+			logrus.Infof("msg %d to user %d not acked after %d retries, give up", msgId, ch.userId, p.retry)
+			continue
+		}
+		p.retry++
+		p.sentAt = now
+		msgs = append(msgs, p.msg)
+		logrus.Infof("retransmit msg %d to user %d, retry %d", msgId, ch.userId, p.retry)
+	}
+	return
+}
+
+// 所有还没确认的消息体，连接断开时使用
+func (ch *Channel) unackedBodies() (bodies [][]byte) {
+	ch.ackLock.Lock()
+	defer ch.ackLock.Unlock()
+	for _, p := range ch.unacked {
+		bodies = append(bodies, p.msg.Body)
+	}
+	return
+}
+
+// 重传确认超时的消息，由写协程的定时器触发
+func (s *Server) retransmit(ch *Channel) {
+	for _, msg := range ch.expiredUnacked(s.Options.AckTimeout, s.Options.AckMaxRetry) {
+		if err := ch.Push(msg); err != nil {
+			logrus.Warnf("retransmit msg to user %d err:%s", ch.userId, err.Error())
+		}
+	}
+}
+
+// 处理客户端的确认，通知logic层告诉发送方已送达
+func (s *Server) ack(ch *Channel, msgId int64) {
+	if ch.userId == 0 {
+		logrus.Warnf("ack before connect, msgId:%d", msgId)
+		return
+	}
+	if !ch.Ack(msgId) {
+		return
+	}
+	ackReq := &logic_pb.AckRequest{
+		UserId: int32(ch.userId),
+		MsgId:  msgId,
+	}
+	if err := s.operator.Ack(ackReq); err != nil {
+		logrus.Warnf("operator ack err:%s", err.Error())
+	}
+}
+
+// 断开连接时带上订阅的房间和未确认的消息，被同一设备的新连接替换掉的要标记出来
+func (s *Server) disConnectRequest(ch *Channel, roomIds []int32, removed bool, serverId string) *logic_pb.DisConnectRequest {
+	disConnectRequest := new(logic_pb.DisConnectRequest)
+	disConnectRequest.RoomIds = roomIds
+	disConnectRequest.Replaced = !removed
+	disConnectRequest.UserId = int32(ch.userId)
+	disConnectRequest.Device = ch.device
+	disConnectRequest.ServerId = serverId
+	disConnectRequest.UnackedMsgs = ch.unackedBodies()
+	return disConnectRequest
+}
@@ -7,8 +7,8 @@ import (
 )
 
 type Bucket struct {
-	cLock         sync.RWMutex     // protect the channels for chs
-	chs           map[int]*Channel // map sub key to a channel 用户ID => 连接映射
+	cLock         sync.RWMutex                // protect the channels for chs
+	chs           map[int]map[string]*Channel // 用户ID => 设备 => 连接映射，一个用户可以多个设备同时在线
 	bucketOptions BucketOptions
 	rooms         map[int]*Room                         // bucket room channels 房间ID => 房间对象映射
 	routines      []chan *connect_pb.PushRoomMsgRequest // 广播携程用到的通道
@@ -25,7 +25,7 @@ type BucketOptions struct {
 func NewBucket(bucketOptions BucketOptions) (b *Bucket) {
 	b = new(Bucket)
 	// 根据设置的链接容量初始化链接数
-	b.chs = make(map[int]*Channel, bucketOptions.ChannelSize)
+	b.chs = make(map[int]map[string]*Channel, bucketOptions.ChannelSize)
 	b.bucketOptions = bucketOptions
 
 	// 根据广播携程数初始化管道数量
@@ -55,6 +55,21 @@ func (b *Bucket) PushRoom(ch chan *connect_pb.PushRoomMsgRequest) {
 		arg = <-ch
 		if room = b.Room(int(arg.RoomId)); room != nil {
 			room.Push(arg.Msg)
+			// 先把事件推给被踢的人，再把他移出房间
+			for _, userId := range arg.EvictUserIds {
+				b.evict(int(userId), room)
+			}
+		}
+	}
+}
+
+// 把用户所有设备的连接移出房间，连接本身保留
+func (b *Bucket) evict(userId int, room *Room) {
+	b.cLock.Lock()
+	defer b.cLock.Unlock()
+	for _, ch := range b.chs[userId] {
+		if ch.rooms[room.Id] == room {
+			b.leaveRoom(ch, room)
 		}
 	}
 }
@@ -67,60 +82,106 @@ func (b *Bucket) Room(rid int) (room *Room) {
 	return
 }
 
-// 添加链接？将用户/链接/房间 入桶管理
-func (b *Bucket) Put(userId int, roomId int, ch *Channel) (err error) {
-	var (
-		room *Room
-		ok   bool
-	)
+// 将用户/设备/链接 入桶管理，带了房间号的同时订阅这个房间
+// 同一设备重连时新连接替换旧连接并关掉旧连接，旧连接断开时不会影响新连接
+func (b *Bucket) Put(userId int, device string, roomId int, ch *Channel) (err error) {
 	b.cLock.Lock()
+	defer b.cLock.Unlock()
+	ch.userId = userId
+	ch.device = device
+	if _, ok := b.chs[userId]; !ok {
+		b.chs[userId] = make(map[string]*Channel)
+	}
+	if old, ok := b.chs[userId][ch.device]; ok && old != ch {
+		old.close()
+	}
+	b.chs[userId][ch.device] = ch
+	if roomId > 0 {
+		err = b.joinRoom(ch, roomId)
+	}
+	return
+}
 
-	// 房间管理，添加新房间
-	if roomId != NoRoom {
-		if room, ok = b.rooms[roomId]; !ok {
-			room = NewRoom(roomId)
-			b.rooms[roomId] = room
-		}
-		ch.Room = room
+// 连接运行中订阅一个房间
+func (b *Bucket) Subscribe(ch *Channel, roomId int) (err error) {
+	b.cLock.Lock()
+	defer b.cLock.Unlock()
+	if _, ok := ch.rooms[roomId]; ok {
+		return
 	}
-	// 关联链接，有个疑问，链接已经被存储到room中了，干嘛还要单独存一个链接关联呢，可能是找的方便吧，待解释
-	ch.userId = userId
-	b.chs[userId] = ch
-	b.cLock.Unlock()
+	return b.joinRoom(ch, roomId)
+}
 
-	// 将链接添加到房间中
-	if room != nil {
-		err = room.Put(ch)
+// 取消订阅，返回之前是否订阅过
+func (b *Bucket) Unsubscribe(ch *Channel, roomId int) bool {
+	b.cLock.Lock()
+	defer b.cLock.Unlock()
+	room, ok := ch.rooms[roomId]
+	if !ok {
+		return false
 	}
-	return
+	b.leaveRoom(ch, room)
+	return true
 }
 
-// 原来是为了删除方便
-func (b *Bucket) DeleteChannel(ch *Channel) {
-	var (
-		ok   bool
-		room *Room
-	)
+// 连接订阅的所有房间ID
+func (b *Bucket) RoomIds(ch *Channel) (roomIds []int32) {
 	b.cLock.RLock()
-	if ch, ok = b.chs[ch.userId]; ok {
-		room = b.chs[ch.userId].Room
-		//delete from bucket
-		delete(b.chs, ch.userId)
+	defer b.cLock.RUnlock()
+	for roomId := range ch.rooms {
+		roomIds = append(roomIds, int32(roomId))
 	}
-	if room != nil && room.DeleteChannel(ch) {
-		// if room empty delete,will mark room.drop is true
-		// 如果房间为空，那就删掉这个房间
-		if room.drop == true {
-			delete(b.rooms, room.Id)
+	return
+}
+
+// 以下两个方法需要持有桶的写锁
+func (b *Bucket) joinRoom(ch *Channel, roomId int) (err error) {
+	room, ok := b.rooms[roomId]
+	if !ok {
+		room = NewRoom(roomId)
+		b.rooms[roomId] = room
+	}
+	if err = room.Put(ch); err != nil {
+		return
+	}
+	ch.rooms[roomId] = room
+	return
+}
+
+func (b *Bucket) leaveRoom(ch *Channel, room *Room) {
+	delete(ch.rooms, room.Id)
+	if room.DeleteChannel(ch) {
+		// 房间空了就删掉
+		delete(b.rooms, room.Id)
+	}
+}
+
+// 连接断开时把连接移出桶和它订阅的所有房间，返回这些房间ID
+// removed 为 false 表示这个设备已经换成了新连接，设备在线状态不能跟着删
+func (b *Bucket) DeleteChannel(ch *Channel) (roomIds []int32, removed bool) {
+	b.cLock.Lock()
+	defer b.cLock.Unlock()
+	// 同一个设备可能已经建立了新连接，只删自己
+	if devices, ok := b.chs[ch.userId]; ok && devices[ch.device] == ch {
+		removed = true
+		delete(devices, ch.device)
+		if len(devices) == 0 {
+			delete(b.chs, ch.userId)
 		}
 	}
-	b.cLock.RUnlock()
+	for roomId, room := range ch.rooms {
+		roomIds = append(roomIds, int32(roomId))
+		b.leaveRoom(ch, room)
+	}
+	return
 }
 
-// 返回userid 对应的链接
-func (b *Bucket) Channel(userId int) (ch *Channel) {
+// 返回userid 在本桶里所有设备的链接
+func (b *Bucket) Channels(userId int) (chs []*Channel) {
 	b.cLock.RLock()
-	ch = b.chs[userId]
+	for _, ch := range b.chs[userId] {
+		chs = append(chs, ch)
+	}
 	b.cLock.RUnlock()
 	return
 }
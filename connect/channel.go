@@ -1,35 +1,150 @@
 package connect
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"yoyichat/config"
 	"yoyichat/pb/connect_pb"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
 )
 
 // in fact, Channel it's a user Connect session
 type Channel struct {
-	Room      *Room // 有点意思，ROOM能用指针找到Channel，Channel还能找回去
-	Next      *Channel
-	Prev      *Channel
-	broadcast chan *connect_pb.Msg // 消息广播通道
-	userId    int                  // 用户ID
-	conn      *websocket.Conn
-	connTcp   *net.TCPConn
+	rooms         map[int]*Room        // 连接订阅的房间，房间ID => 房间对象，由所在的桶加锁维护
+	broadcast     chan *connect_pb.Msg // 消息广播通道
+	userId        int                  // 用户ID
+	device        string               // 设备标识
+	lastActive    atomic.Int64         // 客户端最后一次发来消息的时间，纳秒
+	version       atomic.Int32         // 客户端支持的协议版本，比消息版本低的推送时去掉 payload
+	conn          *websocket.Conn
+	connTcp       *net.TCPConn
+	ackLock       sync.Mutex
+	unacked       map[int64]*pendingAck // msgId => 等待客户端确认的单聊消息
+	ackMaxPending int                   // 最多同时等待确认的消息数，为0表示客户端没有开启确认
+	holdLock      sync.Mutex
+	holding       bool              // 正在推离线消息，实时推送先攒在 held 里
+	held          []*connect_pb.Msg // 推离线消息期间到达的实时推送
+	written       atomic.Int64      // 已经写给客户端的消息数
 }
 
 func NewChannel(size int) (c *Channel) {
 	c = new(Channel)
 	c.broadcast = make(chan *connect_pb.Msg, size)
-	c.Next = nil
-	c.Prev = nil
+	c.unacked = make(map[int64]*pendingAck)
+	c.rooms = make(map[int]*Room)
+	c.version.Store(config.MsgVersionText)
+	c.markActive()
 	return
 }
 
 // 这里的链接究竟是谁的呢，如果是双方的，那为什么只有一个userid呢，如果不是单方的，那为什么这里说的是广播呢？
+// 广播通道满了就丢弃并返回错误，需要确认的单聊消息会在超时后重传
+// 正在推离线消息时先攒起来，等离线消息推完再按顺序放进广播通道
 func (ch *Channel) Push(msg *connect_pb.Msg) (err error) {
+	ch.holdLock.Lock()
+	if ch.holding {
+		defer ch.holdLock.Unlock()
+		if len(ch.held) >= cap(ch.broadcast) {
+			return errors.New("channel held msgs full")
+		}
+		ch.held = append(ch.held, msg)
+		return
+	}
+	ch.holdLock.Unlock()
 	select {
 	case ch.broadcast <- msg:
 	default:
+		err = errors.New("channel broadcast full")
 	}
 	return
 }
+
+// 关闭底层连接，读协程随之退出并走断开流程
+func (ch *Channel) close() {
+	if ch.conn != nil {
+		_ = ch.conn.Close()
+	}
+	if ch.connTcp != nil {
+		_ = ch.connTcp.Close()
+	}
+}
+
+// 开始推离线消息前挂起实时推送，要在入桶之前调用
+func (ch *Channel) hold() {
+	ch.holdLock.Lock()
+	ch.holding = true
+	ch.holdLock.Unlock()
+}
+
+// 离线消息推完，把期间攒下的实时推送放进广播通道，恢复正常推送
+func (ch *Channel) release() {
+	ch.holdLock.Lock()
+	defer ch.holdLock.Unlock()
+	for _, msg := range ch.held {
+		select {
+		case ch.broadcast <- msg:
+		default:
+			logrus.Warnf("channel broadcast full, drop held msg op %d to user %d", msg.Op, ch.userId)
+		}
+	}
+	ch.held = nil
+	ch.holding = false
+}
+
+// 推送离线收件箱里的消息，数量可能超过广播通道容量，所以不能像Push那样满了就丢
+// 阻塞等待writer消费，超时就放弃剩下的，返回放进广播通道的条数
+func (ch *Channel) PushOffline(msgs [][]byte, timeout time.Duration) (pushed int, err error) {
+	for i, body := range msgs {
+		msg := &connect_pb.Msg{
+			Ver:  config.MsgVersion,
+			Op:   offlineMsgOp(body),
+			Seq:  tools.GetSnowflakeId(),
+			Body: body,
+		}
+		if msg.Op == config.OpSingleSend {
+			ch.trackAck(msg)
+		}
+		select {
+		case ch.broadcast <- msg:
+			pushed++
+		case <-time.After(timeout):
+			return pushed, fmt.Errorf("push offline msg timeout, %d msgs left", len(msgs)-i)
+		}
+	}
+	return
+}
+
+// 等待writer写完第target条消息，超时返回当前写到的条数
+func (ch *Channel) waitWritten(target int64, timeout time.Duration) int64 {
+	deadline := time.Now().Add(timeout)
+	for ch.written.Load() < target && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return min(ch.written.Load(), target)
+}
+
+// 离线收件箱里除了单聊消息还有@提醒，按消息体里的op推给客户端
+func offlineMsgOp(body []byte) int32 {
+	sendMsg := &logic_pb.SendMsg{}
+	if err := proto.Unmarshal(body, sendMsg); err == nil && sendMsg.Op == config.OpMention {
+		return config.OpMention
+	}
+	return config.OpSingleSend
+}
+
+// 离线消息的ID，删除收件箱时使用
+func offlineMsgId(body []byte) int64 {
+	sendMsg := &logic_pb.SendMsg{}
+	if err := proto.Unmarshal(body, sendMsg); err != nil {
+		return 0
+	}
+	return sendMsg.MsgId
+}
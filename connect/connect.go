@@ -6,6 +6,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"runtime"
 	"time"
+	"yoyichat/auth"
 	"yoyichat/config"
 )
 
@@ -31,6 +32,9 @@ func (c *Connect) Run() {
 	if err := c.InitLogicRpcClient(); err != nil {
 		logrus.Panicf("InitLogicRpcClient err:%s", err.Error())
 	}
+	if err := auth.Init(nil); err != nil {
+		logrus.Panicf("connect init auth err:%s", err.Error())
+	}
 	// logic层居然也会调用本connection层方法，这确实，因为流程图上大概会如此，但是也不太应该，因为logic和connection之间还有消息队列呢？
 	Buckets := make([]*Bucket, connectConfig.ConnectBucket.CpuNum)
 	for i := 0; i < connectConfig.ConnectBucket.CpuNum; i++ {
@@ -52,6 +56,9 @@ func (c *Connect) Run() {
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 		BroadcastSize:   512,
+		AckTimeout:      10 * time.Second,
+		AckMaxRetry:     3,
+		AckMaxPending:   1024,
 	})
 	c.ServerId = fmt.Sprintf("%s-%s", "ws", uuid.New().String())
 	//init Connect layer rpc server ,task layer will call this
@@ -78,6 +85,9 @@ func (c *Connect) RunTcp() {
 	if err := c.InitLogicRpcClient(); err != nil {
 		logrus.Panicf("InitLogicRpcClient err:%s", err.Error())
 	}
+	if err := auth.Init(nil); err != nil {
+		logrus.Panicf("connect init auth err:%s", err.Error())
+	}
 	//init Connect layer rpc server, logic client will call this
 	Buckets := make([]*Bucket, connectConfig.ConnectBucket.CpuNum)
 	for i := 0; i < connectConfig.ConnectBucket.CpuNum; i++ {
@@ -97,6 +107,9 @@ func (c *Connect) RunTcp() {
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 		BroadcastSize:   512,
+		AckTimeout:      10 * time.Second,
+		AckMaxRetry:     3,
+		AckMaxPending:   1024,
 	})
 	//go func() {
 	//	http.ListenAndServe("0.0.0.0:9000", nil)
@@ -1,11 +1,22 @@
 package connect
 
-import "yoyichat/pb/logic_pb"
+import (
+	"yoyichat/auth"
+	"yoyichat/pb/logic_pb"
+)
 
 // 操作符？这是什么形式，代理吗？
 type Operator interface {
-	Connect(conn *logic_pb.ConnectRequest) (int, error)         // 用于加入房间请求
-	DisConnect(disConn *logic_pb.DisConnectRequest) (err error) // 用于离开房间请求
+	Connect(conn *logic_pb.ConnectRequest) (*logic_pb.ConnectReply, error) // 用于加入房间请求
+	DisConnect(disConn *logic_pb.DisConnectRequest) (err error)            // 用于离开房间请求
+	Ack(ack *logic_pb.AckRequest) (err error)                              // 客户端确认收到单聊消息
+	ReadReceipt(receipt *logic_pb.ReadReceiptRequest) (err error)          // 客户端上报已读位置
+	SubscribeRoom(req *logic_pb.RoomRequest) (err error)                   // 已有连接上订阅房间
+	UnsubscribeRoom(req *logic_pb.RoomRequest) (err error)                 // 已有连接上取消订阅房间
+	Heartbeat(req *logic_pb.HeartbeatRequest) (err error)                  // 心跳上报在线状态
+	Typing(req *logic_pb.TypingRequest) (err error)                        // 客户端上报输入状态
+	Online(req *logic_pb.OnlineRequest) (offlineMsgs [][]byte, err error)  // 入桶后登记设备在线并取离线消息
+	TrimOffline(req *logic_pb.OfflineTrimRequest) (err error)              // 删除已经推出去的离线消息
 }
 
 // 默认操作符只提供加入房间和离开房间的方法
@@ -13,9 +24,15 @@ type DefaultOperator struct {
 }
 
 // rpc call logic layer
-func (o *DefaultOperator) Connect(conn *logic_pb.ConnectRequest) (uid int, err error) {
+// jwt 模式下先本地验签，无效的令牌不用再调 logic
+func (o *DefaultOperator) Connect(conn *logic_pb.ConnectRequest) (reply *logic_pb.ConnectReply, err error) {
+	if auth.Enabled() {
+		if _, err = auth.Parse(conn.AuthToken); err != nil {
+			return
+		}
+	}
 	rpcConnect := new(RpcConnect)
-	uid, err = rpcConnect.Connect(conn)
+	reply, err = rpcConnect.Connect(conn)
 	return
 }
 
@@ -25,3 +42,59 @@ func (o *DefaultOperator) DisConnect(disConn *logic_pb.DisConnectRequest) (err e
 	err = rpcConnect.DisConnect(disConn)
 	return
 }
+
+// rpc call logic layer
+func (o *DefaultOperator) Ack(ack *logic_pb.AckRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.Ack(ack)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) ReadReceipt(receipt *logic_pb.ReadReceiptRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.ReadReceipt(receipt)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) SubscribeRoom(req *logic_pb.RoomRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.SubscribeRoom(req)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) UnsubscribeRoom(req *logic_pb.RoomRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.UnsubscribeRoom(req)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) Heartbeat(req *logic_pb.HeartbeatRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.Heartbeat(req)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) Typing(req *logic_pb.TypingRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.Typing(req)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) Online(req *logic_pb.OnlineRequest) (offlineMsgs [][]byte, err error) {
+	rpcConnect := new(RpcConnect)
+	offlineMsgs, err = rpcConnect.Online(req)
+	return
+}
+
+// rpc call logic layer
+func (o *DefaultOperator) TrimOffline(req *logic_pb.OfflineTrimRequest) (err error) {
+	rpcConnect := new(RpcConnect)
+	err = rpcConnect.TrimOffline(req)
+	return
+}
@@ -0,0 +1,35 @@
+package connect
+
+import (
+	"github.com/sirupsen/logrus"
+	"time"
+	"yoyichat/config"
+	"yoyichat/pb/logic_pb"
+)
+
+// 客户端发来任何东西都算一次操作，心跳时据此判断是否空闲
+func (ch *Channel) markActive() {
+	ch.lastActive.Store(time.Now().UnixNano())
+}
+
+func (ch *Channel) idle() bool {
+	return time.Since(time.Unix(0, ch.lastActive.Load())) > config.PresenceIdleAfter
+}
+
+// 心跳定时器触发时上报logic层，刷新在线状态，不阻塞写协程
+func (s *Server) heartbeat(ch *Channel, serverId string) {
+	if ch.userId == 0 {
+		return
+	}
+	req := &logic_pb.HeartbeatRequest{
+		UserId:   int32(ch.userId),
+		Device:   ch.device,
+		ServerId: serverId,
+		Idle:     ch.idle(),
+	}
+	go func() {
+		if err := s.operator.Heartbeat(req); err != nil {
+			logrus.Warnf("operator heartbeat err:%s", err.Error())
+		}
+	}()
+}
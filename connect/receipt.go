@@ -0,0 +1,23 @@
+package connect
+
+import (
+	"github.com/sirupsen/logrus"
+	"yoyichat/pb/logic_pb"
+)
+
+// 客户端上报"已读到seq N"，单聊带 to_user_id，群聊带 room_id
+func (s *Server) readReceipt(ch *Channel, clientMsg *logic_pb.SendTcpMsg) {
+	if ch.userId == 0 {
+		logrus.Warnf("read receipt before connect")
+		return
+	}
+	req := &logic_pb.ReadReceiptRequest{
+		UserId:   int32(ch.userId),
+		ToUserId: clientMsg.ToUserId,
+		RoomId:   clientMsg.RoomId,
+		Seq:      clientMsg.Seq,
+	}
+	if err := s.operator.ReadReceipt(req); err != nil {
+		logrus.Warnf("operator read receipt err:%s", err.Error())
+	}
+}
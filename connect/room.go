@@ -9,47 +9,42 @@ import (
 
 const NoRoom = -1
 
+// 一个连接可以同时订阅多个房间，所以房间里的连接用集合管理，不再用挂在Channel上的链表
 type Room struct {
 	Id          int // 房间ID
-	OnlineCount int // 房间在线人数，但是怎么判断是否在线？
+	OnlineCount int // 房间在线人数，这里是本桶内订阅了这个房间的连接数
 	rLock       sync.RWMutex
-	drop        bool     // 房间是否存活的标记
-	next        *Channel // 首个Channel指针，原来这东西是dummy头节点
+	drop        bool                  // 房间是否存活的标记
+	chs         map[*Channel]struct{} // 订阅了这个房间的连接
 }
 
 func NewRoom(roomId int) *Room {
 	room := new(Room)
 	room.Id = roomId
 	room.drop = false
-	room.next = nil
+	room.chs = make(map[*Channel]struct{})
 	room.OnlineCount = 0
 	return room
 }
 
-// 加入链表管理，无尾部节点，只能从头部开始找
+// 加入房间的连接集合，重复加入不会重复计数
 func (r *Room) Put(ch *Channel) (err error) {
-	//doubly linked list
 	r.rLock.Lock()
 	defer r.rLock.Unlock()
-	if !r.drop {
-		if r.next != nil {
-			r.next.Prev = ch
-		}
-		ch.Next = r.next
-		ch.Prev = nil
-		r.next = ch
+	if r.drop {
+		return errors.New("room drop")
+	}
+	if _, ok := r.chs[ch]; !ok {
+		r.chs[ch] = struct{}{}
 		r.OnlineCount++
-	} else {
-		err = errors.New("room drop")
 	}
 	return
 }
 
-// 消息推送，Connect层已经是离客户端最近的了，所以这里就直接传输过去了，从头节点往后找一个一个push
-// TODO：也许我们可以优化一下让他推的更快，这里是O(N)
+// 消息推送，Connect层已经是离客户端最近的了，所以这里就直接传输过去了
 func (r *Room) Push(msg *connect_pb.Msg) {
 	r.rLock.RLock()
-	for ch := r.next; ch != nil; ch = ch.Next {
+	for ch := range r.chs {
 		if err := ch.Push(msg); err != nil {
 			logrus.Infof("push msg err:%s", err.Error())
 		}
@@ -58,24 +53,14 @@ func (r *Room) Push(msg *connect_pb.Msg) {
 	return
 }
 
-// 删除链表上的一个节点
+// 从房间里删掉一个连接，房间空了就标记为drop，返回是否需要删掉这个房间
 func (r *Room) DeleteChannel(ch *Channel) bool {
-	r.rLock.RLock()
-	if ch.Next != nil {
-		//if not footer
-		ch.Next.Prev = ch.Prev
-	}
-	if ch.Prev != nil {
-		// if not header
-		ch.Prev.Next = ch.Next
-	} else {
-		r.next = ch.Next
-	}
-	r.OnlineCount--
-	r.drop = false
-	if r.OnlineCount <= 0 {
-		r.drop = true
+	r.rLock.Lock()
+	defer r.rLock.Unlock()
+	if _, ok := r.chs[ch]; ok {
+		delete(r.chs, ch)
+		r.OnlineCount--
 	}
-	r.rLock.RUnlock()
+	r.drop = r.OnlineCount <= 0
 	return r.drop
 }
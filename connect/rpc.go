@@ -70,15 +70,16 @@ func (c *Connect) InitLogicRpcClient() (err error) {
 }
 
 // 加入房间（rpc调用logic层connect方法，logic初始化时已注册进etcd）
-func (rpc *RpcConnect) Connect(connReq *logic_pb.ConnectRequest) (uid int, err error) {
-	reply := &logic_pb.ConnectReply{}
+func (rpc *RpcConnect) Connect(connReq *logic_pb.ConnectRequest) (reply *logic_pb.ConnectReply, err error) {
+	reply = &logic_pb.ConnectReply{}
 
 	// 调用logic层的Connect方法，其实就是加入房间
 	err = logicRpcClient.Call(context.Background(), "Connect", connReq, reply)
 	if err != nil {
-		logrus.Fatalf("failed to call: %v", err)
+		// 认证失败或者不是房间成员都会返回错误，关掉这个连接就行，不能让整个connect层退出
+		logrus.Errorf("failed to call Connect: %v", err)
+		return
 	}
-	uid = int(reply.UserId)
 	logrus.Infof("connect logic userId :%d", reply.UserId)
 	return
 }
@@ -87,7 +88,80 @@ func (rpc *RpcConnect) Connect(connReq *logic_pb.ConnectRequest) (uid int, err e
 func (rpc *RpcConnect) DisConnect(disConnReq *logic_pb.DisConnectRequest) (err error) {
 	reply := &logic_pb.DisConnectReply{}
 	if err = logicRpcClient.Call(context.Background(), "DisConnect", disConnReq, reply); err != nil {
-		logrus.Fatalf("failed to call: %v", err)
+		logrus.Errorf("failed to call DisConnect: %v", err)
+	}
+	return
+}
+
+// 客户端确认收到消息（rpc调用logic层Ack方法）
+func (rpc *RpcConnect) Ack(ackReq *logic_pb.AckRequest) (err error) {
+	reply := &logic_pb.AckReply{}
+	if err = logicRpcClient.Call(context.Background(), "Ack", ackReq, reply); err != nil {
+		logrus.Errorf("failed to call Ack: %v", err)
+	}
+	return
+}
+
+// 上报已读位置（rpc调用logic层ReadReceipt方法）
+func (rpc *RpcConnect) ReadReceipt(receiptReq *logic_pb.ReadReceiptRequest) (err error) {
+	reply := &logic_pb.ReadReceiptReply{}
+	if err = logicRpcClient.Call(context.Background(), "ReadReceipt", receiptReq, reply); err != nil {
+		logrus.Errorf("failed to call ReadReceipt: %v", err)
+	}
+	return
+}
+
+// 订阅房间（rpc调用logic层SubscribeRoom方法），不是成员会返回错误
+func (rpc *RpcConnect) SubscribeRoom(req *logic_pb.RoomRequest) (err error) {
+	reply := &logic_pb.RoomReply{}
+	if err = logicRpcClient.Call(context.Background(), "SubscribeRoom", req, reply); err != nil {
+		logrus.Errorf("failed to call SubscribeRoom: %v", err)
+	}
+	return
+}
+
+// 取消订阅房间（rpc调用logic层UnsubscribeRoom方法）
+func (rpc *RpcConnect) UnsubscribeRoom(req *logic_pb.RoomRequest) (err error) {
+	reply := &logic_pb.RoomReply{}
+	if err = logicRpcClient.Call(context.Background(), "UnsubscribeRoom", req, reply); err != nil {
+		logrus.Errorf("failed to call UnsubscribeRoom: %v", err)
+	}
+	return
+}
+
+// 心跳上报（rpc调用logic层Heartbeat方法）
+func (rpc *RpcConnect) Heartbeat(req *logic_pb.HeartbeatRequest) (err error) {
+	reply := &logic_pb.HeartbeatReply{}
+	if err = logicRpcClient.Call(context.Background(), "Heartbeat", req, reply); err != nil {
+		logrus.Errorf("failed to call Heartbeat: %v", err)
+	}
+	return
+}
+
+// 上报输入状态（rpc调用logic层Typing方法）
+func (rpc *RpcConnect) Typing(req *logic_pb.TypingRequest) (err error) {
+	reply := &logic_pb.TypingReply{}
+	if err = logicRpcClient.Call(context.Background(), "Typing", req, reply); err != nil {
+		logrus.Errorf("failed to call Typing: %v", err)
+	}
+	return
+}
+
+// 入桶后登记设备在线并取离线消息（rpc调用logic层Online方法）
+func (rpc *RpcConnect) Online(req *logic_pb.OnlineRequest) (offlineMsgs [][]byte, err error) {
+	reply := &logic_pb.OnlineReply{}
+	if err = logicRpcClient.Call(context.Background(), "Online", req, reply); err != nil {
+		logrus.Errorf("failed to call Online: %v", err)
+		return
+	}
+	return reply.OfflineMsgs, nil
+}
+
+// 删除已经推出去的离线消息（rpc调用logic层TrimOffline方法）
+func (rpc *RpcConnect) TrimOffline(req *logic_pb.OfflineTrimRequest) (err error) {
+	reply := &logic_pb.OfflineTrimReply{}
+	if err = logicRpcClient.Call(context.Background(), "TrimOffline", req, reply); err != nil {
+		logrus.Errorf("failed to call TrimOffline: %v", err)
 	}
 	return
 }
@@ -127,22 +201,27 @@ type RpcConnectPush struct {
 
 // 单聊消息推送
 func (rpc *RpcConnectPush) PushSingleMsg(ctx context.Context, pushMsgReq *connect_pb.PushMsgRequest, successReply *task_pb.SuccessReply) (err error) {
-	var (
-		bucket  *Bucket
-		channel *Channel
-	)
-	logrus.Info("rpc PushMsg :%v ", pushMsgReq)
+	logrus.Infof("rpc PushMsg :%v ", pushMsgReq)
 	if pushMsgReq == nil {
 		logrus.Errorf("rpc PushSingleMsg() args:(%v)", pushMsgReq)
 		return
 	}
-	// 通过服务器找到筒子，通过筒子找到对应的节点Channel，然后推
-	bucket = DefaultServer.Bucket(int(pushMsgReq.UserId))
-	if channel = bucket.Channel(int(pushMsgReq.UserId)); channel != nil {
-		err = channel.Push(pushMsgReq.Msg)
-		logrus.Infof("DefaultServer Channel err nil ,args: %v", pushMsgReq)
+	// 通过服务器找到筒子，通过筒子找到这个用户所有设备的Channel，然后每个都推
+	channels := DefaultServer.Bucket(int(pushMsgReq.UserId)).Channels(int(pushMsgReq.UserId))
+	if len(channels) == 0 {
+		// 用户不在本connect层上，告诉task层转存离线消息
+		successReply.Code = config.OfflineReplyCode
+		successReply.Msg = "user offline"
+		logrus.Infof("DefaultServer Channel not found ,args: %v", pushMsgReq)
 		return
 	}
+	// 某个设备推失败只记日志，不能给task层返回错误：task会整条重试，已经推成功的设备就收到重复消息
+	// 开了确认的设备靠重传和断线转存离线补上，没开确认的设备只能从历史消息里查到
+	for _, channel := range channels {
+		if pushErr := channel.PushSingle(pushMsgReq.Msg); pushErr != nil {
+			logrus.Warnf("push single msg to user %d device %s err:%s", pushMsgReq.UserId, channel.device, pushErr.Error())
+		}
+	}
 	successReply.Code = config.SuccessReplyCode
 	successReply.Msg = config.SuccessReplyMsg
 	logrus.Infof("successReply:%v", successReply)
@@ -2,9 +2,11 @@ package connect
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
+	"yoyichat/config"
 	"yoyichat/pb/logic_pb"
 	"yoyichat/tools"
 
@@ -31,6 +33,9 @@ type ServerOptions struct {
 	ReadBufferSize  int           // 读缓冲
 	WriteBufferSize int           // 写缓冲
 	BroadcastSize   int           // 广播队列大小？？
+	AckTimeout      time.Duration // 单聊消息等待客户端确认的超时，超时重传
+	AckMaxRetry     int           // 最大重传次数
+	AckMaxPending   int           // 每个连接最多同时等待确认的消息数
 }
 
 func NewServer(b []*Bucket, o Operator, options ServerOptions) *Server {
@@ -54,8 +59,10 @@ func (s *Server) Bucket(userId int) *Bucket {
 func (s *Server) writePump(ch *Channel, c *Connect) {
 	//PingPeriod default eq 54s
 	ticker := time.NewTicker(s.Options.PingPeriod)
+	ackTicker := time.NewTicker(s.Options.AckTimeout)
 	defer func() {
 		ticker.Stop()
+		ackTicker.Stop()
 		ch.conn.Close()
 	}()
 	// 1.变化：没有打包发送？
@@ -74,14 +81,15 @@ func (s *Server) writePump(ch *Channel, c *Connect) {
 			// 消息分帧？
 			w, err := ch.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
-				logrus.Warn(" ch.conn.NextWriter err :%s  ", err.Error())
+				logrus.Warnf(" ch.conn.NextWriter err :%s  ", err.Error())
 				return
 			}
 			logrus.Infof("message write body:%s", message.Body)
-			w.Write(message.Body)
+			w.Write(ch.encodeBody(message))
 			if err := w.Close(); err != nil {
 				return
 			}
+			ch.written.Add(1)
 		case <-ticker.C:
 			//heartbeat，if ping error will exit and close current websocket conn
 			ch.conn.SetWriteDeadline(time.Now().Add(s.Options.WriteWait))
@@ -89,6 +97,9 @@ func (s *Server) writePump(ch *Channel, c *Connect) {
 			if err := ch.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+			s.heartbeat(ch, c.ServerId)
+		case <-ackTicker.C:
+			s.retransmit(ch)
 		}
 	}
 }
@@ -96,16 +107,15 @@ func (s *Server) writePump(ch *Channel, c *Connect) {
 func (s *Server) readPump(ch *Channel, c *Connect) {
 	defer func() {
 		logrus.Infof("start exec disConnect ...")
-		if ch.Room == nil || ch.userId == 0 {
-			logrus.Infof("roomId and userId eq 0")
+		// 没有订阅房间也要走断开流程，清理桶和未确认消息
+		if ch.userId == 0 {
+			logrus.Infof("userId eq 0")
 			ch.conn.Close()
 			return
 		}
 		logrus.Infof("exec disConnect ...")
-		disConnectRequest := new(logic_pb.DisConnectRequest)
-		disConnectRequest.RoomId = int32(ch.Room.Id)
-		disConnectRequest.UserId = int32(ch.userId)
-		s.Bucket(ch.userId).DeleteChannel(ch)
+		roomIds, removed := s.Bucket(ch.userId).DeleteChannel(ch)
+		disConnectRequest := s.disConnectRequest(ch, roomIds, removed, c.ServerId)
 		if err := s.operator.DisConnect(disConnectRequest); err != nil {
 			logrus.Warnf("DisConnect err :%s", err.Error())
 		}
@@ -133,32 +143,106 @@ func (s *Server) readPump(ch *Channel, c *Connect) {
 		if message == nil {
 			return
 		}
-		var connReq *logic_pb.ConnectRequest
+		ch.markActive()
+		// 客户端发来的帧统一按 SendTcpMsg 解析，没带op的当作建立连接请求，兼容原来直接发 ConnectRequest 的客户端
+		var clientMsg *logic_pb.SendTcpMsg
 		logrus.Infof("get a message :%s", message)
-		if err := json.Unmarshal([]byte(message), &connReq); err != nil {
-			logrus.Errorf("message struct %+v", connReq)
+		if err := json.Unmarshal([]byte(message), &clientMsg); err != nil {
+			logrus.Errorf("message struct %+v", clientMsg)
 		}
-		if connReq == nil || connReq.AuthToken == "" {
-			logrus.Errorf("s.operator.Connect no authToken")
+		if clientMsg == nil {
 			return
 		}
-		connReq.ServerId = c.ServerId //config.Conf.Connect.ConnectWebsocket.ServerId
-		userId, err := s.operator.Connect(connReq)
-		if err != nil {
-			logrus.Errorf("s.operator.Connect error %s", err.Error())
-			return
-		}
-		if userId == 0 {
-			logrus.Error("Invalid AuthToken ,userId empty")
-			return
+		switch clientMsg.Op {
+		case config.OpMsgAck:
+			s.ack(ch, clientMsg.MsgId)
+		case config.OpReadReceipt:
+			s.readReceipt(ch, clientMsg)
+		case config.OpRoomSubscribe:
+			s.subscribeRoom(ch, clientMsg.RoomId)
+		case config.OpRoomUnsubscribe:
+			s.unsubscribeRoom(ch, clientMsg.RoomId)
+		case config.OpTyping:
+			s.typing(ch, clientMsg)
+		default:
+			if err := s.connectWs(ch, c, clientMsg); err != nil {
+				logrus.Errorf("websocket connect err:%s", err.Error())
+				return
+			}
 		}
-		logrus.Infof("websocket rpc call return userId:%d,RoomId:%d", userId, connReq.RoomId)
-		b := s.Bucket(userId)
-		//insert into a bucket
-		err = b.Put(userId, int(connReq.RoomId), ch)
-		if err != nil {
-			logrus.Errorf("conn close err: %s", err.Error())
-			ch.conn.Close()
+	}
+}
+
+// 建立连接：调用logic层认证并加入房间，然后入桶
+func (s *Server) connectWs(ch *Channel, c *Connect, clientMsg *logic_pb.SendTcpMsg) (err error) {
+	if clientMsg.AuthToken == "" {
+		return errors.New("s.operator.Connect no authToken")
+	}
+	connReq := &logic_pb.ConnectRequest{
+		AuthToken: clientMsg.AuthToken,
+		RoomId:    clientMsg.RoomId,
+		ServerId:  c.ServerId, //config.Conf.Connect.ConnectWebsocket.ServerId
+		Ack:       clientMsg.Ack,
+	}
+	connReply, err := s.operator.Connect(connReq)
+	if err != nil {
+		return
+	}
+	userId := int(connReply.UserId)
+	if userId == 0 {
+		return errors.New("Invalid AuthToken ,userId empty")
+	}
+	logrus.Infof("websocket rpc call return userId:%d,RoomId:%d", userId, connReq.RoomId)
+	ch.setVersion(clientMsg.Version)
+	if clientMsg.Ack {
+		ch.enableAck(s.Options.AckMaxPending)
+	}
+	b := s.Bucket(userId)
+	// 先挂起实时推送再入桶，入桶后取离线消息推完再放行
+	ch.hold()
+	//insert into a bucket
+	if err = b.Put(userId, connReply.Device, int(connReq.RoomId), ch); err != nil {
+		logrus.Errorf("conn close err: %s", err.Error())
+		ch.conn.Close()
+		return nil
+	}
+	s.flushOffline(ch, c.ServerId)
+	return
+}
+
+// 登记设备在线并推送离线消息，等writer写出去之后让logic层删掉写出去的那些，没写完的留在收件箱里下次再推
+// 调用前要先 hold 再入桶：设备登记之前单聊不会推到这里，登记之后推过来的都能找到Channel，
+// 先挂起，推完离线消息再放行，保证离线消息在新消息前面，也不会漏
+func (s *Server) flushOffline(ch *Channel, serverId string) {
+	defer ch.release()
+	msgs, err := s.operator.Online(&logic_pb.OnlineRequest{
+		UserId:   int32(ch.userId),
+		Device:   ch.device,
+		ServerId: serverId,
+	})
+	if err != nil {
+		logrus.Warnf("user %d online err:%s", ch.userId, err.Error())
+		return
+	}
+	if len(msgs) == 0 {
+		return
+	}
+	base := ch.written.Load()
+	pushed, err := ch.PushOffline(msgs, s.Options.WriteWait)
+	if err != nil {
+		logrus.Warnf("push offline msg to user %d err:%s", ch.userId, err.Error())
+	}
+	written := ch.waitWritten(base+int64(pushed), s.Options.WriteWait) - base
+	trimReq := &logic_pb.OfflineTrimRequest{UserId: int32(ch.userId)}
+	for _, body := range msgs[:written] {
+		if msgId := offlineMsgId(body); msgId > 0 {
+			trimReq.MsgIds = append(trimReq.MsgIds, msgId)
 		}
 	}
+	if len(trimReq.MsgIds) == 0 {
+		return
+	}
+	if err = s.operator.TrimOffline(trimReq); err != nil {
+		logrus.Warnf("trim offline msg of user %d err:%s", ch.userId, err.Error())
+	}
 }
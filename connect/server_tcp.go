@@ -107,18 +107,16 @@ func (c *Connect) readDataFromTcp(s *Server, ch *Channel) {
 	defer func() {
 		// 连接断开时的清理逻辑
 		logrus.Infof("start exec disConnect ...")
-		if ch.Room == nil || ch.userId == 0 {
-			logrus.Infof("roomId and userId eq 0")
+		// 没有订阅房间也要走断开流程，清理桶和未确认消息
+		if ch.userId == 0 {
+			logrus.Infof("userId eq 0")
 			_ = ch.connTcp.Close()
 			return
 		}
 		logrus.Infof("exec disConnect ...")
-		disConnectRequest := new(logic_pb.DisConnectRequest)
-		disConnectRequest.RoomId = int32(ch.Room.Id)
-		disConnectRequest.UserId = int32(ch.userId)
-
-		// 筒子中删掉这个ch
-		s.Bucket(ch.userId).DeleteChannel(ch)
+		// 筒子中删掉这个ch，同时退出它订阅的所有房间
+		roomIds, removed := s.Bucket(ch.userId).DeleteChannel(ch)
+		disConnectRequest := s.disConnectRequest(ch, roomIds, removed, c.ServerId)
 
 		// rpc代理处理离开房间
 		if err := s.operator.DisConnect(disConnectRequest); err != nil {
@@ -165,6 +163,7 @@ func (c *Connect) readDataFromTcp(s *Server, ch *Channel) {
 		}
 		// 根据分包逻辑，对每一个包进行针对性操作
 		for scannerPackage.Scan() {
+			ch.markActive()
 			scannedPack := new(stickpackage.StickPackage)
 			// 调用一下Bytes就Split一下，然后返回出结果
 			// 这里似乎只是检验一下是都读取不出错，貌似下下面才是读取数据
@@ -180,60 +179,86 @@ func (c *Connect) readDataFromTcp(s *Server, ch *Channel) {
 			var rawTcpMsg logic_pb.SendTcpMsg
 			// 原来这个data部分也是一个结构体序列化来的，是TCP连接的元信息
 			if err := json.Unmarshal([]byte(scannedPack.Msg), &rawTcpMsg); err != nil {
-				logrus.Errorf("tcp message struct %+v", rawTcpMsg)
+				logrus.Errorf("tcp message struct %+v", &rawTcpMsg)
 				break
 			}
-			logrus.Infof("json unmarshal,raw tcp msg is:%+v", rawTcpMsg)
-			if rawTcpMsg.AuthToken == "" {
-				logrus.Errorf("tcp s.operator.Connect no authToken")
-				return
-			}
-			if rawTcpMsg.RoomId <= 0 {
-				logrus.Errorf("tcp roomId not allow lgt 0")
-				return
+			logrus.Infof("json unmarshal,raw tcp msg is:%+v", &rawTcpMsg)
+			// 建连和发群聊消息需要带上令牌和房间号，确认之类的操作用的是已经建立好的连接
+			if rawTcpMsg.Op == config.OpBuildTcpConn || rawTcpMsg.Op == config.OpRoomSend {
+				if rawTcpMsg.AuthToken == "" {
+					logrus.Errorf("tcp s.operator.Connect no authToken")
+					return
+				}
+				if rawTcpMsg.RoomId <= 0 {
+					logrus.Errorf("tcp roomId not allow lgt 0")
+					return
+				}
 			}
 			switch rawTcpMsg.Op {
 			case config.OpBuildTcpConn:
 				connReq.AuthToken = rawTcpMsg.AuthToken
 				connReq.RoomId = rawTcpMsg.RoomId
+				connReq.Ack = rawTcpMsg.Ack
 				//fix
 				//connReq.ServerId = config.Conf.Connect.ConnectTcp.ServerId
 				connReq.ServerId = c.ServerId
 
 				// 加入房间，其实就是rpc调用logic注册的服务
-				userId, err := s.operator.Connect(&connReq)
-				logrus.Infof("tcp s.operator.Connect userId is :%d", userId)
+				connReply, err := s.operator.Connect(&connReq)
 				if err != nil {
 					logrus.Errorf("tcp s.operator.Connect error %s", err.Error())
 					return
 				}
+				userId := int(connReply.UserId)
+				logrus.Infof("tcp s.operator.Connect userId is :%d", userId)
 				if userId == 0 {
 					logrus.Error("tcp Invalid AuthToken ,userId empty")
 					return
 				}
 
+				ch.setVersion(rawTcpMsg.Version)
+				if rawTcpMsg.Ack {
+					ch.enableAck(s.Options.AckMaxPending)
+				}
 				// 这是入桶吗？
 				b := s.Bucket(userId)
+				ch.hold()
 				//insert into a bucket
-				err = b.Put(userId, int(connReq.RoomId), ch)
+				err = b.Put(userId, connReply.Device, int(connReq.RoomId), ch)
 				if err != nil {
 					logrus.Errorf("tcp conn put room err: %s", err.Error())
 					_ = ch.connTcp.Close()
 					return
 				}
+				s.flushOffline(ch, c.ServerId)
 			case config.OpRoomSend:
+				// 发送者以建连时鉴权出来的用户为准，用户名由logic按用户ID查，禁言等权限由logic检查
+				if ch.userId == 0 {
+					logrus.Errorf("tcp room send before build conn")
+					break
+				}
 				//send tcp msg to room
 				req := &logic_pb.SendMsg{
-					Msg:          rawTcpMsg.Msg,
-					FromUserId:   rawTcpMsg.FromUserId,
-					FromUserName: rawTcpMsg.FromUserName,
-					RoomId:       rawTcpMsg.RoomId,
-					Op:           config.OpRoomSend,
+					Msg:        rawTcpMsg.Msg,
+					FromUserId: int32(ch.userId),
+					RoomId:     rawTcpMsg.RoomId,
+					Op:         config.OpRoomSend,
+					ReplyTo:    rawTcpMsg.ReplyTo,
 				}
 
 				// 这个rpc为什么是api层中的rpc实例？调用的还是logic在etcd中注册的服务
 				code, msg := rpc.RpcLogicObj.PushRoom(req)
 				logrus.Infof("tcp conn push msg to room,err code is:%d,err msg is:%s", code, msg)
+			case config.OpMsgAck:
+				s.ack(ch, rawTcpMsg.MsgId)
+			case config.OpReadReceipt:
+				s.readReceipt(ch, &rawTcpMsg)
+			case config.OpRoomSubscribe:
+				s.subscribeRoom(ch, rawTcpMsg.RoomId)
+			case config.OpRoomUnsubscribe:
+				s.unsubscribeRoom(ch, rawTcpMsg.RoomId)
+			case config.OpTyping:
+				s.typing(ch, &rawTcpMsg)
 			}
 		}
 		// 读到了一个空包EOF
@@ -248,9 +273,11 @@ func (c *Connect) writeDataToTcp(s *Server, ch *Channel) {
 	//ping time default 54s
 	// 心跳间隔创建了一个计时器？
 	ticker := time.NewTicker(DefaultServer.Options.PingPeriod)
+	ackTicker := time.NewTicker(s.Options.AckTimeout)
 	defer func() {
 		// 计时器停止，然后关闭套接字
 		ticker.Stop()
+		ackTicker.Stop()
 		_ = ch.connTcp.Close()
 		return
 	}()
@@ -266,7 +293,7 @@ func (c *Connect) writeDataToTcp(s *Server, ch *Channel) {
 				_ = ch.connTcp.Close()
 				return
 			}
-			pack.Msg = message.Body
+			pack.Msg = ch.encodeBody(message)
 			pack.Length = pack.GetPackageLength()
 			//send msg
 			logrus.Infof("send tcp msg to conn:%s", pack.String())
@@ -278,6 +305,7 @@ func (c *Connect) writeDataToTcp(s *Server, ch *Channel) {
 				logrus.Errorf("connTcp.write message err:%s", err.Error())
 				return
 			}
+			ch.written.Add(1)
 		case <-ticker.C: // 这是心跳保活，发ping msg，但是是发给谁的呢？
 			// 也许是发给与服务器建立连接的客户端的，确认客户端是否存活吗？那么就还差 pong
 			logrus.Infof("connTcp.ping message,send")
@@ -288,6 +316,10 @@ func (c *Connect) writeDataToTcp(s *Server, ch *Channel) {
 				//send ping msg to tcp conn
 				return
 			}
+			s.heartbeat(ch, c.ServerId)
+		case <-ackTicker.C:
+			// 重传超时没确认的单聊消息
+			s.retransmit(ch)
 		}
 	}
 }
@@ -0,0 +1,39 @@
+package connect
+
+import (
+	"github.com/sirupsen/logrus"
+	"yoyichat/pb/logic_pb"
+)
+
+// 已建立的连接上订阅房间，先让logic层检查成员身份并记录在线，再加入桶里的房间
+func (s *Server) subscribeRoom(ch *Channel, roomId int32) {
+	if ch.userId == 0 || roomId <= 0 {
+		logrus.Warnf("subscribe room %d before connect", roomId)
+		return
+	}
+	req := &logic_pb.RoomRequest{UserId: int32(ch.userId), RoomId: roomId}
+	if err := s.operator.SubscribeRoom(req); err != nil {
+		logrus.Warnf("operator subscribe room err:%s", err.Error())
+		return
+	}
+	if err := s.Bucket(ch.userId).Subscribe(ch, int(roomId)); err != nil {
+		logrus.Warnf("bucket subscribe room %d err:%s", roomId, err.Error())
+		if err = s.operator.UnsubscribeRoom(req); err != nil {
+			logrus.Warnf("operator unsubscribe room err:%s", err.Error())
+		}
+	}
+}
+
+// 取消订阅，没订阅过的房间忽略
+func (s *Server) unsubscribeRoom(ch *Channel, roomId int32) {
+	if ch.userId == 0 {
+		return
+	}
+	if !s.Bucket(ch.userId).Unsubscribe(ch, int(roomId)) {
+		return
+	}
+	req := &logic_pb.RoomRequest{UserId: int32(ch.userId), RoomId: roomId}
+	if err := s.operator.UnsubscribeRoom(req); err != nil {
+		logrus.Warnf("operator unsubscribe room err:%s", err.Error())
+	}
+}
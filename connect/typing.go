@@ -0,0 +1,23 @@
+package connect
+
+import (
+	"github.com/sirupsen/logrus"
+	"yoyichat/pb/logic_pb"
+)
+
+// 客户端上报开始/停止输入，单聊带 to_user_id，群聊带 room_id
+func (s *Server) typing(ch *Channel, clientMsg *logic_pb.SendTcpMsg) {
+	if ch.userId == 0 {
+		logrus.Warnf("typing before connect")
+		return
+	}
+	req := &logic_pb.TypingRequest{
+		UserId:   int32(ch.userId),
+		ToUserId: clientMsg.ToUserId,
+		RoomId:   clientMsg.RoomId,
+		Typing:   clientMsg.Typing,
+	}
+	if err := s.operator.Typing(req); err != nil {
+		logrus.Warnf("operator typing err:%s", err.Error())
+	}
+}
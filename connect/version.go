@@ -0,0 +1,44 @@
+package connect
+
+import (
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"yoyichat/config"
+	"yoyichat/pb/connect_pb"
+	"yoyichat/pb/logic_pb"
+)
+
+// 建连时记下客户端支持的协议版本，不传的是只认文本的老客户端
+func (ch *Channel) setVersion(version int32) {
+	if version <= 0 {
+		version = config.MsgVersionText
+	}
+	ch.version.Store(version)
+}
+
+// 带 SendMsg 消息体的推送
+func carrySendMsg(op int32) bool {
+	switch op {
+	case config.OpSingleSend, config.OpRoomSend, config.OpMsgEdit, config.OpMsgDelete, config.OpThreadReply, config.OpMention:
+		return true
+	}
+	return false
+}
+
+// 写给客户端的消息体：客户端版本比消息低时去掉结构化的 payload，只留 msg 文本
+func (ch *Channel) encodeBody(msg *connect_pb.Msg) []byte {
+	if msg.Ver <= ch.version.Load() || !carrySendMsg(msg.Op) {
+		return msg.Body
+	}
+	sendMsg := &logic_pb.SendMsg{}
+	if err := proto.Unmarshal(msg.Body, sendMsg); err != nil || sendMsg.Payload == nil {
+		return msg.Body
+	}
+	sendMsg.Payload = nil
+	body, err := proto.Marshal(sendMsg)
+	if err != nil {
+		logrus.Warnf("encode msg body for version %d err:%s", ch.version.Load(), err.Error())
+		return msg.Body
+	}
+	return body
+}
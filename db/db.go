@@ -30,14 +30,16 @@ func initDB(dbName string) {
 	dbMap[dbName], e = gorm.Open(sqlite.Open(configFilePath), &gorm.Config{
 		Logger: logger.New(log.Default(), logConfig),
 	})
+	// 库文件打不开多半是工作目录不对，直接退出，不能带着一个空库跑起来
+	if e != nil {
+		syncLock.Unlock()
+		logrus.Panicf("connect db %s fail:%s", configFilePath, e.Error())
+	}
 	db, _ := dbMap[dbName].DB()
 	db.SetMaxIdleConns(4)
 	db.SetMaxOpenConns(20)
 	db.SetConnMaxLifetime(time.Second * 8)
 	syncLock.Unlock()
-	if e != nil {
-		logrus.Error("connect db fail:%s", e.Error())
-	}
 }
 
 func GetDB(dbName string) *gorm.DB {
@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"strconv"
+	"time"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+func (logic *Logic) getDeliveredKey(msgId int64) string {
+	return config.RedisDeliveredPrefix + strconv.FormatInt(msgId, 10)
+}
+
+// 记下消息已经被接收方的某个设备确认，返回是不是第一次确认
+func (logic *Logic) markDelivered(msgId int64) (first bool, err error) {
+	return RedisClient.SetNX(logic.getDeliveredKey(msgId), 1, config.RedisBaseValidTime*time.Second).Result()
+}
+
+// 断开时没确认的消息要不要放回离线收件箱
+// 用户的其他设备已经确认过，或者已读位置已经越过这条消息的，就不用再推了
+func (logic *Logic) needRestoreUnacked(userId int, body []byte) bool {
+	sendData := new(logic_pb.SendMsg)
+	if err := proto.Unmarshal(body, sendData); err != nil {
+		return true
+	}
+	if sendData.MsgId > 0 {
+		n, err := RedisClient.Exists(logic.getDeliveredKey(sendData.MsgId)).Result()
+		if err != nil {
+			logrus.Warnf("check msg %d delivered err : %s", sendData.MsgId, err)
+		} else if n > 0 {
+			return false
+		}
+	}
+	if sendData.Seq > 0 && sendData.ConversationId != "" {
+		if new(dao.ReadCursor).GetSeq(userId, sendData.ConversationId) >= sendData.Seq {
+			return false
+		}
+	}
+	return true
+}
+
+// 把单聊消息的投递状态推给发送方
+// 发送方不在线就不推了，状态事件不进离线收件箱，重连后可以通过历史消息重新拉取
+func (logic *Logic) pushDeliveryState(sendData *logic_pb.SendMsg, state string) (err error) {
+	serverIds := logic.getUserServerIds(int(sendData.FromUserId))
+	if len(serverIds) == 0 {
+		return
+	}
+	stateMsg := &logic_pb.DeliveryStateMsg{
+		Op:             config.OpDeliveryState,
+		MsgId:          sendData.MsgId,
+		ConversationId: sendData.ConversationId,
+		Seq:            sendData.Seq,
+		ToUserId:       sendData.ToUserId,
+		State:          state,
+	}
+	body, err := proto.Marshal(stateMsg)
+	if err != nil {
+		return
+	}
+	for _, serverId := range serverIds {
+		if err = logic.RedisPublishEvent(config.OpDeliveryState, serverId, int(sendData.FromUserId), 0, body); err != nil {
+			return
+		}
+	}
+	return
+}
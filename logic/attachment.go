@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"errors"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+func toAttachmentPb(a *dao.Attachment) *logic_pb.Attachment {
+	return &logic_pb.Attachment{
+		Id:       a.Id,
+		Name:     a.Name,
+		Size:     a.Size,
+		MimeType: a.MimeType,
+		Checksum: a.Checksum,
+	}
+}
+
+// 发消息时带的附件只有ID，这里检查是不是自己上传的、有没有用过，并补全元信息
+// 返回去重后的附件ID，落库时绑定到消息上
+func (logic *Logic) loadAttachments(sendData *logic_pb.SendMsg) (attachmentIds []int64, err error) {
+	if len(sendData.Attachments) == 0 {
+		return
+	}
+	seen := make(map[int64]bool)
+	for _, attachment := range sendData.Attachments {
+		if attachment.Id > 0 && !seen[attachment.Id] {
+			seen[attachment.Id] = true
+			attachmentIds = append(attachmentIds, attachment.Id)
+		}
+	}
+	if len(attachmentIds) > config.AttachmentMaxPerMsg {
+		return nil, errors.New("too many attachments")
+	}
+	list := new(dao.Attachment).GetByIds(attachmentIds)
+	if len(list) != len(attachmentIds) {
+		return nil, dao.ErrAttachmentUsed
+	}
+	sendData.Attachments = sendData.Attachments[:0]
+	for i := range list {
+		if list[i].UploaderId != int(sendData.FromUserId) || list[i].MessageId != 0 {
+			return nil, dao.ErrAttachmentUsed
+		}
+		sendData.Attachments = append(sendData.Attachments, toAttachmentPb(&list[i]))
+	}
+	return
+}
+
+// 查历史时把附件一起带上，删除了的消息不返回附件
+func (logic *Logic) fillAttachments(msgs []*logic_pb.SendMsg) {
+	msgIds := make([]int64, 0, len(msgs))
+	msgIndex := make(map[int64]*logic_pb.SendMsg, len(msgs))
+	for _, msg := range msgs {
+		if !msg.Deleted {
+			msgIds = append(msgIds, msg.MsgId)
+			msgIndex[msg.MsgId] = msg
+		}
+	}
+	list := new(dao.Attachment).GetByMessageIds(msgIds)
+	for i := range list {
+		if msg, ok := msgIndex[list[i].MessageId]; ok {
+			msg.Attachments = append(msg.Attachments, toAttachmentPb(&list[i]))
+		}
+	}
+}
+
+// 能否下载附件：上传者本人，或者能看到附件所在消息的人
+// 单聊里的附件只有双方能下载，同房间的人不行，和 canSeeMessage 一样按 op 区分单聊
+func (logic *Logic) canSeeAttachment(a *dao.Attachment, userId int) bool {
+	if a.UploaderId == userId {
+		return true
+	}
+	// 还没发出去的，或者正在被当作孤儿清理的
+	if a.MessageId <= 0 {
+		return false
+	}
+	m := new(dao.Message).GetMessageById(a.MessageId)
+	return m.Id > 0 && !m.Deleted && logic.canSeeMessage(&m, userId)
+}
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"time"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 联系人操作的对象，可以直接给用户ID，也可以给用户名
+func (logic *Logic) getContactTargetId(req *logic_pb.ContactRequest) int {
+	if req.TargetUserId > 0 {
+		return int(req.TargetUserId)
+	}
+	if req.TargetUserName != "" {
+		return new(dao.User).GetUserIdByUserName(req.TargetUserName)
+	}
+	return 0
+}
+
+// 单聊、好友申请、拉黑的对象必须是存在的其他用户
+func (logic *Logic) checkContactTarget(userId, targetUserId int) error {
+	if userId <= 0 || targetUserId <= 0 || userId == targetUserId {
+		return errors.New("target user id invalid")
+	}
+	if new(dao.User).GetUserNameByUserId(targetUserId) == "" {
+		return errors.New("target user not exist")
+	}
+	return nil
+}
+
+func (logic *Logic) toFriendRequestPb(f *dao.FriendRequest) *logic_pb.FriendRequest {
+	u := new(dao.User)
+	return &logic_pb.FriendRequest{
+		Id:           f.Id,
+		FromUserId:   int32(f.FromUserId),
+		FromUserName: u.GetUserNameByUserId(f.FromUserId),
+		ToUserId:     int32(f.ToUserId),
+		ToUserName:   u.GetUserNameByUserId(f.ToUserId),
+		Message:      f.Message,
+		Status:       f.Status,
+		CreateTime:   f.CreateTime.Format(time.DateTime),
+	}
+}
+
+// 联系人事件实时推给对方，对方不在线就不推了，上线后可以从联系人列表里看到待处理的申请
+func (logic *Logic) pushContactEvent(toUserId int, event string, f *dao.FriendRequest) (err error) {
+	serverIds := logic.getUserServerIds(toUserId)
+	if len(serverIds) == 0 {
+		return
+	}
+	eventMsg := &logic_pb.ContactEventMsg{
+		Op:      config.OpContact,
+		Event:   event,
+		Request: logic.toFriendRequestPb(f),
+	}
+	body, err := proto.Marshal(eventMsg)
+	if err != nil {
+		return
+	}
+	for _, serverId := range serverIds {
+		if err = logic.RedisPublishEvent(config.OpContact, serverId, toUserId, 0, body); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 联系人列表带上在线状态，在线与否看有没有设备连着connect层
+func (logic *Logic) getContacts(userId int) (contacts []*logic_pb.Contact) {
+	u := new(dao.User)
+	for _, c := range new(dao.Contact).GetByUserId(userId) {
+		contacts = append(contacts, &logic_pb.Contact{
+			UserId:   int32(c.ContactUserId),
+			UserName: u.GetUserNameByUserId(c.ContactUserId),
+			Status:   c.Status,
+			Online:   c.Status == config.ContactStatusFriend && logic.isUserOnline(c.ContactUserId),
+		})
+	}
+	return
+}
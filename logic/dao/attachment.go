@@ -0,0 +1,116 @@
+package dao
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+	"yoyichat/db"
+)
+
+var ErrAttachmentQuota = errors.New("attachment quota exceeded")
+
+// 正在被清理的孤儿附件，绑定消息时只认 message_id=0，认领后就不会再被发出去
+const attachmentOrphanClaimed = -1
+
+// 附件元信息，文件存在api层的存储里，这里只记key
+// 上传后 MessageId 为0，随消息发出时绑定到消息上，一个附件只能用在一条消息里
+type Attachment struct {
+	Id         int64 `gorm:"primary_key"`
+	UploaderId int   `gorm:"not null;index"`
+	MessageId  int64 `gorm:"index"`
+	Name       string
+	Size       int64
+	MimeType   string `gorm:"size:128"`
+	Checksum   string `gorm:"size:64"`
+	StorageKey string `gorm:"size:255;not null"`
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+func init() {
+	if err := dbIns.AutoMigrate(&Attachment{}); err != nil {
+		logrus.Errorf("auto migrate attachment fail:%s", err.Error())
+	}
+}
+
+func (a *Attachment) TableName() string { return "attachment" }
+
+func (a *Attachment) DbName() string {
+	return a.GetDbName()
+}
+
+func (a *Attachment) Add() error {
+	a.CreateTime = time.Now()
+	return dbIns.Table(a.TableName()).Create(a).Error
+}
+
+// 登记附件，上传者已有附件加上这个超过 quota 时返回 ErrAttachmentQuota，quota<=0 不限制
+func (a *Attachment) AddWithQuota(quota int64) error {
+	if quota <= 0 {
+		return a.Add()
+	}
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		var used int64
+		if err := tx.Table(a.TableName()).Where("uploader_id=?", a.UploaderId).
+			Select("COALESCE(SUM(size),0)").Scan(&used).Error; err != nil {
+			return err
+		}
+		if used+a.Size > quota {
+			return ErrAttachmentQuota
+		}
+		a.CreateTime = time.Now()
+		return tx.Table(a.TableName()).Create(a).Error
+	})
+}
+
+// 认领上传时间早于 before 还没绑定消息的附件，删掉记录并返回存储key，文件由调用方删除
+// 先改 message_id 占住这些记录，之后发消息就绑不上了；上次认领到一半的也一起带上
+func (a *Attachment) ClaimOrphans(before time.Time, limit int) (storageKeys []string, err error) {
+	err = dbIns.Transaction(func(tx *gorm.DB) error {
+		orphanIds := tx.Table(a.TableName()).Select("id").
+			Where("message_id=0 and create_time<?", before).Limit(limit)
+		if err := tx.Table(a.TableName()).Where("id in (?)", orphanIds).
+			Update("message_id", attachmentOrphanClaimed).Error; err != nil {
+			return err
+		}
+		var list []Attachment
+		if err := tx.Table(a.TableName()).Where("message_id=?", attachmentOrphanClaimed).Limit(limit).Find(&list).Error; err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		ids := make([]int64, 0, len(list))
+		for _, item := range list {
+			ids = append(ids, item.Id)
+			storageKeys = append(storageKeys, item.StorageKey)
+		}
+		return tx.Table(a.TableName()).Where("id in ?", ids).Delete(&Attachment{}).Error
+	})
+	if err != nil {
+		storageKeys = nil
+	}
+	return
+}
+
+func (a *Attachment) GetById(id int64) (data Attachment) {
+	dbIns.Table(a.TableName()).Where("id=?", id).Take(&data)
+	return
+}
+
+func (a *Attachment) GetByIds(ids []int64) (list []Attachment) {
+	if len(ids) == 0 {
+		return
+	}
+	dbIns.Table(a.TableName()).Where("id in ?", ids).Find(&list)
+	return
+}
+
+func (a *Attachment) GetByMessageIds(messageIds []int64) (list []Attachment) {
+	if len(messageIds) == 0 {
+		return
+	}
+	dbIns.Table(a.TableName()).Where("message_id in ?", messageIds).Order("id").Find(&list)
+	return
+}
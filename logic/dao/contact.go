@@ -0,0 +1,189 @@
+package dao
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+	"yoyichat/config"
+	"yoyichat/db"
+)
+
+// 联系人关系，有方向：(A,B,friend) 表示B在A的好友列表里，好友关系两个方向各一行
+// (A,B,blocked) 表示A拉黑了B，拉黑会顶掉A这一侧的好友关系，同时删掉B那一侧的
+type Contact struct {
+	Id            int    `gorm:"primary_key"`
+	UserId        int    `gorm:"not null;uniqueIndex:idx_user_contact"`
+	ContactUserId int    `gorm:"not null;uniqueIndex:idx_user_contact"`
+	Status        string `gorm:"size:16;not null"`
+	CreateTime    time.Time
+	db.DbYoyiChat
+}
+
+// 好友申请，同一对用户同时只会有一条待处理的申请
+type FriendRequest struct {
+	Id         int64 `gorm:"primary_key"`
+	FromUserId int   `gorm:"not null;index"`
+	ToUserId   int   `gorm:"not null;index"`
+	Message    string
+	Status     string `gorm:"size:16;not null"`
+	CreateTime time.Time
+	UpdateTime time.Time
+	db.DbYoyiChat
+}
+
+func init() {
+	if err := dbIns.AutoMigrate(&Contact{}, &FriendRequest{}); err != nil {
+		logrus.Errorf("auto migrate contact fail:%s", err.Error())
+	}
+}
+
+func (c *Contact) TableName() string { return "contact" }
+
+func (c *Contact) DbName() string {
+	return c.GetDbName()
+}
+
+func (f *FriendRequest) TableName() string { return "friend_request" }
+
+func (f *FriendRequest) DbName() string {
+	return f.GetDbName()
+}
+
+// userId 这一侧对 contactUserId 的关系，没有关系时返回空串
+func (c *Contact) GetStatus(userId, contactUserId int) string {
+	var data Contact
+	dbIns.Table(c.TableName()).Where("user_id=? and contact_user_id=?", userId, contactUserId).Limit(1).Find(&data)
+	return data.Status
+}
+
+// userId 是否拉黑了 targetUserId
+func (c *Contact) IsBlocked(userId, targetUserId int) bool {
+	return c.GetStatus(userId, targetUserId) == config.ContactStatusBlocked
+}
+
+func (c *Contact) GetByUserId(userId int) (list []Contact) {
+	dbIns.Table(c.TableName()).Where("user_id=?", userId).Order("id asc").Find(&list)
+	return
+}
+
+// 设置一侧的关系，已有记录就覆盖状态
+func setContactStatus(tx *gorm.DB, userId, contactUserId int, status string) error {
+	c := &Contact{}
+	res := tx.Table(c.TableName()).Where("user_id=? and contact_user_id=?", userId, contactUserId).
+		Update("status", status)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	return tx.Table(c.TableName()).Create(&Contact{
+		UserId:        userId,
+		ContactUserId: contactUserId,
+		Status:        status,
+		CreateTime:    time.Now(),
+	}).Error
+}
+
+// 删除一侧指定状态的关系
+func deleteContact(tx *gorm.DB, userId, contactUserId int, status string) error {
+	c := &Contact{}
+	return tx.Table(c.TableName()).Where("user_id=? and contact_user_id=? and status=?", userId, contactUserId, status).
+		Delete(&Contact{}).Error
+}
+
+// 接受好友申请：申请置为已接受，双方互加好友
+func (f *FriendRequest) Accept() error {
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(f.TableName()).Where("id=?", f.Id).Updates(map[string]interface{}{
+			"status":      config.FriendRequestStatusAccepted,
+			"update_time": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		if err := setContactStatus(tx, f.FromUserId, f.ToUserId, config.ContactStatusFriend); err != nil {
+			return err
+		}
+		return setContactStatus(tx, f.ToUserId, f.FromUserId, config.ContactStatusFriend)
+	})
+}
+
+func (f *FriendRequest) Decline() error {
+	return dbIns.Table(f.TableName()).Where("id=?", f.Id).Updates(map[string]interface{}{
+		"status":      config.FriendRequestStatusDeclined,
+		"update_time": time.Now(),
+	}).Error
+}
+
+// 新建好友申请，已经有待处理的申请时只更新附言和时间
+func (f *FriendRequest) Add() error {
+	var old FriendRequest
+	dbIns.Table(f.TableName()).Where("from_user_id=? and to_user_id=? and status=?",
+		f.FromUserId, f.ToUserId, config.FriendRequestStatusPending).Limit(1).Find(&old)
+	now := time.Now()
+	f.Status = config.FriendRequestStatusPending
+	f.UpdateTime = now
+	if old.Id > 0 {
+		f.Id = old.Id
+		f.CreateTime = old.CreateTime
+		return dbIns.Table(f.TableName()).Where("id=?", old.Id).Updates(map[string]interface{}{
+			"message":     f.Message,
+			"update_time": now,
+		}).Error
+	}
+	f.CreateTime = now
+	return dbIns.Table(f.TableName()).Create(f).Error
+}
+
+func (f *FriendRequest) GetById(id int64) (data FriendRequest) {
+	dbIns.Table(f.TableName()).Where("id=?", id).Limit(1).Find(&data)
+	return
+}
+
+// from 发给 to 的待处理申请
+func (f *FriendRequest) GetPending(fromUserId, toUserId int) (data FriendRequest) {
+	dbIns.Table(f.TableName()).Where("from_user_id=? and to_user_id=? and status=?",
+		fromUserId, toUserId, config.FriendRequestStatusPending).Limit(1).Find(&data)
+	return
+}
+
+// 用户收到和发出的待处理申请
+func (f *FriendRequest) GetPendingByUserId(userId int) (list []FriendRequest) {
+	dbIns.Table(f.TableName()).Where("(from_user_id=? or to_user_id=?) and status=?",
+		userId, userId, config.FriendRequestStatusPending).Order("id desc").Find(&list)
+	return
+}
+
+// 删除好友，双方的好友关系都删掉
+func (c *Contact) RemoveFriend(userId, contactUserId int) error {
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := deleteContact(tx, userId, contactUserId, config.ContactStatusFriend); err != nil {
+			return err
+		}
+		return deleteContact(tx, contactUserId, userId, config.ContactStatusFriend)
+	})
+}
+
+// 拉黑：自己这一侧改成拉黑，对方那一侧的好友关系删掉，双方之间待处理的申请都作废
+func (c *Contact) Block(userId, targetUserId int) error {
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := setContactStatus(tx, userId, targetUserId, config.ContactStatusBlocked); err != nil {
+			return err
+		}
+		if err := deleteContact(tx, targetUserId, userId, config.ContactStatusFriend); err != nil {
+			return err
+		}
+		f := &FriendRequest{}
+		return tx.Table(f.TableName()).
+			Where("((from_user_id=? and to_user_id=?) or (from_user_id=? and to_user_id=?)) and status=?",
+				userId, targetUserId, targetUserId, userId, config.FriendRequestStatusPending).
+			Updates(map[string]interface{}{
+				"status":      config.FriendRequestStatusDeclined,
+				"update_time": time.Now(),
+			}).Error
+	})
+}
+
+// 取消拉黑，不会恢复之前的好友关系
+func (c *Contact) Unblock(userId, targetUserId int) error {
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		return deleteContact(tx, userId, targetUserId, config.ContactStatusBlocked)
+	})
+}
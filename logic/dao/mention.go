@@ -0,0 +1,60 @@
+package dao
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+	"time"
+	"yoyichat/db"
+)
+
+// 群聊消息里的@提及，每个被提醒的人一行，@all 给房间里每个人各记一行
+type Mention struct {
+	Id         int64 `gorm:"primary_key"`
+	MessageId  int64 `gorm:"not null;uniqueIndex:idx_mention_msg_user"`
+	UserId     int   `gorm:"not null;uniqueIndex:idx_mention_msg_user;index"`
+	RoomId     int
+	FromUserId int
+	All        bool
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+// 批量写入时每批的条数，@all 的大房间会有很多行
+const mentionBatchSize = 200
+
+func init() {
+	if err := dbIns.AutoMigrate(&Mention{}); err != nil {
+		logrus.Errorf("auto migrate mention fail:%s", err.Error())
+	}
+}
+
+func (mt *Mention) TableName() string { return "mention" }
+
+func (mt *Mention) DbName() string {
+	return mt.GetDbName()
+}
+
+func (mt *Mention) AddBatch(list []Mention) error {
+	if len(list) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range list {
+		list[i].CreateTime = now
+	}
+	return dbIns.Table(mt.TableName()).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(list, mentionBatchSize).Error
+}
+
+// 用户被@的记录，按ID倒序，cursorId 大于0时只查比它早的
+func (mt *Mention) GetByUserId(userId int, cursorId int64, limit int) (list []Mention, hasMore bool) {
+	query := dbIns.Table(mt.TableName()).Where("user_id=?", userId)
+	if cursorId > 0 {
+		query = query.Where("id<?", cursorId)
+	}
+	query.Order("id desc").Limit(limit + 1).Find(&list)
+	if len(list) > limit {
+		hasMore = true
+		list = list[:limit]
+	}
+	return
+}
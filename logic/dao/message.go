@@ -0,0 +1,328 @@
+package dao
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+	"yoyichat/config"
+	"yoyichat/db"
+)
+
+// 消息持久化，单聊与群聊都落在这张表里，用会话ID区分
+// 同一会话内 seq 严格递增，(conversation_id, seq) 唯一
+// 编辑时旧内容存进 MessageRevision；删除只留墓碑：清空内容，记下删除人
+// ReplyTo 不为0的是话题回复，话题只有一层，根消息上记着回复数和最后回复时间
+type Message struct {
+	Id             int64  `gorm:"primary_key"`
+	ConversationId string `gorm:"size:64;not null;uniqueIndex:idx_conversation_seq"`
+	Seq            int64  `gorm:"not null;uniqueIndex:idx_conversation_seq"`
+	Op             int
+	FromUserId     int `gorm:"index"`
+	FromUserName   string
+	ToUserId       int `gorm:"index"`
+	ToUserName     string
+	RoomId         int `gorm:"index"`
+	Content        string
+	CreateTime     time.Time `gorm:"index"`
+	Edited         bool
+	Deleted        bool
+	DeletedBy      int
+	EditTime       time.Time
+	ReplyTo        int64 `gorm:"index"`
+	ReplyCount     int
+	LastReplyTime  time.Time
+	PayloadType    string `gorm:"size:16"` // 结构化消息体的类型，Content 是它的文本形式
+	Payload        []byte
+	AttachmentIds  []int64 `gorm:"-"` // 写入时一起绑定的附件
+	db.DbYoyiChat
+}
+
+// 关注话题的人，有新回复时通知
+type ThreadFollower struct {
+	Id         int64 `gorm:"primary_key"`
+	MessageId  int64 `gorm:"not null;uniqueIndex:idx_thread_user"`
+	UserId     int   `gorm:"not null;uniqueIndex:idx_thread_user"`
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+// 消息的历史版本，每次编辑前的内容
+type MessageRevision struct {
+	Id         int64 `gorm:"primary_key"`
+	MessageId  int64 `gorm:"not null;index"`
+	Content    string
+	EditorId   int
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+// 分配seq时可能与其他logic实例冲突，冲突后重试的次数
+const messageSeqRetry = 5
+
+var ErrAttachmentUsed = errors.New("attachment not found or already used")
+
+func init() {
+	if err := dbIns.AutoMigrate(&Message{}, &MessageRevision{}, &ThreadFollower{}); err != nil {
+		logrus.Errorf("auto migrate message fail:%s", err.Error())
+		return
+	}
+	// 早期的单聊消息带着发送时随手填的房间号落库，清掉，单聊的 room_id 一律是0
+	if err := dbIns.Table(new(Message).TableName()).Where("op=? and room_id<>0", config.OpSingleSend).
+		Update("room_id", 0).Error; err != nil {
+		logrus.Errorf("clear room id of single msgs fail:%s", err.Error())
+	}
+}
+
+func (m *Message) TableName() string { return "message" }
+
+func (m *Message) DbName() string {
+	return m.GetDbName()
+}
+
+func (mr *MessageRevision) TableName() string { return "message_revision" }
+
+func (mr *MessageRevision) DbName() string {
+	return mr.GetDbName()
+}
+
+func (tf *ThreadFollower) TableName() string { return "thread_follower" }
+
+func (tf *ThreadFollower) DbName() string {
+	return tf.GetDbName()
+}
+
+// 单聊会话ID，与双方的先后顺序无关
+func GetSingleConversationId(userIdA, userIdB int) string {
+	if userIdA > userIdB {
+		userIdA, userIdB = userIdB, userIdA
+	}
+	return fmt.Sprintf("single_%d_%d", userIdA, userIdB)
+}
+
+// 群聊会话ID
+func GetRoomConversationId(roomId int) string {
+	return fmt.Sprintf("room_%d", roomId)
+}
+
+// 写入消息，在事务内分配会话内的下一个seq，话题回复同时更新根消息的回复数
+// 带附件时在同一个事务里绑定，附件已经被别的消息用掉时整条消息写入失败
+func (m *Message) Add() (err error) {
+	if m.ConversationId == "" {
+		return errors.New("conversation_id empty!")
+	}
+	m.CreateTime = time.Now()
+	for i := 0; i < messageSeqRetry; i++ {
+		m.Id = 0
+		err = dbIns.Transaction(func(tx *gorm.DB) error {
+			var maxSeq int64
+			if err := tx.Table(m.TableName()).
+				Where("conversation_id=?", m.ConversationId).
+				Select("COALESCE(MAX(seq),0)").
+				Scan(&maxSeq).Error; err != nil {
+				return err
+			}
+			m.Seq = maxSeq + 1
+			if err := tx.Table(m.TableName()).Create(m).Error; err != nil {
+				return err
+			}
+			if err := indexMessage(tx, m); err != nil {
+				return err
+			}
+			if len(m.AttachmentIds) > 0 {
+				result := tx.Table(new(Attachment).TableName()).
+					Where("id in ? and uploader_id=? and message_id=0", m.AttachmentIds, m.FromUserId).
+					Update("message_id", m.Id)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected != int64(len(m.AttachmentIds)) {
+					return ErrAttachmentUsed
+				}
+			}
+			if m.ReplyTo == 0 {
+				return nil
+			}
+			return tx.Table(m.TableName()).Where("id=?", m.ReplyTo).Updates(map[string]interface{}{
+				"reply_count":     gorm.Expr("reply_count+1"),
+				"last_reply_time": m.CreateTime,
+			}).Error
+		})
+		if err == nil || err == ErrAttachmentUsed {
+			return
+		}
+	}
+	return
+}
+
+// 按游标分页查询会话历史，结果按seq升序返回
+// after为true时查游标之后的消息，否则查游标之前的消息；多取一条用来判断是否还有更多
+func (m *Message) GetHistory(conversationId string, cursorSeq int64, cursorTime time.Time, after bool, limit int) (list []Message, hasMore bool, err error) {
+	query := dbIns.Table(m.TableName()).Where("conversation_id=?", conversationId)
+	return m.pageHistory(query, cursorSeq, cursorTime, after, limit)
+}
+
+// 查话题里的回复，分页方式和会话历史一样，不含根消息
+func (m *Message) GetThreadHistory(threadId int64, cursorSeq int64, cursorTime time.Time, after bool, limit int) (list []Message, hasMore bool, err error) {
+	query := dbIns.Table(m.TableName()).Where("reply_to=?", threadId)
+	return m.pageHistory(query, cursorSeq, cursorTime, after, limit)
+}
+
+func (m *Message) pageHistory(query *gorm.DB, cursorSeq int64, cursorTime time.Time, after bool, limit int) (list []Message, hasMore bool, err error) {
+	if after {
+		if cursorSeq > 0 {
+			query = query.Where("seq>?", cursorSeq)
+		}
+		if !cursorTime.IsZero() {
+			query = query.Where("create_time>?", cursorTime)
+		}
+		query = query.Order("seq asc")
+	} else {
+		if cursorSeq > 0 {
+			query = query.Where("seq<?", cursorSeq)
+		}
+		if !cursorTime.IsZero() {
+			query = query.Where("create_time<?", cursorTime)
+		}
+		query = query.Order("seq desc")
+	}
+	if err = query.Limit(limit + 1).Find(&list).Error; err != nil {
+		return
+	}
+	if len(list) > limit {
+		hasMore = true
+		list = list[:limit]
+	}
+	if !after {
+		for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+			list[i], list[j] = list[j], list[i]
+		}
+	}
+	return
+}
+
+// 会话里有回复的话题，按最后回复时间倒序，cursorTime 不为零时只查最后回复早于它的
+func (m *Message) GetThreads(conversationId string, cursorTime time.Time, limit int) (list []Message, hasMore bool, err error) {
+	query := dbIns.Table(m.TableName()).Where("conversation_id=? and reply_count>0", conversationId)
+	if !cursorTime.IsZero() {
+		query = query.Where("last_reply_time<?", cursorTime)
+	}
+	if err = query.Order("last_reply_time desc").Limit(limit + 1).Find(&list).Error; err != nil {
+		return
+	}
+	if len(list) > limit {
+		hasMore = true
+		list = list[:limit]
+	}
+	return
+}
+
+func (m *Message) GetMessageById(id int64) (data Message) {
+	dbIns.Table(m.TableName()).Where("id=?", id).Take(&data)
+	return
+}
+
+func (m *Message) GetMessagesByIds(ids []int64) (list []Message) {
+	if len(ids) == 0 {
+		return
+	}
+	dbIns.Table(m.TableName()).Where("id in ?", ids).Find(&list)
+	return
+}
+
+// 编辑消息，旧内容存一个版本
+func (m *Message) Edit(content string, editorId int) error {
+	now := time.Now()
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(new(MessageRevision).TableName()).Create(&MessageRevision{
+			MessageId:  m.Id,
+			Content:    m.Content,
+			EditorId:   editorId,
+			CreateTime: now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(m.TableName()).Where("id=?", m.Id).Updates(map[string]interface{}{
+			"content":   content,
+			"edited":    true,
+			"edit_time": now,
+		}).Error; err != nil {
+			return err
+		}
+		m.Content, m.Edited, m.EditTime = content, true, now
+		return reindexMessage(tx, m)
+	})
+}
+
+// 删除消息，只留墓碑，历史版本和表情回应一起删掉
+func (m *Message) Delete(operatorId int) error {
+	now := time.Now()
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(new(MessageRevision).TableName()).Where("message_id=?", m.Id).Delete(&MessageRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(new(MessageReaction).TableName()).Where("message_id=?", m.Id).Delete(&MessageReaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(m.TableName()).Where("id=?", m.Id).Updates(map[string]interface{}{
+			"content":    "",
+			"payload":    nil,
+			"deleted":    true,
+			"deleted_by": operatorId,
+			"edit_time":  now,
+		}).Error; err != nil {
+			return err
+		}
+		m.Content, m.Payload, m.Deleted, m.DeletedBy, m.EditTime = "", nil, true, operatorId, now
+		return unindexMessage(tx, m)
+	})
+}
+
+// 会话概况，用来算未读数
+type ConversationStat struct {
+	ConversationId string
+	RoomId         int
+	PeerUserId     int
+	LastSeq        int64
+}
+
+// 用户参与过的单聊会话，对方ID = 发送方 + 接收方 - 自己
+func (m *Message) GetSingleConversationStats(userId int, op int) (list []ConversationStat) {
+	dbIns.Table(m.TableName()).
+		Select("conversation_id, MAX(seq) AS last_seq, MAX(from_user_id+to_user_id-?) AS peer_user_id", userId).
+		Where("op=? and (from_user_id=? or to_user_id=?)", op, userId, userId).
+		Group("conversation_id").
+		Scan(&list)
+	return
+}
+
+func (m *Message) GetMaxSeq(conversationId string) (maxSeq int64) {
+	dbIns.Table(m.TableName()).Where("conversation_id=?", conversationId).Select("COALESCE(MAX(seq),0)").Scan(&maxSeq)
+	return
+}
+
+// 已读位置之后别人发的消息条数
+func (m *Message) CountUnread(conversationId string, userId int, readSeq int64) (count int64) {
+	dbIns.Table(m.TableName()).Where("conversation_id=? and seq>? and from_user_id<>?", conversationId, readSeq, userId).Count(&count)
+	return
+}
+
+// 关注话题，已经关注时什么都不做
+func (tf *ThreadFollower) Follow(messageId int64, userId int) error {
+	return dbIns.Table(tf.TableName()).Clauses(clause.OnConflict{DoNothing: true}).Create(&ThreadFollower{
+		MessageId:  messageId,
+		UserId:     userId,
+		CreateTime: time.Now(),
+	}).Error
+}
+
+func (tf *ThreadFollower) Unfollow(messageId int64, userId int) error {
+	return dbIns.Table(tf.TableName()).Where("message_id=? and user_id=?", messageId, userId).Delete(&ThreadFollower{}).Error
+}
+
+func (tf *ThreadFollower) GetFollowerIds(messageId int64) (userIds []int) {
+	dbIns.Table(tf.TableName()).Where("message_id=?", messageId).Pluck("user_id", &userIds)
+	return
+}
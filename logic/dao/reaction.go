@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+	"time"
+	"yoyichat/db"
+)
+
+// 消息的表情回应，同一个人对同一条消息的同一个表情只有一行
+type MessageReaction struct {
+	Id         int64  `gorm:"primary_key"`
+	MessageId  int64  `gorm:"not null;uniqueIndex:idx_msg_user_emoji"`
+	UserId     int    `gorm:"not null;uniqueIndex:idx_msg_user_emoji"`
+	Emoji      string `gorm:"size:32;not null;uniqueIndex:idx_msg_user_emoji"`
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+// 某条消息某个表情的汇总
+type ReactionStat struct {
+	MessageId int64
+	Emoji     string
+	Count     int
+	Reacted   bool
+}
+
+func init() {
+	if err := dbIns.AutoMigrate(&MessageReaction{}); err != nil {
+		logrus.Errorf("auto migrate message reaction fail:%s", err.Error())
+	}
+}
+
+func (mr *MessageReaction) TableName() string { return "message_reaction" }
+
+func (mr *MessageReaction) DbName() string {
+	return mr.GetDbName()
+}
+
+// 添加回应，已经回应过时 added 为 false
+func (mr *MessageReaction) Add() (added bool, err error) {
+	mr.CreateTime = time.Now()
+	result := dbIns.Table(mr.TableName()).Clauses(clause.OnConflict{DoNothing: true}).Create(mr)
+	return result.RowsAffected > 0, result.Error
+}
+
+// 取消回应，没有回应过时 removed 为 false
+func (mr *MessageReaction) Remove(messageId int64, userId int, emoji string) (removed bool, err error) {
+	result := dbIns.Table(mr.TableName()).Where("message_id=? and user_id=? and emoji=?", messageId, userId, emoji).Delete(&MessageReaction{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 多条消息的回应汇总，userId 大于0时标出该用户自己回应过哪些
+// 同一条消息内按最早回应的时间排序，表情的顺序不会因为人数变化而跳动
+func (mr *MessageReaction) GetStats(messageIds []int64, userId int) (list []ReactionStat) {
+	if len(messageIds) == 0 {
+		return
+	}
+	dbIns.Table(mr.TableName()).
+		Select("message_id, emoji, COUNT(*) AS count, MAX(CASE WHEN user_id=? THEN 1 ELSE 0 END) AS reacted", userId).
+		Where("message_id in ?", messageIds).
+		Group("message_id, emoji").
+		Order("message_id, MIN(id)").
+		Scan(&list)
+	return
+}
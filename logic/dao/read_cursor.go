@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+	"yoyichat/db"
+)
+
+// 用户在每个会话里的已读位置，只进不退
+type ReadCursor struct {
+	Id             int    `gorm:"primary_key"`
+	UserId         int    `gorm:"not null;uniqueIndex:idx_user_conversation"`
+	ConversationId string `gorm:"size:64;not null;uniqueIndex:idx_user_conversation"`
+	RoomId         int
+	PeerUserId     int
+	Seq            int64
+	UpdateTime     time.Time
+	db.DbYoyiChat
+}
+
+func init() {
+	if err := dbIns.AutoMigrate(&ReadCursor{}); err != nil {
+		logrus.Errorf("auto migrate read_cursor fail:%s", err.Error())
+	}
+}
+
+func (r *ReadCursor) TableName() string { return "read_cursor" }
+
+func (r *ReadCursor) DbName() string {
+	return r.GetDbName()
+}
+
+// 更新已读位置，比已有位置小的上报直接忽略，返回是否真的前进了
+func (r *ReadCursor) Save() (advanced bool, err error) {
+	err = dbIns.Transaction(func(tx *gorm.DB) error {
+		var old ReadCursor
+		res := tx.Table(r.TableName()).Where("user_id=? and conversation_id=?", r.UserId, r.ConversationId).Limit(1).Find(&old)
+		if res.Error != nil {
+			return res.Error
+		}
+		r.UpdateTime = time.Now()
+		if res.RowsAffected == 0 {
+			advanced = true
+			return tx.Table(r.TableName()).Create(r).Error
+		}
+		if r.Seq <= old.Seq {
+			return nil
+		}
+		advanced = true
+		r.Id = old.Id
+		return tx.Table(r.TableName()).Where("id=?", old.Id).Updates(map[string]interface{}{
+			"seq":         r.Seq,
+			"update_time": r.UpdateTime,
+		}).Error
+	})
+	return
+}
+
+func (r *ReadCursor) GetByUserId(userId int) (list []ReadCursor) {
+	dbIns.Table(r.TableName()).Where("user_id=?", userId).Find(&list)
+	return
+}
+
+// 用户在某个会话里的已读位置，没有记录时返回0
+func (r *ReadCursor) GetSeq(userId int, conversationId string) (seq int64) {
+	var cursor ReadCursor
+	dbIns.Table(r.TableName()).Where("user_id=? and conversation_id=?", userId, conversationId).Limit(1).Find(&cursor)
+	return cursor.Seq
+}
@@ -0,0 +1,221 @@
+package dao
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"time"
+	"yoyichat/config"
+	"yoyichat/db"
+)
+
+// 持久化的房间，所有人都离线之后房间和成员关系依然保留
+// 在线情况仍然记在redis的 yoyichat_room_<id> 里
+type Room struct {
+	Id         int    `gorm:"primary_key"`
+	Name       string `gorm:"size:64;not null;uniqueIndex"`
+	Topic      string
+	OwnerId    int    `gorm:"not null;index"`
+	Visibility string `gorm:"size:16;not null"`
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+// 房间成员，角色见 config.RoomRole*
+type RoomMember struct {
+	Id       int    `gorm:"primary_key"`
+	RoomId   int    `gorm:"not null;uniqueIndex:idx_room_user"`
+	UserId   int    `gorm:"not null;uniqueIndex:idx_room_user;index"`
+	Role     string `gorm:"size:16;not null;default:member"`
+	JoinTime time.Time
+	db.DbYoyiChat
+}
+
+// 房间封禁名单，被封禁的人会被移出房间并且不能再加入
+type RoomBan struct {
+	Id         int `gorm:"primary_key"`
+	RoomId     int `gorm:"not null;uniqueIndex:idx_room_ban_user"`
+	UserId     int `gorm:"not null;uniqueIndex:idx_room_ban_user"`
+	OperatorId int
+	CreateTime time.Time
+	db.DbYoyiChat
+}
+
+func init() {
+	if err := dbIns.AutoMigrate(&Room{}, &RoomMember{}, &RoomBan{}); err != nil {
+		logrus.Errorf("auto migrate room fail:%s", err.Error())
+	}
+}
+
+func (r *Room) TableName() string { return "room" }
+
+func (r *Room) DbName() string {
+	return r.GetDbName()
+}
+
+func (rm *RoomMember) TableName() string { return "room_member" }
+
+func (rm *RoomMember) DbName() string {
+	return rm.GetDbName()
+}
+
+func (rb *RoomBan) TableName() string { return "room_ban" }
+
+func (rb *RoomBan) DbName() string {
+	return rb.GetDbName()
+}
+
+// 创建房间，房主自动成为成员
+func (r *Room) Add() (err error) {
+	if r.Name == "" || r.OwnerId <= 0 {
+		return errors.New("room name or owner empty!")
+	}
+	if r.Visibility != config.RoomVisibilityPrivate {
+		r.Visibility = config.RoomVisibilityPublic
+	}
+	r.CreateTime = time.Now()
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(r.TableName()).Create(r).Error; err != nil {
+			return err
+		}
+		return tx.Table(new(RoomMember).TableName()).Create(&RoomMember{
+			RoomId:   r.Id,
+			UserId:   r.OwnerId,
+			Role:     config.RoomRoleOwner,
+			JoinTime: r.CreateTime,
+		}).Error
+	})
+}
+
+func (r *Room) GetById(roomId int) (data Room) {
+	dbIns.Table(r.TableName()).Where("id=?", roomId).Limit(1).Find(&data)
+	return
+}
+
+func (r *Room) GetByName(name string) (data Room) {
+	dbIns.Table(r.TableName()).Where("name=?", name).Limit(1).Find(&data)
+	return
+}
+
+// 用户加入的房间
+func (r *Room) GetByUserId(userId int) (list []Room) {
+	dbIns.Table(r.TableName()).
+		Joins("JOIN room_member ON room_member.room_id = room.id").
+		Where("room_member.user_id=?", userId).
+		Order("room.id asc").
+		Find(&list)
+	return
+}
+
+// 改名或者改话题
+func (r *Room) Update(name, topic string) error {
+	return dbIns.Table(r.TableName()).Where("id=?", r.Id).Updates(map[string]interface{}{
+		"name":  name,
+		"topic": topic,
+	}).Error
+}
+
+// 按名字或话题搜索公开房间
+func (r *Room) SearchPublic(keyword string, limit int) (list []Room) {
+	like := "%" + keyword + "%"
+	dbIns.Table(r.TableName()).
+		Where("visibility=? and (name like ? or topic like ?)", config.RoomVisibilityPublic, like, like).
+		Order("id asc").
+		Limit(limit).
+		Find(&list)
+	return
+}
+
+func (rm *RoomMember) IsMember(roomId, userId int) bool {
+	var count int64
+	dbIns.Table(rm.TableName()).Where("room_id=? and user_id=?", roomId, userId).Count(&count)
+	return count > 0
+}
+
+func (rm *RoomMember) CountByRoomId(roomId int) (count int64) {
+	dbIns.Table(rm.TableName()).Where("room_id=?", roomId).Count(&count)
+	return
+}
+
+func (rm *RoomMember) GetRoomIdsByUserId(userId int) (roomIds []int) {
+	dbIns.Table(rm.TableName()).Where("user_id=?", userId).Pluck("room_id", &roomIds)
+	return
+}
+
+func (rm *RoomMember) GetUserIdsByRoomId(roomId int) (userIds []int) {
+	dbIns.Table(rm.TableName()).Where("room_id=?", roomId).Pluck("user_id", &userIds)
+	return
+}
+
+// 从给定的用户里筛出房间成员
+func (rm *RoomMember) FilterMembers(roomId int, userIds []int) (memberIds []int) {
+	if len(userIds) == 0 {
+		return
+	}
+	dbIns.Table(rm.TableName()).Where("room_id=? and user_id in ?", roomId, userIds).Pluck("user_id", &memberIds)
+	return
+}
+
+// 从给定的用户里筛出和 userId 至少同在一个房间的
+func (rm *RoomMember) FilterRoommates(userId int, userIds []int) (roommateIds []int) {
+	if len(userIds) == 0 {
+		return
+	}
+	roomIds := dbIns.Table(rm.TableName()).Select("room_id").Where("user_id=?", userId)
+	dbIns.Table(rm.TableName()).Where("user_id in ? and room_id in (?)", userIds, roomIds).Distinct().Pluck("user_id", &roommateIds)
+	return
+}
+
+// 成员的角色，不是成员时返回空串
+func (rm *RoomMember) GetRole(roomId, userId int) string {
+	var data RoomMember
+	dbIns.Table(rm.TableName()).Where("room_id=? and user_id=?", roomId, userId).Limit(1).Find(&data)
+	return data.Role
+}
+
+func (rm *RoomMember) SetRole(roomId, userId int, role string) error {
+	return dbIns.Table(rm.TableName()).Where("room_id=? and user_id=?", roomId, userId).Update("role", role).Error
+}
+
+// 加入房间，已经是成员时什么都不做
+func (rm *RoomMember) Add() error {
+	if rm.IsMember(rm.RoomId, rm.UserId) {
+		return nil
+	}
+	if rm.Role == "" {
+		rm.Role = config.RoomRoleMember
+	}
+	rm.JoinTime = time.Now()
+	return dbIns.Table(rm.TableName()).Create(rm).Error
+}
+
+func (rm *RoomMember) Remove(roomId, userId int) error {
+	return dbIns.Table(rm.TableName()).Where("room_id=? and user_id=?", roomId, userId).Delete(&RoomMember{}).Error
+}
+
+// 封禁并移出房间
+func (rb *RoomBan) Add() error {
+	rb.CreateTime = time.Now()
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(new(RoomMember).TableName()).Where("room_id=? and user_id=?", rb.RoomId, rb.UserId).
+			Delete(&RoomMember{}).Error; err != nil {
+			return err
+		}
+		var count int64
+		tx.Table(rb.TableName()).Where("room_id=? and user_id=?", rb.RoomId, rb.UserId).Count(&count)
+		if count > 0 {
+			return nil
+		}
+		return tx.Table(rb.TableName()).Create(rb).Error
+	})
+}
+
+func (rb *RoomBan) IsBanned(roomId, userId int) bool {
+	var count int64
+	dbIns.Table(rb.TableName()).Where("room_id=? and user_id=?", roomId, userId).Count(&count)
+	return count > 0
+}
+
+func (rb *RoomBan) Remove(roomId, userId int) error {
+	return dbIns.Table(rb.TableName()).Where("room_id=? and user_id=?", roomId, userId).Delete(&RoomBan{}).Error
+}
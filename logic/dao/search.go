@@ -0,0 +1,120 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yoyichat/config"
+)
+
+// 消息搜索的条件，会话范围由logic层按调用者的身份填好
+type SearchFilter struct {
+	UserId     int   // 调用者，单聊只查自己参与的
+	RoomIds    []int // 调用者所在的房间
+	RoomId     int   // 只查这个房间，logic层已经检查过是成员
+	Keyword    string
+	FromUserId int
+	StartTime  time.Time
+	EndTime    time.Time
+	Attachment int
+	CursorId   int64 // 上一页最后一条的消息ID
+	Limit      int
+}
+
+// 搜索命中的消息和高亮的片段
+type SearchHit struct {
+	Message
+	Snippet string
+}
+
+// 关键词以外的条件，FTS5和LIKE两种实现共用；按消息ID倒序，新的在前
+func (m *Message) searchQuery(f *SearchFilter) *gorm.DB {
+	query := dbIns.Table(m.TableName()).Where("message.deleted=?", false)
+	// 单聊还是群聊按 op 区分，单聊只查调用者是发送方或接收方的
+	if f.RoomId > 0 {
+		query = query.Where("message.op<>? and message.room_id=?", config.OpSingleSend, f.RoomId)
+	} else {
+		query = query.Where("((message.op<>? and message.room_id in ?) or (message.op=? and (message.from_user_id=? or message.to_user_id=?)))",
+			config.OpSingleSend, f.RoomIds, config.OpSingleSend, f.UserId, f.UserId)
+	}
+	if f.FromUserId > 0 {
+		query = query.Where("message.from_user_id=?", f.FromUserId)
+	}
+	if !f.StartTime.IsZero() {
+		query = query.Where("message.create_time>=?", f.StartTime)
+	}
+	if !f.EndTime.IsZero() {
+		query = query.Where("message.create_time<?", f.EndTime)
+	}
+	hasAttachment := dbIns.Table(new(Attachment).TableName()).Select("1").Where("attachment.message_id=message.id")
+	switch f.Attachment {
+	case config.SearchAttachmentWith:
+		query = query.Where("EXISTS (?)", hasAttachment)
+	case config.SearchAttachmentWithout:
+		query = query.Where("NOT EXISTS (?)", hasAttachment)
+	}
+	if f.CursorId > 0 {
+		query = query.Where("message.id<?", f.CursorId)
+	}
+	return query.Order("message.id desc").Limit(f.Limit + 1)
+}
+
+func (m *Message) searchResult(hits []SearchHit, limit int) ([]SearchHit, bool) {
+	if len(hits) > limit {
+		return hits[:limit], true
+	}
+	return hits, false
+}
+
+// 不走全文索引，直接 LIKE 消息内容，片段在这里截取
+func (m *Message) likeSearch(f *SearchFilter) (hits []SearchHit, hasMore bool, err error) {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	pattern := "%" + replacer.Replace(f.Keyword) + "%"
+	err = m.searchQuery(f).Select("message.*").Where(`message.content LIKE ? ESCAPE '\'`, pattern).Scan(&hits).Error
+	if err != nil {
+		return
+	}
+	for i := range hits {
+		hits[i].Snippet = makeSnippet(hits[i].Content, f.Keyword)
+	}
+	hits, hasMore = m.searchResult(hits, f.Limit)
+	return
+}
+
+// 截取关键词前后的一段并高亮，找不到关键词时返回开头的一段
+// SQLite 的 LIKE 只对ASCII不区分大小写，这里也一样
+func makeSnippet(content, keyword string) string {
+	start := strings.Index(strings.ToLower(content), strings.ToLower(keyword))
+	if start < 0 || !isASCIIFold(content, keyword) {
+		start = strings.Index(content, keyword)
+	}
+	if start < 0 {
+		return truncateRunes(content, 2*config.SearchSnippetRunes)
+	}
+	end := start + len(keyword)
+	before, match, after := content[:start], content[start:end], content[end:]
+	prefix, suffix := "", ""
+	if utf8.RuneCountInString(before) > config.SearchSnippetRunes {
+		runes := []rune(before)
+		before = string(runes[len(runes)-config.SearchSnippetRunes:])
+		prefix = "..."
+	}
+	if utf8.RuneCountInString(after) > config.SearchSnippetRunes {
+		after = string([]rune(after)[:config.SearchSnippetRunes])
+		suffix = "..."
+	}
+	return prefix + before + config.SearchHighlightStart + match + config.SearchHighlightEnd + after + suffix
+}
+
+// ToLower 之后字节偏移不变才能拿小写串里的位置去切原串
+func isASCIIFold(content, keyword string) bool {
+	return len(strings.ToLower(content)) == len(content) && len(strings.ToLower(keyword)) == len(keyword)
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
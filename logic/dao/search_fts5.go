@@ -0,0 +1,79 @@
+//go:build sqlite_fts5
+
+package dao
+
+import (
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"strings"
+	"unicode/utf8"
+	"yoyichat/config"
+)
+
+// FTS5 全文索引，rowid 就是消息ID，删除的消息从索引里去掉
+// 用 trigram 分词，中文不用额外分词也能按子串搜，但关键词少于3个字时用不上索引，退回 LIKE
+// 需要 go-sqlite3 也带 sqlite_fts5 标签编译：go build -tags sqlite_fts5
+
+const (
+	messageFtsTable    = "message_fts"
+	ftsTrigramMinRunes = 3
+)
+
+func init() {
+	err := dbIns.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS " + messageFtsTable + " USING fts5(content, tokenize='trigram')").Error
+	if err != nil {
+		logrus.Errorf("create message fts table fail:%s", err.Error())
+		return
+	}
+	// 启动时和消息表对一遍：第一次建索引，或者中间用没带 sqlite_fts5 的版本跑过，
+	// 那段时间发的、改的、删的消息都没进索引，这里补上，不然搜索会漏
+	if err = syncMessageIndex(); err != nil {
+		logrus.Errorf("sync message fts index fail:%s", err.Error())
+	}
+}
+
+// 补上缺的消息，去掉已删除的，内容不一致的按消息表更新
+func syncMessageIndex() error {
+	return dbIns.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+messageFtsTable+" WHERE rowid NOT IN (SELECT id FROM message WHERE deleted=?)", false).Error; err != nil {
+			return err
+		}
+		current := "(SELECT content FROM message WHERE message.id=" + messageFtsTable + ".rowid)"
+		if err := tx.Exec("UPDATE " + messageFtsTable + " SET content=" + current + " WHERE content IS NOT " + current).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO "+messageFtsTable+"(rowid, content) SELECT id, content FROM message"+
+			" WHERE deleted=? AND id NOT IN (SELECT rowid FROM "+messageFtsTable+")", false).Error
+	})
+}
+
+func indexMessage(tx *gorm.DB, m *Message) error {
+	return tx.Exec("INSERT INTO "+messageFtsTable+"(rowid, content) VALUES (?, ?)", m.Id, m.Content).Error
+}
+
+func reindexMessage(tx *gorm.DB, m *Message) error {
+	return tx.Exec("UPDATE "+messageFtsTable+" SET content=? WHERE rowid=?", m.Content, m.Id).Error
+}
+
+func unindexMessage(tx *gorm.DB, m *Message) error {
+	return tx.Exec("DELETE FROM "+messageFtsTable+" WHERE rowid=?", m.Id).Error
+}
+
+func (m *Message) Search(f *SearchFilter) (hits []SearchHit, hasMore bool, err error) {
+	if utf8.RuneCountInString(f.Keyword) < ftsTrigramMinRunes {
+		return m.likeSearch(f)
+	}
+	// 整个关键词当成一个短语，用户输入里的 FTS 语法不生效
+	phrase := `"` + strings.ReplaceAll(f.Keyword, `"`, `""`) + `"`
+	snippet := "snippet(" + messageFtsTable + ", 0, ?, ?, '...', 16) AS snippet"
+	err = m.searchQuery(f).
+		Select("message.*, "+snippet, config.SearchHighlightStart, config.SearchHighlightEnd).
+		Joins("JOIN "+messageFtsTable+" ON "+messageFtsTable+".rowid=message.id").
+		Where(messageFtsTable+" MATCH ?", phrase).
+		Scan(&hits).Error
+	if err != nil {
+		return
+	}
+	hits, hasMore = m.searchResult(hits, f.Limit)
+	return
+}
@@ -0,0 +1,20 @@
+//go:build !sqlite_fts5
+
+package dao
+
+import "gorm.io/gorm"
+
+// 没有FTS5时不维护索引，搜索直接 LIKE 消息表
+// LIKE '%关键词%' 用不上任何索引，每次搜索都要把调用者能看到的会话里的消息全扫一遍，
+// 消息量大了会很慢，还会占着数据库；生产环境要带 sqlite_fts5 标签编译
+// 之后换成带标签的版本启动时会把这期间的消息补进索引
+
+func indexMessage(tx *gorm.DB, m *Message) error { return nil }
+
+func reindexMessage(tx *gorm.DB, m *Message) error { return nil }
+
+func unindexMessage(tx *gorm.DB, m *Message) error { return nil }
+
+func (m *Message) Search(f *SearchFilter) ([]SearchHit, bool, error) {
+	return m.likeSearch(f)
+}
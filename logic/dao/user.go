@@ -2,20 +2,33 @@ package dao
 
 import (
 	"errors"
+	"github.com/sirupsen/logrus"
 	"time"
 	"yoyichat/db"
 )
 
 var dbIns = db.GetDB("yoyichat")
 
+// Password 是哈希后的密码，PasswordAlgo 记着用的哪种算法，为空的是早期存的 sha1
 type User struct {
-	Id         int `gorm:"primary_key"`
-	UserName   string
-	Password   string
-	CreateTime time.Time
+	Id           int `gorm:"primary_key"`
+	UserName     string
+	Password     string
+	PasswordAlgo string `gorm:"size:16;not null;default:''"`
+	CreateTime   time.Time
 	db.DbYoyiChat
 }
 
+// user 表是建库脚本建的，这里只补上后加的列
+func init() {
+	migrator := dbIns.Migrator()
+	if !migrator.HasColumn(&User{}, "PasswordAlgo") {
+		if err := migrator.AddColumn(&User{}, "PasswordAlgo"); err != nil {
+			logrus.Errorf("add user password_algo column fail:%s", err.Error())
+		}
+	}
+}
+
 func (u *User) TableName() string { return "user" }
 
 func (u *User) DbName() string {
@@ -43,12 +56,28 @@ func (u *User) CheckHaveUserName(userName string) (data User) {
 	return
 }
 
+// 换成新算法的哈希，登录时升级老密码用
+func (u *User) UpdatePassword(userId int, password string, algo string) error {
+	return dbIns.Table(u.TableName()).Where("id=?", userId).Updates(map[string]interface{}{
+		"password":      password,
+		"password_algo": algo,
+	}).Error
+}
+
 func (u *User) GetUserNameByUserId(userId int) (userName string) {
 	var data User
 	dbIns.Table(u.TableName()).Where("id=?", userId).Take(&data)
 	return data.UserName
 }
 
+func (u *User) GetUserIdsByUserNames(userNames []string) (userIds []int) {
+	if len(userNames) == 0 {
+		return
+	}
+	dbIns.Table(u.TableName()).Where("user_name in ?", userNames).Pluck("id", &userIds)
+	return
+}
+
 func (u *User) GetUserIdByUserName(userName string) (userId int) {
 	var data User
 	dbIns.Table(u.TableName()).Where("user_name=?", userName).Take(&data)
@@ -0,0 +1,107 @@
+package logic
+
+import (
+	"github.com/go-redis/redis"
+	"strconv"
+	"time"
+	"yoyichat/config"
+	"yoyichat/tools"
+)
+
+// 多设备：每个设备一个会话令牌，同一设备重新登录只顶掉这个设备的旧令牌
+// 在线设备记在 yoyichat_device_<uid> 哈希里，设备 => connect层serverId，单聊等消息推给所有设备
+
+// 创建会话，返回令牌
+func (logic *Logic) createSession(userId int, userName string, device string) (token string, err error) {
+	if device == "" {
+		device = tools.GetRandomToken(8)
+	}
+	deviceSessionKey := tools.GetDeviceSessionKey(userId)
+	if oldToken, _ := RedisSessClient.HGet(deviceSessionKey, device).Result(); oldToken != "" {
+		if err = RedisSessClient.Del(tools.CreateSessionId(oldToken)).Err(); err != nil {
+			return
+		}
+	}
+	token = tools.GetRandomToken(32)
+	sessionId := tools.CreateSessionId(token)
+	userData := make(map[string]interface{})
+	userData["userId"] = userId
+	userData["userName"] = userName
+	userData["device"] = device
+	validTime := config.RedisBaseValidTime * time.Second
+	pipe := RedisSessClient.TxPipeline()
+	pipe.HMSet(sessionId, userData)
+	pipe.Expire(sessionId, validTime)
+	pipe.HSet(deviceSessionKey, device, token)
+	pipe.Expire(deviceSessionKey, validTime)
+	_, err = pipe.Exec()
+	return
+}
+
+// 删除一个设备的会话
+func (logic *Logic) removeSession(userId int, device string, token string) (err error) {
+	if err = RedisSessClient.HDel(tools.GetDeviceSessionKey(userId), device).Err(); err != nil {
+		return
+	}
+	return RedisSessClient.Del(tools.CreateSessionId(token)).Err()
+}
+
+func (logic *Logic) getDeviceKey(userId int) string {
+	return config.RedisDevicePrefix + strconv.Itoa(userId)
+}
+
+// 记录设备连在哪个connect层上
+func (logic *Logic) setDeviceServer(userId int, device string, serverId string) (err error) {
+	deviceKey := logic.getDeviceKey(userId)
+	pipe := RedisClient.TxPipeline()
+	pipe.HSet(deviceKey, device, serverId)
+	pipe.Expire(deviceKey, config.RedisBaseValidTime*time.Second)
+	_, err = pipe.Exec()
+	return
+}
+
+// 心跳只续期还挂在这个connect层上的设备，设备已经断开或者换到别的connect层时不能再写回去
+func (logic *Logic) refreshDeviceServer(userId int, device string, serverId string) (ok bool, err error) {
+	deviceKey := logic.getDeviceKey(userId)
+	current, err := RedisClient.HGet(deviceKey, device).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil || current != serverId {
+		return
+	}
+	err = RedisClient.Expire(deviceKey, config.RedisBaseValidTime*time.Second).Err()
+	return err == nil, err
+}
+
+// 设备断开，设备已经重连到别的connect层时不删
+func (logic *Logic) removeDeviceServer(userId int, device string, serverId string) (err error) {
+	deviceKey := logic.getDeviceKey(userId)
+	if current, _ := RedisClient.HGet(deviceKey, device).Result(); serverId != "" && current != serverId {
+		return
+	}
+	return RedisClient.HDel(deviceKey, device).Err()
+}
+
+// 用户所有在线设备所在的connect层，去重
+func (logic *Logic) getUserServerIds(userId int) (serverIds []string) {
+	devices, err := RedisClient.HGetAll(logic.getDeviceKey(userId)).Result()
+	if err != nil {
+		return
+	}
+	seen := make(map[string]bool, len(devices))
+	for _, serverId := range devices {
+		if serverId == "" || seen[serverId] {
+			continue
+		}
+		seen[serverId] = true
+		serverIds = append(serverIds, serverId)
+	}
+	return
+}
+
+// 用户是否有设备在线
+func (logic *Logic) isUserOnline(userId int) bool {
+	count, _ := RedisClient.HLen(logic.getDeviceKey(userId)).Result()
+	return count > 0
+}
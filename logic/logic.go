@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"runtime"
+	"yoyichat/auth"
 	"yoyichat/config"
 )
 
@@ -26,6 +27,13 @@ func (logic *Logic) Run() {
 	if err := logic.InitPublishRedisClient(); err != nil {
 		logrus.Panicf("logic init publishRedisClient fail,err:%s", err.Error())
 	}
+	// jwt 认证模式下检查签名密钥，同步访问令牌吊销列表
+	if err := auth.Init(RedisSessClient); err != nil {
+		logrus.Panicf("logic init auth fail,err:%s", err.Error())
+	}
+
+	// connect层挂掉时设备不会走断开流程，靠心跳超时清理
+	go logic.sweepPresence()
 
 	//init rpc server 这里是logic => 消息队列的rpc吗？ 不对，应该是作为api => logic的rpc服务器
 	// 没想到吧，其实是connect层调用的
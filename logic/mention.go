@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/tools"
+)
+
+// 解析群聊消息里的@提及，回填到 sendData 里跟着消息一起广播，返回需要提醒的用户
+// 只提醒房间成员，不提醒自己；@all 只有房主和管理员能用
+func (logic *Logic) resolveMentions(sendData *logic_pb.SendMsg) (targetIds []int) {
+	roomId, fromUserId := int(sendData.RoomId), int(sendData.FromUserId)
+	userNames, all := tools.ParseMentions(sendData.Msg)
+	if all && !logic.isRoomManager(roomId, fromUserId) {
+		all = false
+	}
+	if len(userNames) > config.MentionMaxUsers {
+		userNames = userNames[:config.MentionMaxUsers]
+	}
+	roomMemberDao := new(dao.RoomMember)
+	userIds := new(dao.User).GetUserIdsByUserNames(userNames)
+	for _, userId := range roomMemberDao.FilterMembers(roomId, userIds) {
+		if userId != fromUserId {
+			sendData.MentionUserIds = append(sendData.MentionUserIds, int32(userId))
+		}
+	}
+	sendData.MentionAll = all
+	if !all {
+		for _, userId := range sendData.MentionUserIds {
+			targetIds = append(targetIds, int(userId))
+		}
+		return
+	}
+	for _, userId := range roomMemberDao.GetUserIdsByRoomId(roomId) {
+		if userId != fromUserId {
+			targetIds = append(targetIds, userId)
+		}
+	}
+	return
+}
+
+// 记下提及并单独提醒被@的人，没在当前连接上订阅这个房间的设备也能收到
+// 被点名@的用户不在线时和单聊消息一样进离线收件箱；@all 不进收件箱，大房间会一次写入成千上万条，
+// 离线的成员上线后通过提及记录（/history/mentions）查看
+func (logic *Logic) pushMentions(sendData *logic_pb.SendMsg, targetIds []int) (err error) {
+	if len(targetIds) == 0 {
+		return
+	}
+	mentions := make([]dao.Mention, 0, len(targetIds))
+	for _, userId := range targetIds {
+		mentions = append(mentions, dao.Mention{
+			MessageId:  sendData.MsgId,
+			UserId:     userId,
+			RoomId:     int(sendData.RoomId),
+			FromUserId: int(sendData.FromUserId),
+			All:        sendData.MentionAll,
+		})
+	}
+	if err = new(dao.Mention).AddBatch(mentions); err != nil {
+		return
+	}
+	mentionMsg := proto.Clone(sendData).(*logic_pb.SendMsg)
+	mentionMsg.Op = config.OpMention
+	body, err := proto.Marshal(mentionMsg)
+	if err != nil {
+		return
+	}
+	named := make(map[int]bool, len(sendData.MentionUserIds))
+	for _, userId := range sendData.MentionUserIds {
+		named[int(userId)] = true
+	}
+	for _, userId := range targetIds {
+		serverIds := logic.getUserServerIds(userId)
+		if len(serverIds) == 0 {
+			if !named[userId] {
+				continue
+			}
+			if err = logic.storeOfflineMsg(userId, body); err != nil {
+				logrus.Warnf("logic,pushMentions store offline err:%s", err.Error())
+			}
+			continue
+		}
+		for _, serverId := range serverIds {
+			if err = logic.RedisPublishEvent(config.OpMention, serverId, userId, 0, body); err != nil {
+				return
+			}
+		}
+	}
+	return nil
+}
+
+// 被@的消息列表，消息按当前状态返回，删除了的只剩墓碑
+func (logic *Logic) getMentions(req *logic_pb.MentionRequest, reply *logic_pb.MentionReply) {
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = config.MentionDefaultLimit
+	}
+	if limit > config.MentionMaxLimit {
+		limit = config.MentionMaxLimit
+	}
+	list, hasMore := new(dao.Mention).GetByUserId(int(req.UserId), req.CursorId, limit)
+	msgIds := make([]int64, 0, len(list))
+	for _, mention := range list {
+		msgIds = append(msgIds, mention.MessageId)
+	}
+	msgs := make(map[int64]*dao.Message, len(list))
+	messages := new(dao.Message).GetMessagesByIds(msgIds)
+	for i := range messages {
+		msgs[messages[i].Id] = &messages[i]
+	}
+	var sendMsgs []*logic_pb.SendMsg
+	for _, mention := range list {
+		m, ok := msgs[mention.MessageId]
+		if !ok {
+			continue
+		}
+		sendMsg := logic.toSendMsg(m)
+		sendMsgs = append(sendMsgs, sendMsg)
+		reply.Mentions = append(reply.Mentions, &logic_pb.Mention{
+			Id:  mention.Id,
+			Msg: sendMsg,
+			All: mention.All,
+		})
+	}
+	logic.fillMsgExtras(sendMsgs, int(req.UserId))
+	reply.HasMore = hasMore
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"google.golang.org/protobuf/proto"
+	"time"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 消息入队前先落库，分配会话ID、会话内seq和服务端时间戳，并回填到sendData中
+// 话题回复的 reply_to 会被换成话题根消息的ID
+func (logic *Logic) storeMessage(sendData *logic_pb.SendMsg) (err error) {
+	attachmentIds, err := logic.loadAttachments(sendData)
+	if err != nil {
+		return
+	}
+	if err = logic.preparePayload(sendData); err != nil {
+		return
+	}
+	payloadBytes, err := proto.Marshal(sendData.Payload)
+	if err != nil {
+		return
+	}
+	m := &dao.Message{
+		Op:            int(sendData.Op),
+		FromUserId:    int(sendData.FromUserId),
+		FromUserName:  sendData.FromUserName,
+		ToUserId:      int(sendData.ToUserId),
+		ToUserName:    sendData.ToUserName,
+		Content:       sendData.Msg,
+		PayloadType:   payloadType(sendData.Payload),
+		Payload:       payloadBytes,
+		AttachmentIds: attachmentIds,
+	}
+	if sendData.Op == config.OpRoomSend {
+		m.RoomId = int(sendData.RoomId)
+		m.ConversationId = dao.GetRoomConversationId(int(sendData.RoomId))
+	} else {
+		m.ConversationId = dao.GetSingleConversationId(int(sendData.FromUserId), int(sendData.ToUserId))
+	}
+	var root dao.Message
+	if sendData.ReplyTo > 0 {
+		if root, err = logic.getThreadRoot(sendData.ReplyTo, m.ConversationId); err != nil {
+			return
+		}
+		m.ReplyTo = root.Id
+		sendData.ReplyTo = root.Id
+	}
+	if err = m.Add(); err != nil {
+		return
+	}
+	sendData.MsgId = m.Id
+	sendData.ConversationId = m.ConversationId
+	sendData.Seq = m.Seq
+	sendData.Timestamp = m.CreateTime.UnixMilli()
+	sendData.CreateTime = m.CreateTime.Format(time.DateTime)
+	if root.Id > 0 {
+		logic.followOnReply(&root, int(sendData.FromUserId))
+	}
+	return
+}
+
+// 持久化消息转为推送用的消息结构
+func (logic *Logic) toSendMsg(m *dao.Message) *logic_pb.SendMsg {
+	sendMsg := &logic_pb.SendMsg{
+		Msg:            m.Content,
+		FromUserId:     int32(m.FromUserId),
+		FromUserName:   m.FromUserName,
+		ToUserId:       int32(m.ToUserId),
+		ToUserName:     m.ToUserName,
+		RoomId:         int32(m.RoomId),
+		Op:             int32(m.Op),
+		CreateTime:     m.CreateTime.Format(time.DateTime),
+		MsgId:          m.Id,
+		ConversationId: m.ConversationId,
+		Seq:            m.Seq,
+		Timestamp:      m.CreateTime.UnixMilli(),
+		Edited:         m.Edited,
+		Deleted:        m.Deleted,
+		ReplyTo:        m.ReplyTo,
+		ReplyCount:     int32(m.ReplyCount),
+		Payload:        decodePayload(m),
+	}
+	if !m.EditTime.IsZero() {
+		sendMsg.EditTime = m.EditTime.UnixMilli()
+	}
+	if !m.LastReplyTime.IsZero() {
+		sendMsg.LastReplyTime = m.LastReplyTime.UnixMilli()
+	}
+	return sendMsg
+}
+
+// 能否看到这条消息：单聊的双方，或者群聊房间的成员
+// 单聊还是群聊按 op 判断，不能看 RoomId，早期的单聊消息落库时可能带着房间号
+func (logic *Logic) canSeeMessage(m *dao.Message, userId int) bool {
+	if m.Op == config.OpSingleSend {
+		return m.FromUserId == userId || m.ToUserId == userId
+	}
+	return m.RoomId > 0 && logic.isRoomMember(m.RoomId, userId)
+}
+
+// 能否删除这条消息：自己发的，或者群聊里的房主、管理员
+func (logic *Logic) canDeleteMessage(m *dao.Message, userId int) bool {
+	if m.FromUserId == userId {
+		return true
+	}
+	return m.Op != config.OpSingleSend && m.RoomId > 0 && logic.isRoomManager(m.RoomId, userId)
+}
+
+// 编辑、删除事件推给会话里的所有人：群聊广播到房间，单聊推给双方所有在线设备
+// 不进离线收件箱，离线的人上线时收件箱里的消息会按最新状态推送
+func (logic *Logic) pushMsgUpdate(m *dao.Message, op int) (err error) {
+	sendMsg := logic.toSendMsg(m)
+	sendMsg.Op = int32(op)
+	body, err := proto.Marshal(sendMsg)
+	if err != nil {
+		return
+	}
+	return logic.publishMsgEvent(m, op, body)
+}
+
+// 和消息相关的事件推给消息所在会话的所有人，单聊只推给双方
+func (logic *Logic) publishMsgEvent(m *dao.Message, op int, body []byte) (err error) {
+	if m.Op != config.OpSingleSend && m.RoomId > 0 {
+		return logic.RedisPublishEvent(op, "", 0, m.RoomId, body)
+	}
+	for _, userId := range []int{m.ToUserId, m.FromUserId} {
+		for _, serverId := range logic.getUserServerIds(userId) {
+			if err = logic.RedisPublishEvent(op, serverId, userId, 0, body); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// 离线收件箱里的消息可能在离线期间被编辑或删除了，推送前换成最新的内容
+func (logic *Logic) refreshOfflineMsgs(msgs [][]byte) [][]byte {
+	messageDao := new(dao.Message)
+	for i, body := range msgs {
+		sendMsg := &logic_pb.SendMsg{}
+		if err := proto.Unmarshal(body, sendMsg); err != nil || sendMsg.MsgId == 0 {
+			continue
+		}
+		m := messageDao.GetMessageById(sendMsg.MsgId)
+		if m.Id == 0 || (!m.Edited && !m.Deleted) {
+			continue
+		}
+		sendMsg.Msg = m.Content
+		sendMsg.Payload = decodePayload(&m)
+		sendMsg.Edited = m.Edited
+		sendMsg.Deleted = m.Deleted
+		sendMsg.EditTime = m.EditTime.UnixMilli()
+		if m.Deleted {
+			sendMsg.Attachments = nil
+		}
+		if refreshed, err := proto.Marshal(sendMsg); err == nil {
+			msgs[i] = refreshed
+		}
+	}
+	return msgs
+}
+
+// 用户是否是该房间的成员
+func (logic *Logic) isRoomMember(roomId int, userId int) bool {
+	return new(dao.RoomMember).IsMember(roomId, userId)
+}
+
+// 查询会话历史，供单聊和群聊历史接口共用
+func (logic *Logic) getHistory(conversationId string, req *logic_pb.HistoryRequest, reply *logic_pb.HistoryReply) (err error) {
+	after := req.Direction == config.HistoryDirectionAfter
+	list, hasMore, err := new(dao.Message).GetHistory(conversationId, req.CursorSeq, historyCursorTime(req), after, historyLimit(req))
+	if err != nil {
+		return
+	}
+	logic.fillHistoryReply(list, hasMore, int(req.UserId), reply)
+	return
+}
+
+func (logic *Logic) fillHistoryReply(list []dao.Message, hasMore bool, userId int, reply *logic_pb.HistoryReply) {
+	for i := range list {
+		reply.Msgs = append(reply.Msgs, logic.toSendMsg(&list[i]))
+	}
+	logic.fillMsgExtras(reply.Msgs, userId)
+	reply.HasMore = hasMore
+}
+
+// 查询消息时补上单独存的表情回应和附件
+func (logic *Logic) fillMsgExtras(msgs []*logic_pb.SendMsg, userId int) {
+	logic.fillReactions(msgs, userId)
+	logic.fillAttachments(msgs)
+}
+
+func historyLimit(req *logic_pb.HistoryRequest) int {
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = config.HistoryDefaultLimit
+	}
+	if limit > config.HistoryMaxLimit {
+		limit = config.HistoryMaxLimit
+	}
+	return limit
+}
+
+func historyCursorTime(req *logic_pb.HistoryRequest) (cursorTime time.Time) {
+	if req.CursorTime > 0 {
+		cursorTime = time.UnixMilli(req.CursorTime)
+	}
+	return
+}
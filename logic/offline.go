@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"yoyichat/pb/logic_pb"
+)
+
+// 离线收件箱：接收者没有在线的connect层时，单聊消息先存进 yoyichat_offline_<uid> 列表
+// 用户下次Connect时取出来交给connect层推到新的Channel上，connect层写出去之后再回来删掉
+// 推到一半连接断了，没写出去的还留在收件箱里，下次连上来继续推
+
+func (logic *Logic) storeOfflineMsg(userId int, msg []byte) (err error) {
+	offlineKey := logic.getOfflineKey(fmt.Sprintf("%d", userId))
+	return RedisClient.RPush(offlineKey, msg).Err()
+}
+
+// 读取离线消息，不删除
+func (logic *Logic) getOfflineMsgs(userId int) (msgs [][]byte, err error) {
+	offlineKey := logic.getOfflineKey(fmt.Sprintf("%d", userId))
+	list, err := RedisClient.LRange(offlineKey, 0, -1).Result()
+	if err != nil {
+		return
+	}
+	for _, msg := range list {
+		msgs = append(msgs, []byte(msg))
+	}
+	return
+}
+
+// 删掉已经推给客户端的离线消息，按消息ID匹配
+// 推送前消息体可能换成了编辑后的内容，所以不能按消息体删；多个设备同时推送时重复删除也没关系
+func (logic *Logic) trimOfflineMsgs(userId int, msgIds []int64) (err error) {
+	if len(msgIds) == 0 {
+		return
+	}
+	delivered := make(map[int64]bool, len(msgIds))
+	for _, msgId := range msgIds {
+		delivered[msgId] = true
+	}
+	offlineKey := logic.getOfflineKey(fmt.Sprintf("%d", userId))
+	list, err := RedisClient.LRange(offlineKey, 0, -1).Result()
+	if err != nil {
+		return
+	}
+	pipe := RedisClient.Pipeline()
+	for _, msg := range list {
+		sendMsg := &logic_pb.SendMsg{}
+		if err := proto.Unmarshal([]byte(msg), sendMsg); err != nil || !delivered[sendMsg.MsgId] {
+			continue
+		}
+		pipe.LRem(offlineKey, 1, msg)
+	}
+	_, err = pipe.Exec()
+	return
+}
@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/tools"
+)
+
+// 密码在 logic 层加盐哈希，api 层只转发明文
+func hashPassword(password string) (hash string, algo string, err error) {
+	if password == "" {
+		return "", "", errors.New("password empty")
+	}
+	if len(password) > config.PasswordMaxLen {
+		return "", "", errors.New("password too long")
+	}
+	hashBytes, err := bcrypt.GenerateFromPassword([]byte(password), config.PasswordBcryptCost)
+	if err != nil {
+		return
+	}
+	return string(hashBytes), config.PasswordAlgoBcrypt, nil
+}
+
+// 按用户记录里的算法校验密码
+func checkPassword(u *dao.User, password string) bool {
+	switch u.PasswordAlgo {
+	case config.PasswordAlgoBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+	case "", config.PasswordAlgoSha1:
+		return subtle.ConstantTimeCompare([]byte(tools.Sha1(password)), []byte(u.Password)) == 1
+	}
+	return false
+}
+
+// 老算法的密码在登录成功后换成 bcrypt，失败了下次登录再换，不影响这次登录
+func upgradePassword(u *dao.User, password string) {
+	if u.PasswordAlgo == config.PasswordAlgoBcrypt {
+		return
+	}
+	hash, algo, err := hashPassword(password)
+	if err != nil {
+		logrus.Warnf("upgrade password of user %d err:%s", u.Id, err.Error())
+		return
+	}
+	if err = u.UpdatePassword(u.Id, hash, algo); err != nil {
+		logrus.Warnf("upgrade password of user %d err:%s", u.Id, err.Error())
+	}
+}
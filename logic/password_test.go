@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+	"yoyichat/config"
+	"yoyichat/db"
+	"yoyichat/logic/dao"
+	"yoyichat/tools"
+)
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"normal", "p@ssw0rd", false},
+		{"unicode", "密码123", false},
+		{"max len", strings.Repeat("a", config.PasswordMaxLen), false},
+		{"empty", "", true},
+		{"too long", strings.Repeat("a", config.PasswordMaxLen+1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, algo, err := hashPassword(tt.password)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("hashPassword(%q) want err, got nil", tt.password)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("hashPassword(%q) err: %s", tt.password, err)
+			}
+			if algo != config.PasswordAlgoBcrypt {
+				t.Errorf("algo = %q, want %q", algo, config.PasswordAlgoBcrypt)
+			}
+			cost, err := bcrypt.Cost([]byte(hash))
+			if err != nil {
+				t.Fatalf("bcrypt.Cost err: %s", err)
+			}
+			if cost != config.PasswordBcryptCost {
+				t.Errorf("cost = %d, want %d", cost, config.PasswordBcryptCost)
+			}
+		})
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	bcryptHash, _, err := hashPassword("secret")
+	if err != nil {
+		t.Fatalf("hashPassword err: %s", err)
+	}
+	tests := []struct {
+		name     string
+		user     dao.User
+		password string
+		want     bool
+	}{
+		{"bcrypt correct", dao.User{Password: bcryptHash, PasswordAlgo: config.PasswordAlgoBcrypt}, "secret", true},
+		{"bcrypt wrong", dao.User{Password: bcryptHash, PasswordAlgo: config.PasswordAlgoBcrypt}, "Secret", false},
+		{"bcrypt empty", dao.User{Password: bcryptHash, PasswordAlgo: config.PasswordAlgoBcrypt}, "", false},
+		{"sha1 correct", dao.User{Password: tools.Sha1("secret"), PasswordAlgo: config.PasswordAlgoSha1}, "secret", true},
+		{"sha1 wrong", dao.User{Password: tools.Sha1("secret"), PasswordAlgo: config.PasswordAlgoSha1}, "secret2", false},
+		{"legacy empty algo", dao.User{Password: tools.Sha1("secret")}, "secret", true},
+		{"legacy empty algo wrong", dao.User{Password: tools.Sha1("secret")}, "other", false},
+		{"sha1 hash passed as password", dao.User{Password: tools.Sha1("secret")}, tools.Sha1("secret"), false},
+		{"bcrypt hash with legacy algo", dao.User{Password: bcryptHash}, "secret", false},
+		{"unknown algo", dao.User{Password: tools.Sha1("secret"), PasswordAlgo: "md5"}, "secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkPassword(&tt.user, tt.password); got != tt.want {
+				t.Errorf("checkPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// go test 在包目录下运行，库文件是 logic/db/yoyichat.db，不会碰到仓库里的库
+// user 表平时由建库脚本建，这里每次重建一张空表
+func setupUserTable(t *testing.T) {
+	t.Helper()
+	testDb := db.GetDB("yoyichat")
+	if err := testDb.Migrator().DropTable(&dao.User{}); err != nil {
+		t.Fatalf("drop user table err: %s", err)
+	}
+	if err := testDb.AutoMigrate(&dao.User{}); err != nil {
+		t.Fatalf("migrate user err: %s", err)
+	}
+}
+
+func TestUpgradePassword(t *testing.T) {
+	setupUserTable(t)
+	bcryptHash, _, err := hashPassword("secret")
+	if err != nil {
+		t.Fatalf("hashPassword err: %s", err)
+	}
+	tests := []struct {
+		name     string
+		userName string
+		hash     string
+		algo     string
+		wantSame bool // 已经是 bcrypt 的不用换
+	}{
+		{"legacy empty algo", "upgrade_legacy", tools.Sha1("secret"), "", false},
+		{"sha1", "upgrade_sha1", tools.Sha1("secret"), config.PasswordAlgoSha1, false},
+		{"already bcrypt", "upgrade_bcrypt", bcryptHash, config.PasswordAlgoBcrypt, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &dao.User{UserName: tt.userName, Password: tt.hash, PasswordAlgo: tt.algo}
+			if _, err := u.Add(); err != nil {
+				t.Fatalf("add user err: %s", err)
+			}
+			user := u.CheckHaveUserName(tt.userName)
+			if !checkPassword(&user, "secret") {
+				t.Fatalf("checkPassword before upgrade failed")
+			}
+			upgradePassword(&user, "secret")
+
+			upgraded := u.CheckHaveUserName(tt.userName)
+			if upgraded.PasswordAlgo != config.PasswordAlgoBcrypt {
+				t.Errorf("algo after upgrade = %q, want %q", upgraded.PasswordAlgo, config.PasswordAlgoBcrypt)
+			}
+			if same := upgraded.Password == tt.hash; same != tt.wantSame {
+				t.Errorf("hash unchanged = %v, want %v", same, tt.wantSame)
+			}
+			if !checkPassword(&upgraded, "secret") {
+				t.Errorf("checkPassword after upgrade failed")
+			}
+			if checkPassword(&upgraded, "wrong") {
+				t.Errorf("checkPassword after upgrade accepted a wrong password")
+			}
+		})
+	}
+}
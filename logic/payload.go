@@ -0,0 +1,146 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 发消息时整理消息体：没带 payload 的老客户端按纯文本处理 (只有附件时算附件消息)
+// 带了的检查内容，并把文本形式写回 msg，老客户端、搜索和@提及都用这个文本
+// 附件要先加载好，附件消息的文本形式里有文件名
+func (logic *Logic) preparePayload(sendData *logic_pb.SendMsg) (err error) {
+	payload := sendData.Payload
+	if payload == nil || payload.Body == nil {
+		if sendData.Msg == "" && len(sendData.Attachments) > 0 {
+			payload = &logic_pb.MsgPayload{Body: &logic_pb.MsgPayload_Attachment{Attachment: &logic_pb.AttachmentPayload{}}}
+		} else {
+			payload = &logic_pb.MsgPayload{Body: &logic_pb.MsgPayload_Text{Text: &logic_pb.TextPayload{Text: sendData.Msg}}}
+		}
+	}
+	if err = checkPayload(payload, sendData.Attachments); err != nil {
+		return
+	}
+	sendData.Payload = payload
+	sendData.Msg = payloadText(payload, sendData.Attachments)
+	return
+}
+
+func checkPayload(payload *logic_pb.MsgPayload, attachments []*logic_pb.Attachment) error {
+	switch body := payload.Body.(type) {
+	case *logic_pb.MsgPayload_Text:
+		if body.Text.Text == "" {
+			return errors.New("text empty")
+		}
+	case *logic_pb.MsgPayload_Markdown:
+		if body.Markdown.Markdown == "" {
+			return errors.New("markdown empty")
+		}
+	case *logic_pb.MsgPayload_Attachment:
+		if len(attachments) == 0 {
+			return errors.New("attachment message without attachments")
+		}
+	case *logic_pb.MsgPayload_System:
+		return errors.New("system notice can only be sent by server")
+	case *logic_pb.MsgPayload_Location:
+		location := body.Location
+		if location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180 {
+			return errors.New("invalid location")
+		}
+	case *logic_pb.MsgPayload_Custom:
+		custom := body.Custom
+		if custom.Type == "" {
+			return errors.New("custom type empty")
+		}
+		if len(custom.Json) > config.PayloadCustomMaxLen || !json.Valid([]byte(custom.Json)) {
+			return errors.New("invalid custom json")
+		}
+	default:
+		return errors.New("unknown payload")
+	}
+	return nil
+}
+
+func payloadType(payload *logic_pb.MsgPayload) string {
+	switch payload.Body.(type) {
+	case *logic_pb.MsgPayload_Markdown:
+		return config.PayloadMarkdown
+	case *logic_pb.MsgPayload_Attachment:
+		return config.PayloadAttachment
+	case *logic_pb.MsgPayload_System:
+		return config.PayloadSystem
+	case *logic_pb.MsgPayload_Location:
+		return config.PayloadLocation
+	case *logic_pb.MsgPayload_Custom:
+		return config.PayloadCustom
+	}
+	return config.PayloadText
+}
+
+// 消息体的文本形式
+func payloadText(payload *logic_pb.MsgPayload, attachments []*logic_pb.Attachment) string {
+	switch body := payload.Body.(type) {
+	case *logic_pb.MsgPayload_Text:
+		return body.Text.Text
+	case *logic_pb.MsgPayload_Markdown:
+		return body.Markdown.Markdown
+	case *logic_pb.MsgPayload_Attachment:
+		names := make([]string, 0, len(attachments))
+		for _, attachment := range attachments {
+			names = append(names, attachment.Name)
+		}
+		text := "[附件] " + strings.Join(names, ", ")
+		if body.Attachment.Caption != "" {
+			text = body.Attachment.Caption + " " + text
+		}
+		return text
+	case *logic_pb.MsgPayload_System:
+		return body.System.Text
+	case *logic_pb.MsgPayload_Location:
+		location := body.Location
+		text := fmt.Sprintf("[位置] %.6f,%.6f", location.Latitude, location.Longitude)
+		if place := strings.TrimSpace(location.Name + " " + location.Address); place != "" {
+			text = "[位置] " + place + fmt.Sprintf(" (%.6f,%.6f)", location.Latitude, location.Longitude)
+		}
+		return text
+	case *logic_pb.MsgPayload_Custom:
+		if body.Custom.Fallback != "" {
+			return body.Custom.Fallback
+		}
+		return "[" + body.Custom.Type + "]"
+	}
+	return ""
+}
+
+// 落库的消息体，编辑过的文本、markdown 消息以 Content 为准
+// 这个功能之前存的消息没有消息体，按纯文本返回；删除的消息不返回消息体
+func decodePayload(m *dao.Message) *logic_pb.MsgPayload {
+	if m.Deleted {
+		return nil
+	}
+	payload := &logic_pb.MsgPayload{}
+	if len(m.Payload) == 0 || proto.Unmarshal(m.Payload, payload) != nil || payload.Body == nil {
+		return &logic_pb.MsgPayload{Body: &logic_pb.MsgPayload_Text{Text: &logic_pb.TextPayload{Text: m.Content}}}
+	}
+	switch body := payload.Body.(type) {
+	case *logic_pb.MsgPayload_Text:
+		body.Text.Text = m.Content
+	case *logic_pb.MsgPayload_Markdown:
+		body.Markdown.Markdown = m.Content
+	}
+	return payload
+}
+
+// 只有文本和 markdown 消息能编辑
+func canEditPayload(m *dao.Message) bool {
+	switch m.PayloadType {
+	case "", config.PayloadText, config.PayloadMarkdown:
+		return true
+	}
+	return false
+}
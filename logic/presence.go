@@ -0,0 +1,227 @@
+package logic
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"strconv"
+	"strings"
+	"time"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 在线状态：yoyichat_presence_<uid> 哈希
+//   manual          手动设置的状态 away / dnd，空表示自动
+//   status          上次推送出去的状态，用来判断有没有变化
+//   lastSeen        最后在线时间，毫秒
+//   hb_<device>     设备最后一次心跳，毫秒
+//   idle_<device>   设备是否空闲
+// 设备在线以 yoyichat_device_<uid> 为准，心跳超时的设备当作已经掉线
+// 所有设备的心跳时间另外记在 yoyichat_presence_hb 里，connect层挂掉没有走断开流程时由定时清理把设备下线
+
+func (logic *Logic) getPresenceKey(userId int) string {
+	return config.RedisPresencePrefix + strconv.Itoa(userId)
+}
+
+// 设备心跳或者连上来，刷新设备心跳和最后在线时间
+func (logic *Logic) touchPresence(userId int, device string, idle bool) (err error) {
+	nowMs := time.Now().UnixMilli()
+	now := strconv.FormatInt(nowMs, 10)
+	idleFlag := "0"
+	if idle {
+		idleFlag = "1"
+	}
+	presenceKey := logic.getPresenceKey(userId)
+	pipe := RedisClient.TxPipeline()
+	pipe.HMSet(presenceKey, map[string]interface{}{
+		"hb_" + device:   now,
+		"idle_" + device: idleFlag,
+		"lastSeen":       now,
+	})
+	pipe.Expire(presenceKey, config.RedisBaseValidTime*time.Second)
+	pipe.ZAdd(config.RedisPresenceHbKey, redis.Z{Score: float64(nowMs), Member: presenceHbMember(userId, device)})
+	_, err = pipe.Exec()
+	return
+}
+
+// 设备断开，去掉设备心跳，记下最后在线时间
+func (logic *Logic) removePresenceDevice(userId int, device string) (err error) {
+	presenceKey := logic.getPresenceKey(userId)
+	pipe := RedisClient.TxPipeline()
+	pipe.HDel(presenceKey, "hb_"+device, "idle_"+device)
+	pipe.HSet(presenceKey, "lastSeen", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	pipe.ZRem(config.RedisPresenceHbKey, presenceHbMember(userId, device))
+	_, err = pipe.Exec()
+	return
+}
+
+func presenceHbMember(userId int, device string) string {
+	return strconv.Itoa(userId) + "_" + device
+}
+
+// 定时把心跳超时的设备下线
+func (logic *Logic) sweepPresence() {
+	ticker := time.NewTicker(config.PresenceSweepEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := logic.expirePresenceDevices(); err != nil {
+			logrus.Warnf("logic sweep presence err : %s", err)
+		}
+	}
+}
+
+// 心跳超时的设备：去掉设备映射和心跳，最后在线时间记为最后一次心跳，状态有变化照常推送下线事件
+// 多个logic一起清理时，ZRem 成功的那个才继续处理
+func (logic *Logic) expirePresenceDevices() (err error) {
+	deadline := time.Now().Add(-config.PresenceTimeout).UnixMilli()
+	expired, err := RedisClient.ZRangeByScoreWithScores(config.RedisPresenceHbKey, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(deadline, 10),
+	}).Result()
+	if err != nil {
+		return
+	}
+	for _, z := range expired {
+		member, _ := z.Member.(string)
+		uid, device, found := strings.Cut(member, "_")
+		userId, _ := strconv.Atoi(uid)
+		if removed, _ := RedisClient.ZRem(config.RedisPresenceHbKey, member).Result(); removed == 0 || !found || userId <= 0 {
+			continue
+		}
+		// 清理前刚好来了心跳就放回去
+		presenceKey := logic.getPresenceKey(userId)
+		hb, _ := RedisClient.HGet(presenceKey, "hb_"+device).Int64()
+		if hb > deadline {
+			RedisClient.ZAdd(config.RedisPresenceHbKey, redis.Z{Score: float64(hb), Member: member})
+			continue
+		}
+		if err = logic.removeDeviceServer(userId, device, ""); err != nil {
+			return
+		}
+		pipe := RedisClient.TxPipeline()
+		pipe.HDel(presenceKey, "hb_"+device, "idle_"+device)
+		if hb > 0 {
+			pipe.HSet(presenceKey, "lastSeen", strconv.FormatInt(hb, 10))
+		}
+		if _, err = pipe.Exec(); err != nil {
+			return
+		}
+		if err = logic.refreshPresence(userId); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 算出用户当前的状态
+func (logic *Logic) getPresence(userId int) *logic_pb.Presence {
+	fields, _ := RedisClient.HGetAll(logic.getPresenceKey(userId)).Result()
+	devices, _ := RedisClient.HGetAll(logic.getDeviceKey(userId)).Result()
+	now := time.Now().UnixMilli()
+	connected, active := false, false
+	for device := range devices {
+		hb, _ := strconv.ParseInt(fields["hb_"+device], 10, 64)
+		if hb == 0 || now-hb > config.PresenceTimeout.Milliseconds() {
+			continue
+		}
+		connected = true
+		if fields["idle_"+device] != "1" {
+			active = true
+		}
+	}
+	lastSeen, _ := strconv.ParseInt(fields["lastSeen"], 10, 64)
+	presence := &logic_pb.Presence{
+		UserId:   int32(userId),
+		UserName: new(dao.User).GetUserNameByUserId(userId),
+		LastSeen: lastSeen,
+	}
+	switch {
+	case !connected:
+		presence.Status = config.PresenceOffline
+	case fields["manual"] != "":
+		presence.Status = fields["manual"]
+	case active:
+		presence.Status = config.PresenceOnline
+	default:
+		presence.Status = config.PresenceAway
+	}
+	return presence
+}
+
+// 重新计算状态，有变化就推给联系人和同房间的人
+func (logic *Logic) refreshPresence(userId int) (err error) {
+	presenceKey := logic.getPresenceKey(userId)
+	presence := logic.getPresence(userId)
+	old, _ := RedisClient.HGet(presenceKey, "status").Result()
+	if old == presence.Status || (old == "" && presence.Status == config.PresenceOffline) {
+		return
+	}
+	if err = RedisClient.HSet(presenceKey, "status", presence.Status).Err(); err != nil {
+		return
+	}
+	return logic.pushPresence(presence)
+}
+
+// 在线状态事件推给好友和用户所在的房间，不进离线收件箱
+func (logic *Logic) pushPresence(presence *logic_pb.Presence) (err error) {
+	body, err := proto.Marshal(&logic_pb.PresenceMsg{
+		Op:       config.OpPresence,
+		Presence: presence,
+	})
+	if err != nil {
+		return
+	}
+	userId := int(presence.UserId)
+	for _, c := range new(dao.Contact).GetByUserId(userId) {
+		if c.Status != config.ContactStatusFriend {
+			continue
+		}
+		for _, serverId := range logic.getUserServerIds(c.ContactUserId) {
+			if err = logic.RedisPublishEvent(config.OpPresence, serverId, c.ContactUserId, 0, body); err != nil {
+				return
+			}
+		}
+	}
+	for _, roomId := range new(dao.RoomMember).GetRoomIdsByUserId(userId) {
+		if err = logic.RedisPublishEvent(config.OpPresence, "", 0, roomId, body); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 手动设置状态，online 表示回到自动
+func (logic *Logic) setManualPresence(userId int, status string) (err error) {
+	presenceKey := logic.getPresenceKey(userId)
+	if status == config.PresenceOnline {
+		err = RedisClient.HDel(presenceKey, "manual").Err()
+	} else {
+		err = RedisClient.HSet(presenceKey, "manual", status).Err()
+	}
+	if err != nil {
+		return
+	}
+	return logic.refreshPresence(userId)
+}
+
+// 在线状态只对自己、好友和同房间的人可见，和状态变化推送的范围一致
+func (logic *Logic) presenceVisible(userId int, targetIds []int32) map[int]bool {
+	visible := map[int]bool{userId: true}
+	for _, c := range new(dao.Contact).GetByUserId(userId) {
+		if c.Status == config.ContactStatusFriend {
+			visible[c.ContactUserId] = true
+		}
+	}
+	var rest []int
+	for _, targetId := range targetIds {
+		if !visible[int(targetId)] {
+			rest = append(rest, int(targetId))
+		}
+	}
+	for _, roommateId := range new(dao.RoomMember).FilterRoommates(userId, rest) {
+		visible[roommateId] = true
+	}
+	return visible
+}
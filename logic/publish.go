@@ -13,11 +13,13 @@ import (
 	"time"
 	"yoyichat/config"
 	"yoyichat/pb/task_pb"
+	"yoyichat/queue"
 	"yoyichat/tools"
 )
 
 var RedisClient *redis.Client
 var RedisSessClient *redis.Client
+var QueuePublisher queue.Publisher
 
 // 消息队列客户端初始化
 func (l *Logic) InitPublishRedisClient() (err error) {
@@ -33,6 +35,7 @@ func (l *Logic) InitPublishRedisClient() (err error) {
 
 	// 会话客户端通用
 	RedisSessClient = RedisClient
+	QueuePublisher, err = queue.NewPublisher(RedisClient)
 	return err
 }
 
@@ -94,6 +97,7 @@ func (l *Logic) addRegistryPlugin(s *server.Server, network string, addr string)
 // 单聊消息发布
 func (l *Logic) RedisPublishSingleSend(serverId string, toUserId int, msg []byte) (err error) {
 	redisMsg := task_pb.RedisMsg{
+		Ver:      config.MsgVersion,
 		Op:       config.OpSingleSend,
 		ServerId: serverId,
 		Msg:      msg,
@@ -106,10 +110,9 @@ func (l *Logic) RedisPublishSingleSend(serverId string, toUserId int, msg []byte
 		return err
 	}
 
-	// 将二进制数组push进队列下了吗？
-	redisChannel := config.QueueName
-	if err := RedisClient.LPush(redisChannel, redisMsgBytes).Err(); err != nil {
-		logrus.Errorf("logic,RedisPublishChannel LPush err:%s", err.Error())
+	// 将二进制数组写进消息队列，由task层消费
+	if err := l.publishToQueue(redisMsgBytes); err != nil {
+		logrus.Errorf("logic,RedisPublishChannel XAdd err:%s", err.Error())
 		return err
 	}
 	return
@@ -117,6 +120,7 @@ func (l *Logic) RedisPublishSingleSend(serverId string, toUserId int, msg []byte
 
 func (l *Logic) RedisPublishRoomSend(roomId int, count int, RoomUserInfo map[string]string, msg []byte) (err error) {
 	var redisMsg = &task_pb.RedisMsg{
+		Ver:          config.MsgVersion,
 		Op:           config.OpRoomSend,
 		RoomId:       int32(roomId),
 		Count:        int32(count),
@@ -129,7 +133,7 @@ func (l *Logic) RedisPublishRoomSend(roomId int, count int, RoomUserInfo map[str
 		logrus.Errorf("logic,RedisPublishRoomInfo redisMsg error : %s", err.Error())
 		return
 	}
-	err = RedisClient.LPush(config.QueueName, redisMsgBytes).Err()
+	err = l.publishToQueue(redisMsgBytes)
 	if err != nil {
 		logrus.Errorf("logic,RedisPublishRoomInfo redisMsg error : %s", err.Error())
 		return
@@ -140,6 +144,7 @@ func (l *Logic) RedisPublishRoomSend(roomId int, count int, RoomUserInfo map[str
 // 查询房间人数
 func (l *Logic) RedisPublishRoomCount(roomId int, count int) (err error) {
 	var redisMsg = &task_pb.RedisMsg{
+		Ver:    config.MsgVersion,
 		Op:     config.OpRoomCountSend,
 		RoomId: int32(roomId),
 		Count:  int32(count),
@@ -149,7 +154,7 @@ func (l *Logic) RedisPublishRoomCount(roomId int, count int) (err error) {
 		logrus.Errorf("logic,RedisPushRoomCount redisMsg error : %s", err.Error())
 		return
 	}
-	err = RedisClient.LPush(config.QueueName, redisMsgBytes).Err()
+	err = l.publishToQueue(redisMsgBytes)
 	if err != nil {
 		logrus.Errorf("logic,RedisPushRoomCount redisMsg error : %s", err.Error())
 		return
@@ -158,19 +163,22 @@ func (l *Logic) RedisPublishRoomCount(roomId int, count int) (err error) {
 }
 
 // 查询房间元信息
-func (l *Logic) RedisPublishRoomInfo(roomId int, count int, roomUserInfo map[string]string) (err error) {
+// 房间事件（角色变更、踢人等）也随房间信息一起广播，event可以为空
+func (l *Logic) RedisPublishRoomInfo(roomId int, count int, roomUserInfo map[string]string, event *task_pb.RoomEvent) (err error) {
 	var redisMsg = &task_pb.RedisMsg{
+		Ver:          config.MsgVersion,
 		Op:           config.OpRoomInfoSend,
 		RoomId:       int32(roomId),
 		Count:        int32(count),
 		RoomUserInfo: roomUserInfo,
+		RoomEvent:    event,
 	}
 	redisMsgBytes, err := proto.Marshal(redisMsg)
 	if err != nil {
 		logrus.Errorf("logic,RedisPushRoomInfo redisMsg error : %s", err.Error())
 		return
 	}
-	err = RedisClient.LPush(config.QueueName, redisMsgBytes).Err()
+	err = l.publishToQueue(redisMsgBytes)
 	if err != nil {
 		logrus.Errorf("logic,RedisPushRoomInfo redisMsg error : %s", err.Error())
 		return
@@ -178,6 +186,35 @@ func (l *Logic) RedisPublishRoomInfo(roomId int, count int, roomUserInfo map[str
 	return
 }
 
+// 推给单个用户或房间的事件（投递状态、已读回执、在线状态、输入状态、消息编辑删除、好友申请、@提醒等）
+// 带房间号的广播给房间，否则和单聊一样按serverId定位connect层推给userId，推不到就丢弃
+func (l *Logic) RedisPublishEvent(op int, serverId string, userId int, roomId int, msg []byte) (err error) {
+	var redisMsg = &task_pb.RedisMsg{
+		Ver:      config.MsgVersion,
+		Op:       int32(op),
+		ServerId: serverId,
+		UserId:   int32(userId),
+		RoomId:   int32(roomId),
+		Msg:      msg,
+	}
+	redisMsgBytes, err := proto.Marshal(redisMsg)
+	if err != nil {
+		logrus.Errorf("logic,RedisPublishEvent op:%d redisMsg error : %s", op, err.Error())
+		return
+	}
+	err = l.publishToQueue(redisMsgBytes)
+	if err != nil {
+		logrus.Errorf("logic,RedisPublishEvent op:%d redisMsg error : %s", op, err.Error())
+		return
+	}
+	return
+}
+
+// 写入logic->task的消息队列
+func (l *Logic) publishToQueue(msg []byte) error {
+	return QueuePublisher.Publish(msg)
+}
+
 // 键命名规范
 func (logic *Logic) getRoomUserKey(authKey string) string {
 	var returnKey bytes.Buffer
@@ -193,9 +230,9 @@ func (logic *Logic) getRoomOnlineCountKey(authKey string) string {
 	return returnKey.String()
 }
 
-func (logic *Logic) getUserKey(authKey string) string {
+func (logic *Logic) getOfflineKey(authKey string) string {
 	var returnKey bytes.Buffer
-	returnKey.WriteString(config.RedisPrefix)
+	returnKey.WriteString(config.RedisOfflinePrefix)
 	returnKey.WriteString(authKey)
 	return returnKey.String()
 }
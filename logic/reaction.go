@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"unicode/utf8"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 表情只接受一个不带空白的短字符串，不校验是不是真的emoji，客户端自己决定怎么显示
+func (logic *Logic) checkEmoji(emoji string) error {
+	if emoji == "" {
+		return errors.New("emoji empty")
+	}
+	if len(emoji) > config.ReactionEmojiMaxLen || !utf8.ValidString(emoji) {
+		return errors.New("invalid emoji")
+	}
+	if strings.ContainsAny(emoji, " \t\r\n") {
+		return errors.New("invalid emoji")
+	}
+	return nil
+}
+
+// 一条消息的回应汇总
+func (logic *Logic) getReactions(msgId int64, userId int) []*logic_pb.ReactionCount {
+	var reactions []*logic_pb.ReactionCount
+	for _, stat := range new(dao.MessageReaction).GetStats([]int64{msgId}, userId) {
+		reactions = append(reactions, &logic_pb.ReactionCount{
+			Emoji:   stat.Emoji,
+			Count:   int32(stat.Count),
+			Reacted: stat.Reacted,
+		})
+	}
+	return reactions
+}
+
+// 查历史时把回应汇总一起带上，一次查完整页
+func (logic *Logic) fillReactions(msgs []*logic_pb.SendMsg, userId int) {
+	if len(msgs) == 0 {
+		return
+	}
+	msgIds := make([]int64, 0, len(msgs))
+	msgIndex := make(map[int64]*logic_pb.SendMsg, len(msgs))
+	for _, msg := range msgs {
+		msgIds = append(msgIds, msg.MsgId)
+		msgIndex[msg.MsgId] = msg
+	}
+	for _, stat := range new(dao.MessageReaction).GetStats(msgIds, userId) {
+		if msg, ok := msgIndex[stat.MessageId]; ok {
+			msg.Reactions = append(msg.Reactions, &logic_pb.ReactionCount{
+				Emoji:   stat.Emoji,
+				Count:   int32(stat.Count),
+				Reacted: stat.Reacted,
+			})
+		}
+	}
+}
+
+// 回应变化推给会话里的人，和编辑删除一样，群聊广播到房间，单聊推给双方
+func (logic *Logic) pushReaction(m *dao.Message, userId int, emoji string, action string, reactions []*logic_pb.ReactionCount) (err error) {
+	reactionMsg := &logic_pb.ReactionMsg{
+		Op:             config.OpMsgReaction,
+		MsgId:          m.Id,
+		ConversationId: m.ConversationId,
+		RoomId:         int32(m.RoomId),
+		UserId:         int32(userId),
+		UserName:       new(dao.User).GetUserNameByUserId(userId),
+		Emoji:          emoji,
+		Action:         action,
+	}
+	// reacted 是相对查询者的，广播给所有人时去掉
+	for _, reaction := range reactions {
+		reactionMsg.Reactions = append(reactionMsg.Reactions, &logic_pb.ReactionCount{
+			Emoji: reaction.Emoji,
+			Count: reaction.Count,
+		})
+	}
+	body, err := proto.Marshal(reactionMsg)
+	if err != nil {
+		return
+	}
+	return logic.publishMsgEvent(m, config.OpMsgReaction, body)
+}
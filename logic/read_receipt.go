@@ -0,0 +1,92 @@
+package logic
+
+import (
+	"google.golang.org/protobuf/proto"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 已读回执推给会话里的其他人：单聊推给对方，群聊广播到房间
+// 对方不在线就不推了，回执不进离线收件箱，对方可以通过未读数接口拿到最新状态
+func (logic *Logic) pushReadReceipt(cursor *dao.ReadCursor, userName string) (err error) {
+	receipt := &logic_pb.ReadReceiptMsg{
+		Op:             config.OpReadReceipt,
+		ConversationId: cursor.ConversationId,
+		UserId:         int32(cursor.UserId),
+		UserName:       userName,
+		RoomId:         int32(cursor.RoomId),
+		Seq:            cursor.Seq,
+	}
+	body, err := proto.Marshal(receipt)
+	if err != nil {
+		return
+	}
+	if cursor.RoomId > 0 {
+		return logic.RedisPublishEvent(config.OpReadReceipt, "", 0, cursor.RoomId, body)
+	}
+	for _, serverId := range logic.getUserServerIds(cursor.PeerUserId) {
+		if err = logic.RedisPublishEvent(config.OpReadReceipt, serverId, cursor.PeerUserId, 0, body); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// 用户所有会话的未读数：参与过的单聊，加上有已读位置或者已加入的房间
+func (logic *Logic) getUnreadCounts(userId int) (counts []*logic_pb.UnreadCount) {
+	m := new(dao.Message)
+	readSeqMap := make(map[string]int64)
+	stats := make(map[string]*dao.ConversationStat)
+	var order []string
+	addStat := func(stat dao.ConversationStat) {
+		if _, ok := stats[stat.ConversationId]; ok {
+			return
+		}
+		stats[stat.ConversationId] = &stat
+		order = append(order, stat.ConversationId)
+	}
+	for _, stat := range m.GetSingleConversationStats(userId, config.OpSingleSend) {
+		addStat(stat)
+	}
+	roomMember := new(dao.RoomMember)
+	for _, cursor := range new(dao.ReadCursor).GetByUserId(userId) {
+		// 已经退出的房间不再算未读
+		if cursor.RoomId > 0 && !roomMember.IsMember(cursor.RoomId, userId) {
+			continue
+		}
+		readSeqMap[cursor.ConversationId] = cursor.Seq
+		addStat(dao.ConversationStat{
+			ConversationId: cursor.ConversationId,
+			RoomId:         cursor.RoomId,
+			PeerUserId:     cursor.PeerUserId,
+		})
+	}
+	for _, roomId := range roomMember.GetRoomIdsByUserId(userId) {
+		addStat(dao.ConversationStat{
+			ConversationId: dao.GetRoomConversationId(roomId),
+			RoomId:         roomId,
+		})
+	}
+	for _, conversationId := range order {
+		stat := stats[conversationId]
+		if stat.LastSeq == 0 {
+			stat.LastSeq = m.GetMaxSeq(conversationId)
+		}
+		readSeq := readSeqMap[conversationId]
+		unreadCount := &logic_pb.UnreadCount{
+			ConversationId: conversationId,
+			RoomId:         int32(stat.RoomId),
+			ReadSeq:        readSeq,
+			LastSeq:        stat.LastSeq,
+		}
+		if stat.RoomId == 0 {
+			unreadCount.ToUserId = int32(stat.PeerUserId)
+		}
+		if stat.LastSeq > readSeq {
+			unreadCount.Unread = m.CountUnread(conversationId, userId, readSeq)
+		}
+		counts = append(counts, unreadCount)
+	}
+	return
+}
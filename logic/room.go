@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"time"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+	"yoyichat/pb/task_pb"
+)
+
+func (logic *Logic) toRoomPb(r *dao.Room) *logic_pb.Room {
+	return &logic_pb.Room{
+		Id:          int32(r.Id),
+		Name:        r.Name,
+		Topic:       r.Topic,
+		OwnerId:     int32(r.OwnerId),
+		OwnerName:   new(dao.User).GetUserNameByUserId(r.OwnerId),
+		Visibility:  r.Visibility,
+		MemberCount: int32(new(dao.RoomMember).CountByRoomId(r.Id)),
+		CreateTime:  r.CreateTime.Format(time.DateTime),
+	}
+}
+
+func (logic *Logic) toRoomListPb(list []dao.Room) (rooms []*logic_pb.Room) {
+	for i := range list {
+		rooms = append(rooms, logic.toRoomPb(&list[i]))
+	}
+	return
+}
+
+// 角色等级，数值越大权限越高
+func roomRoleLevel(role string) int {
+	switch role {
+	case config.RoomRoleOwner:
+		return 3
+	case config.RoomRoleAdmin:
+		return 2
+	case config.RoomRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// 房主和管理员才能管理房间
+func (logic *Logic) isRoomManager(roomId, userId int) bool {
+	return roomRoleLevel(new(dao.RoomMember).GetRole(roomId, userId)) >= roomRoleLevel(config.RoomRoleAdmin)
+}
+
+// 检查操作者能否管理目标成员：只能管理比自己角色低的人，返回目标当前角色
+func (logic *Logic) checkRoomManage(roomId, operatorId, targetId int) (targetRole string, err error) {
+	if operatorId == targetId {
+		return "", errors.New("can not operate yourself")
+	}
+	roomMember := new(dao.RoomMember)
+	operatorRole := roomMember.GetRole(roomId, operatorId)
+	if roomRoleLevel(operatorRole) < roomRoleLevel(config.RoomRoleAdmin) {
+		return "", errors.New("permission denied")
+	}
+	targetRole = roomMember.GetRole(roomId, targetId)
+	if targetRole == "" {
+		return "", errors.New("target is not a member of this room")
+	}
+	if roomRoleLevel(targetRole) >= roomRoleLevel(operatorRole) {
+		return "", errors.New("permission denied")
+	}
+	return
+}
+
+// 房间事件随房间信息广播给在线的人
+func (logic *Logic) pushRoomEvent(r *dao.Room, operatorId int, targetId int, event string, role string) (err error) {
+	userDao := new(dao.User)
+	roomEvent := &task_pb.RoomEvent{
+		Event:        event,
+		OperatorId:   int32(operatorId),
+		OperatorName: userDao.GetUserNameByUserId(operatorId),
+		Role:         role,
+		RoomName:     r.Name,
+		Topic:        r.Topic,
+	}
+	if targetId > 0 {
+		roomEvent.TargetUserId = int32(targetId)
+		roomEvent.TargetUserName = userDao.GetUserNameByUserId(targetId)
+	}
+	roomUserKey := logic.getRoomUserKey(strconv.Itoa(r.Id))
+	roomUserInfo, err := RedisClient.HGetAll(roomUserKey).Result()
+	if err != nil {
+		return
+	}
+	// 被踢、被封禁或自己退出的人先收到事件，再由connect层移出房间，之后从在线名单里去掉
+	if event == config.RoomEventKick || event == config.RoomEventBan || event == config.RoomEventLeave {
+		if _, online := roomUserInfo[strconv.Itoa(targetId)]; online {
+			delete(roomUserInfo, strconv.Itoa(targetId))
+			RedisClient.HDel(logic.getRoomConnKey(r.Id), strconv.Itoa(targetId))
+			RedisClient.HDel(roomUserKey, strconv.Itoa(targetId))
+			RedisClient.Decr(logic.getRoomOnlineCountKey(strconv.Itoa(r.Id)))
+		}
+	}
+	return logic.RedisPublishRoomInfo(r.Id, len(roomUserInfo), roomUserInfo, roomEvent)
+}
+
+// 记录用户在房间里在线，同一个用户多个设备只计一次
+func (logic *Logic) joinRoomOnline(roomId, userId int, userName string) {
+	conns, err := RedisClient.HIncrBy(logic.getRoomConnKey(roomId), strconv.Itoa(userId), 1).Result()
+	if err != nil || conns > 1 {
+		return
+	}
+	roomUserKey := logic.getRoomUserKey(strconv.Itoa(roomId))
+	if added, _ := RedisClient.HSetNX(roomUserKey, strconv.Itoa(userId), userName).Result(); added {
+		RedisClient.Incr(logic.getRoomOnlineCountKey(strconv.Itoa(roomId)))
+		if err = logic.publishRoomOnline(roomId); err != nil {
+			logrus.Warnf("logic,joinRoomOnline publish room %d online err:%s", roomId, err.Error())
+		}
+	}
+}
+
+// 用户离开房间，更新在线人数并通知房间
+func (logic *Logic) leaveRoomOnline(roomId, userId int) (err error) {
+	if roomId <= 0 {
+		return
+	}
+	// 还有别的设备在房间里就只减连接数
+	conns, err := RedisClient.HIncrBy(logic.getRoomConnKey(roomId), strconv.Itoa(userId), -1).Result()
+	if err != nil {
+		return
+	}
+	if conns > 0 {
+		return
+	}
+	RedisClient.HDel(logic.getRoomConnKey(roomId), strconv.Itoa(userId))
+	roomUserKey := logic.getRoomUserKey(strconv.Itoa(roomId))
+	removed, err := RedisClient.HDel(roomUserKey, strconv.Itoa(userId)).Result()
+	if err != nil {
+		return
+	}
+	if removed == 0 {
+		return
+	}
+	RedisClient.Decr(logic.getRoomOnlineCountKey(strconv.Itoa(roomId)))
+	//below code can optimize send a signal to queue,another process get a signal from queue,then push event to websocket
+	// 下方代码可优化为：发送信号到队列，再由另一个进程从队列获取信号并推送事件到WebSocket
+	return logic.publishRoomOnline(roomId)
+}
+
+// 房间在线名单变了，广播最新的在线人数和名单，房间里其他人的人数不会停在旧值
+func (logic *Logic) publishRoomOnline(roomId int) (err error) {
+	roomUserInfo, err := RedisClient.HGetAll(logic.getRoomUserKey(strconv.Itoa(roomId))).Result()
+	if err != nil {
+		return
+	}
+	if err = logic.RedisPublishRoomCount(roomId, len(roomUserInfo)); err != nil {
+		return
+	}
+	return logic.RedisPublishRoomInfo(roomId, len(roomUserInfo), roomUserInfo, nil)
+}
+
+func (logic *Logic) getRoomConnKey(roomId int) string {
+	return config.RedisRoomConnPrefix + strconv.Itoa(roomId)
+}
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
+	"slices"
 	"strconv"
 	"time"
+	"yoyichat/auth"
 	"yoyichat/config"
 	"yoyichat/logic/dao"
 	"yoyichat/pb/logic_pb"
@@ -29,7 +31,9 @@ func (rpc *RpcLogic) Register(ctx context.Context, args *logic_pb.RegisterReques
 		return errors.New("this user name already have , please login !!!")
 	}
 	u.UserName = args.Name
-	u.Password = args.Password
+	if u.Password, u.PasswordAlgo, err = hashPassword(args.Password); err != nil {
+		return err
+	}
 	userId, err := u.Add()
 	if err != nil {
 		logrus.Infof("register err:%s", err.Error())
@@ -39,16 +43,8 @@ func (rpc *RpcLogic) Register(ctx context.Context, args *logic_pb.RegisterReques
 		return errors.New("register userId empty!")
 	}
 
-	// 设置会话令牌
-	randToken := tools.GetRandomToken(32)
-	sessionId := tools.CreateSessionId(randToken)
-	userData := make(map[string]interface{})
-	userData["userId"] = userId
-	userData["userName"] = uData.UserName
-	RedisSessClient.Do("MULTI")
-	RedisSessClient.HMSet(sessionId, userData)
-	RedisSessClient.Expire(sessionId, config.RedisBaseValidTime*time.Second)
-	err = RedisSessClient.Do("EXEC").Err()
+	// 设置会话令牌，jwt 模式下是访问令牌和刷新令牌
+	tokens, err := new(Logic).createAuthTokens(userId, args.Name, args.Device)
 	if err != nil {
 		logrus.Infof("register set redis token fail!")
 		return err
@@ -56,7 +52,9 @@ func (rpc *RpcLogic) Register(ctx context.Context, args *logic_pb.RegisterReques
 
 	// 操作成功，返回认证令牌
 	reply.Code = config.SuccessReplyCode
-	reply.AuthToken = randToken
+	reply.AuthToken = tokens.AccessToken
+	reply.RefreshToken = tokens.RefreshToken
+	reply.ExpiresAt = tokens.ExpiresAt
 	return
 }
 
@@ -68,39 +66,22 @@ func (rpc *RpcLogic) Login(ctx context.Context, request *logic_pb.LoginRequest,
 	password := request.Password
 
 	data := u.CheckHaveUserName(username)
-	if (data.Id == 0) || (password != data.Password) {
+	if (data.Id == 0) || !checkPassword(&data, password) {
 		return errors.New("username or password error")
 	}
+	upgradePassword(&data, password)
 
-	// 获取会话ID
-	loginSessionId := tools.GetSessionIdByUserId(data.Id)
-	randToken := tools.GetRandomToken(32)
-	sessionId := tools.CreateSessionId(randToken)
-	userData := make(map[string]interface{})
-	userData["userId"] = data.Id
-	userData["userName"] = data.UserName
-
-	token, _ := RedisSessClient.Get(loginSessionId).Result()
-
-	if token != "" {
-		oldSession := tools.CreateSessionId(token)
-		err := RedisSessClient.Del(oldSession).Err()
-		if err != nil {
-			return errors.New("logout user fail token is:" + token)
-		}
-	}
-	RedisSessClient.Do("MULTI")
-	RedisSessClient.HMSet(sessionId, userData)
-	RedisSessClient.Expire(sessionId, config.RedisBaseValidTime*time.Second)
-	RedisSessClient.Set(loginSessionId, randToken, config.RedisBaseValidTime*time.Second)
-	err = RedisSessClient.Do("EXEC").Err()
+	// 每个设备一个令牌，只顶掉同一设备的旧令牌，其他设备不受影响
+	tokens, err := new(Logic).createAuthTokens(data.Id, data.UserName, request.Device)
 	if err != nil {
 		logrus.Infof("login set redis token fail!")
 		return err
 	}
 
 	reply.Code = config.SuccessReplyCode
-	reply.AuthToken = randToken
+	reply.AuthToken = tokens.AccessToken
+	reply.RefreshToken = tokens.RefreshToken
+	reply.ExpiresAt = tokens.ExpiresAt
 	return
 }
 
@@ -116,67 +97,54 @@ func (rpc *RpcLogic) GetUserInfoByUserId(ctx context.Context, req *logic_pb.GetU
 	return
 }
 
-// 用会话key拿到用户元信息，然后比对
+// 用会话key拿到用户元信息，然后比对；jwt 模式下直接验签
 func (rpc *RpcLogic) CheckAuth(ctx context.Context, req *logic_pb.CheckAuthRequest, reply *logic_pb.CheckAuthResponse) (err error) {
 	reply.Code = config.FailReplyCode
 	authToken := req.AuthToken
 
-	sessionName := tools.GetSessionName(authToken)
-
-	userDataMap, err := RedisSessClient.HGetAll(sessionName).Result()
+	userId, userName, device, err := new(Logic).getAuthUser(authToken)
 	if err != nil {
 		logrus.Infof("check auth fail!， authToken is: %s", authToken)
 		return err
 	}
-	if len(userDataMap) == 0 {
+	if userId == 0 {
 		logrus.Infof("no this user session! authToken is: %s", authToken)
 		return
 	}
 
-	intUserId, _ := strconv.Atoi(userDataMap["userId"])
 	reply.Code = config.SuccessReplyCode
-	reply.UserId = int32(intUserId)
-	userName, _ := userDataMap["userName"]
+	reply.UserId = int32(userId)
 	reply.UserName = userName
+	reply.Device = device
 	return
 }
 
 func (rpc *RpcLogic) Logout(ctx context.Context, req *logic_pb.LogoutRequest, reply *logic_pb.LogoutResponse) (err error) {
 	reply.Code = config.FailReplyCode
 	authToken := req.AuthToken
-	sessionName := tools.GetSessionName(authToken)
-	userDataMap, err := RedisSessClient.HGetAll(sessionName).Result()
+	logic := new(Logic)
+	intUserId, _, device, err := logic.getAuthUser(authToken)
 	if err != nil {
 		logrus.Infof("logout fail! authToken is: %s", authToken)
 		return err
 	}
-	if len(userDataMap) == 0 {
+	if intUserId == 0 {
 		logrus.Infof("no this user session! authToken is: %s", authToken)
 		return
 	}
 
-	intUserId, _ := strconv.Atoi(userDataMap["userId"])
-	SessIdMap := tools.GetSessionIdByUserId(intUserId)
-	// 删掉用户key
-	err = RedisSessClient.Del(SessIdMap).Err()
-	if err != nil {
-		logrus.Infof("logout del token error ! authToken is: %s", authToken)
-		return err
+	// 只退出当前设备，删掉这个设备的令牌、会话和在线记录
+	if auth.Enabled() {
+		err = logic.revokeTokens(authToken)
+	} else {
+		err = logic.removeSession(intUserId, device, authToken)
 	}
-
-	logic := new(Logic)
-	// yoyichat_user01，删掉对应的这个是什么用户key？？似乎是connect key
-	serverIdKey := logic.getUserKey(fmt.Sprintf("%d", intUserId))
-	err = RedisSessClient.Del(serverIdKey).Err()
 	if err != nil {
-		logrus.Infof("logout del server id error:%s", err.Error())
+		logrus.Infof("logout error:%s", err.Error())
 		return err
 	}
-
-	// 删掉会话
-	err = RedisSessClient.Del(sessionName).Err()
-	if err != nil {
-		logrus.Infof("logout error:%s", err.Error())
+	if err = logic.removeDeviceServer(intUserId, device, ""); err != nil {
+		logrus.Infof("logout del device server error:%s", err.Error())
 		return err
 	}
 	reply.Code = config.SuccessReplyCode
@@ -187,26 +155,76 @@ func (rpc *RpcLogic) Logout(ctx context.Context, req *logic_pb.LogoutRequest, re
 func (rpc *RpcLogic) Push(ctx context.Context, req *logic_pb.SendMsg, reply *task_pb.SuccessReply) (err error) {
 	reply.Code = config.FailReplyCode
 	sendData := req
+	sendData.Op = config.OpSingleSend
+	// 单聊不属于任何房间，房间号一律清掉，后面按房间号判断会话的地方才不会把单聊当成群聊
+	sendData.RoomId = 0
+	logic := new(Logic)
+	if err = logic.checkContactTarget(int(sendData.FromUserId), int(sendData.ToUserId)); err != nil {
+		return
+	}
+	// 被对方拉黑了就不能再发单聊，自己拉黑了对方也要先解除
+	c := new(dao.Contact)
+	if c.IsBlocked(int(sendData.ToUserId), int(sendData.FromUserId)) {
+		return errors.New("you have been blocked by this user")
+	}
+	if c.IsBlocked(int(sendData.FromUserId), int(sendData.ToUserId)) {
+		return errors.New("you have blocked this user")
+	}
+	// 先落库再入队，队列丢了也能从历史消息里找回来
+	if err = logic.storeMessage(sendData); err != nil {
+		logrus.Errorf("logic,Push store message err:%s", err.Error())
+		return
+	}
 	bodyBytes, err := proto.Marshal(sendData)
 	if err != nil {
 		logrus.Errorf("logic layer push msg fail !!! err: %s", err.Error())
 		return
 	}
-	// 获取接收者所在的Connection服务器层，这个存在redis中
-	logic := new(Logic)
-	// yoyichat_2918 找到对方的connect层
-	userSidKey := logic.getUserKey(fmt.Sprintf("%d", sendData.ToUserId))
-	serverIdStr := RedisSessClient.Get(userSidKey).Val()
+	// 获取接收者所有设备所在的Connection服务器层，这个存在redis中
+	// yoyichat_device_2918 找到对方的connect层，每个connect层推一次，由connect层推给这个用户在上面的所有设备
+	serverIds := logic.getUserServerIds(int(sendData.ToUserId))
+	if len(serverIds) == 0 {
+		// 对方没有在线的connect层，直接进离线收件箱，等他下次连上来再推
+		if err = logic.storeOfflineMsg(int(sendData.ToUserId), bodyBytes); err != nil {
+			logrus.Errorf("logic,push store offline msg err: %s", err.Error())
+			return
+		}
+	}
+	for _, serverId := range serverIds {
+		// 推送到对应的队列中
+		err = logic.RedisPublishSingleSend(serverId, int(sendData.ToUserId), bodyBytes)
+		if err != nil {
+			logrus.Errorf("logic,redis publish err: %s", err.Error())
+			return
+		}
+	}
+	// 服务端已经接收，告诉发送方消息已发出，送达要等接收方客户端确认
+	if err = logic.pushDeliveryState(sendData, config.DeliveryStateSent); err != nil {
+		logrus.Warnf("logic,push delivery state err: %s", err.Error())
+		err = nil
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
 
+// 接收方客户端确认收到单聊消息，通知发送方已送达
+func (rpc *RpcLogic) Ack(ctx context.Context, req *logic_pb.AckRequest, reply *logic_pb.AckReply) (err error) {
+	reply.Code = config.FailReplyCode
+	m := new(dao.Message).GetMessageById(req.MsgId)
+	if m.Id == 0 || m.ToUserId != int(req.UserId) {
+		return errors.New("ack msg not found")
+	}
+	logic := new(Logic)
+	// 多个设备都会确认同一条消息，只给发送方推一次已送达
+	first, err := logic.markDelivered(m.Id)
 	if err != nil {
-		logrus.Errorf("logic,push parse int fail:%s", err.Error())
+		logrus.Warnf("logic,Ack mark delivered err: %s", err.Error())
+	} else if !first {
+		reply.Code = config.SuccessReplyCode
 		return
 	}
-
-	// 推送到对应的队列中
-	err = logic.RedisPublishSingleSend(serverIdStr, int(sendData.ToUserId), bodyBytes)
-	if err != nil {
-		logrus.Errorf("logic,redis publish err: %s", err.Error())
+	if err = logic.pushDeliveryState(logic.toSendMsg(&m), config.DeliveryStateDelivered); err != nil {
+		logrus.Errorf("logic,Ack push delivery state err: %s", err.Error())
 		return
 	}
 	reply.Code = config.SuccessReplyCode
@@ -218,6 +236,12 @@ func (rpc *RpcLogic) PushRoom(ctx context.Context, req *logic_pb.SendMsg, reply
 	sendData := req
 	roomId := sendData.RoomId
 	logic := new(Logic)
+	switch new(dao.RoomMember).GetRole(int(roomId), int(sendData.FromUserId)) {
+	case "":
+		return errors.New("not a member of this room")
+	case config.RoomRoleMuted:
+		return errors.New("you are muted in this room")
+	}
 	roomUserInfo := make(map[string]string)
 	// yoyichat_room_room01
 	roomUserKey := logic.getRoomUserKey(strconv.Itoa(int(roomId)))
@@ -231,9 +255,16 @@ func (rpc *RpcLogic) PushRoom(ctx context.Context, req *logic_pb.SendMsg, reply
 	sendData.RoomId = roomId
 	sendData.Msg = req.Msg
 	sendData.FromUserId = req.FromUserId
-	sendData.FromUserName = req.FromUserName
+	// 显示名按发送者ID从库里查，不信任调用方带来的名字（tcp客户端可以随便填）
+	sendData.FromUserName = new(dao.User).GetUserNameByUserId(int(req.FromUserId))
 	sendData.Op = config.OpRoomSend
 	sendData.CreateTime = tools.GetNowDateTime()
+	if err = logic.storeMessage(sendData); err != nil {
+		logrus.Errorf("logic,PushRoom store message err:%s", err.Error())
+		return
+	}
+	// @提及跟着消息一起广播，按落库时整理好的文本解析，结构化消息的文本在 payload 里
+	mentionTargetIds := logic.resolveMentions(sendData)
 	bodyBytes, err = proto.Marshal(sendData)
 	if err != nil {
 		logrus.Errorf("logic,PushRoom Marshal err:%s", err.Error())
@@ -244,6 +275,14 @@ func (rpc *RpcLogic) PushRoom(ctx context.Context, req *logic_pb.SendMsg, reply
 		logrus.Errorf("logic,PushRoom err:%s", err.Error())
 		return
 	}
+	if err = logic.notifyThreadFollowers(sendData); err != nil {
+		logrus.Warnf("logic,PushRoom notify thread followers err:%s", err.Error())
+		err = nil
+	}
+	if err = logic.pushMentions(sendData, mentionTargetIds); err != nil {
+		logrus.Warnf("logic,PushRoom push mentions err:%s", err.Error())
+		err = nil
+	}
 	reply.Code = config.SuccessReplyCode
 	return
 }
@@ -281,7 +320,7 @@ func (rpc *RpcLogic) GetRoomInfo(ctx context.Context, args *logic_pb.SendMsg, re
 	if len(roomUserInfo) == 0 {
 		return errors.New("getRoomInfo no this user")
 	}
-	err = logic.RedisPublishRoomInfo(int(roomId), len(roomUserInfo), roomUserInfo)
+	err = logic.RedisPublishRoomInfo(int(roomId), len(roomUserInfo), roomUserInfo, nil)
 	if err != nil {
 		logrus.Errorf("logic,GetRoomInfo err:%s", err.Error())
 		return
@@ -301,71 +340,1014 @@ func (rpc *RpcLogic) Connect(ctx context.Context, args *logic_pb.ConnectRequest,
 	logic := new(Logic)
 	//key := logic.getUserKey(args.AuthToken)
 	logrus.Infof("logic,authToken is:%s", args.AuthToken)
-	key := tools.GetSessionName(args.AuthToken)
-	userInfo, err := RedisClient.HGetAll(key).Result()
+	userId, userName, device, err := logic.getAuthUser(args.AuthToken)
 	if err != nil {
-		logrus.Infof("RedisCli HGetAll key :%s , err:%s", key, err.Error())
+		logrus.Infof("logic,connect get auth user err:%s", err.Error())
 		return err
 	}
-	if len(userInfo) == 0 {
+	if userId == 0 {
 		reply.UserId = 0
 		return
 	}
-	userId, _ := strconv.Atoi(userInfo["userId"])
+	// 带房间号连接时必须是房间成员
+	if args.RoomId > 0 && !logic.isRoomMember(int(args.RoomId), userId) {
+		return errors.New("not a member of this room")
+	}
 	reply.UserId = int32(userId)
-	roomUserKey := logic.getRoomUserKey(strconv.Itoa(int(args.RoomId)))
+	reply.Device = device
+	if reply.Device == "" {
+		reply.Device = config.DefaultDevice
+	}
 	if reply.UserId != 0 {
-		// yoyichat_29185
-		userKey := logic.getUserKey(fmt.Sprintf("%d", reply.UserId))
-		logrus.Infof("logic redis set userKey:%s, serverId : %s", userKey, args.ServerId)
-		validTime := config.RedisBaseValidTime * time.Second
-
-		// 记录用户 - 服务器 映射
-		// yoyichat_29185 : s01
-		err = RedisClient.Set(userKey, args.ServerId, validTime).Err()
-		if err != nil {
-			logrus.Warnf("logic set err:%s", err)
-		}
-
 		// 加入房间，人数加1，房间记录新用户
-		if RedisClient.HGet(roomUserKey, fmt.Sprintf("%d", reply.UserId)).Val() == "" {
-			RedisClient.HSet(roomUserKey, fmt.Sprintf("%d", reply.UserId), userInfo["userName"])
-			// add room user count ++
-			RedisClient.Incr(logic.getRoomOnlineCountKey(fmt.Sprintf("%d", args.RoomId)))
+		if args.RoomId > 0 {
+			logic.joinRoomOnline(int(args.RoomId), userId, userName)
 		}
+
+		// 设备在线和离线消息由connect层在Channel入桶后调 Online 处理
 	}
 	logrus.Infof("logic rpc userId:%d", reply.UserId)
 	return
 }
 
-// 离开房间
+// 断开连接，退出连接订阅的所有房间
 func (rpc *RpcLogic) DisConnect(ctx context.Context, args *logic_pb.DisConnectRequest, reply *logic_pb.DisConnectReply) (err error) {
 	logic := new(Logic)
-	roomUserKey := logic.getRoomUserKey(strconv.Itoa(int(args.RoomId)))
-	// room user count -- 更新在线人数
-	if args.RoomId > 0 {
-		count, _ := RedisSessClient.Get(logic.getRoomOnlineCountKey(fmt.Sprintf("%d", args.RoomId))).Int()
-		if count > 0 {
-			RedisClient.Decr(logic.getRoomOnlineCountKey(fmt.Sprintf("%d", args.RoomId))).Result()
+	if args.UserId == 0 {
+		return
+	}
+	// 被同一设备的新连接替换掉的，设备映射和在线状态已经属于新连接
+	if !args.Replaced {
+		if err = logic.removeDeviceServer(int(args.UserId), args.Device, args.ServerId); err != nil {
+			logrus.Warnf("logic remove device server err : %s", err)
+		}
+		if err = logic.removePresenceDevice(int(args.UserId), args.Device); err != nil {
+			logrus.Warnf("logic remove presence device err : %s", err)
+		}
+		if err = logic.refreshPresence(int(args.UserId)); err != nil {
+			logrus.Warnf("logic refresh presence err : %s", err)
 		}
 	}
-	// room login user-- 将用户从map中移除
-	if args.UserId != 0 {
-		err = RedisClient.HDel(roomUserKey, fmt.Sprintf("%d", args.UserId)).Err()
-		if err != nil {
-			logrus.Warnf("HDel getRoomUserKey err : %s", err)
+	// 还没被确认的单聊消息转存离线，下次连上来重新推
+	for _, msg := range args.UnackedMsgs {
+		if !logic.needRestoreUnacked(int(args.UserId), msg) {
+			continue
+		}
+		if err = logic.storeOfflineMsg(int(args.UserId), msg); err != nil {
+			logrus.Warnf("store unacked msg offline err : %s", err)
+		}
+	}
+	// 兼容只带一个房间号的老connect层
+	roomIds := args.RoomIds
+	if args.RoomId > 0 && !slices.Contains(roomIds, args.RoomId) {
+		roomIds = append(roomIds, args.RoomId)
+	}
+	for _, roomId := range roomIds {
+		if err = logic.leaveRoomOnline(int(roomId), int(args.UserId)); err != nil {
+			logrus.Warnf("logic,DisConnect leave room %d err: %s", roomId, err.Error())
+		}
+	}
+	return nil
+}
+
+// 已有连接上订阅房间，和带房间号建立连接一样需要是房间成员
+func (rpc *RpcLogic) SubscribeRoom(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	if req.UserId <= 0 || req.RoomId <= 0 {
+		return errors.New("user id or room id empty")
+	}
+	if !logic.isRoomMember(int(req.RoomId), int(req.UserId)) {
+		return errors.New("not a member of this room")
+	}
+	userName := new(dao.User).GetUserNameByUserId(int(req.UserId))
+	logic.joinRoomOnline(int(req.RoomId), int(req.UserId), userName)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 取消订阅房间
+func (rpc *RpcLogic) UnsubscribeRoom(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if err = new(Logic).leaveRoomOnline(int(req.RoomId), int(req.UserId)); err != nil {
+		logrus.Warnf("logic,UnsubscribeRoom err: %s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 单聊历史消息，会话ID由查询者和对方共同决定，所以只能查到自己参与的单聊
+func (rpc *RpcLogic) GetSingleHistory(ctx context.Context, req *logic_pb.HistoryRequest, reply *logic_pb.HistoryReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.ToUserId <= 0 {
+		return errors.New("user id empty")
+	}
+	logic := new(Logic)
+	conversationId := dao.GetSingleConversationId(int(req.UserId), int(req.ToUserId))
+	if err = logic.getHistory(conversationId, req, reply); err != nil {
+		logrus.Errorf("logic,GetSingleHistory err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 群聊历史消息，只有房间成员可以查
+func (rpc *RpcLogic) GetRoomHistory(ctx context.Context, req *logic_pb.HistoryRequest, reply *logic_pb.HistoryReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.RoomId <= 0 {
+		return errors.New("user id or room id empty")
+	}
+	logic := new(Logic)
+	if !logic.isRoomMember(int(req.RoomId), int(req.UserId)) {
+		return errors.New("not a member of this room")
+	}
+	conversationId := dao.GetRoomConversationId(int(req.RoomId))
+	if err = logic.getHistory(conversationId, req, reply); err != nil {
+		logrus.Errorf("logic,GetRoomHistory err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 上报已读位置，位置前进了才把回执推给会话里的其他人
+func (rpc *RpcLogic) ReadReceipt(ctx context.Context, req *logic_pb.ReadReceiptRequest, reply *logic_pb.ReadReceiptReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.Seq <= 0 {
+		return errors.New("user id or seq empty")
+	}
+	logic := new(Logic)
+	cursor := &dao.ReadCursor{
+		UserId: int(req.UserId),
+		Seq:    req.Seq,
+	}
+	if req.RoomId > 0 {
+		if !logic.isRoomMember(int(req.RoomId), int(req.UserId)) {
+			return errors.New("not a member of this room")
+		}
+		cursor.RoomId = int(req.RoomId)
+		cursor.ConversationId = dao.GetRoomConversationId(int(req.RoomId))
+	} else if req.ToUserId > 0 {
+		cursor.PeerUserId = int(req.ToUserId)
+		cursor.ConversationId = dao.GetSingleConversationId(int(req.UserId), int(req.ToUserId))
+	} else {
+		return errors.New("room id or to user id empty")
+	}
+	// 不能读到还不存在的消息
+	if maxSeq := new(dao.Message).GetMaxSeq(cursor.ConversationId); cursor.Seq > maxSeq {
+		cursor.Seq = maxSeq
+	}
+	advanced, err := cursor.Save()
+	if err != nil {
+		logrus.Errorf("logic,ReadReceipt save cursor err:%s", err.Error())
+		return
+	}
+	if advanced {
+		userName := new(dao.User).GetUserNameByUserId(int(req.UserId))
+		if err = logic.pushReadReceipt(cursor, userName); err != nil {
+			logrus.Warnf("logic,ReadReceipt push receipt err:%s", err.Error())
+			err = nil
+		}
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 各会话未读数
+func (rpc *RpcLogic) GetUnreadCount(ctx context.Context, req *logic_pb.UnreadRequest, reply *logic_pb.UnreadReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	logic := new(Logic)
+	reply.Counts = logic.getUnreadCounts(int(req.UserId))
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 发送好友申请，对方也给自己发过申请的话直接互加好友
+func (rpc *RpcLogic) SendFriendRequest(ctx context.Context, req *logic_pb.ContactRequest, reply *logic_pb.ContactReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	userId, targetUserId := int(req.UserId), logic.getContactTargetId(req)
+	if err = logic.checkContactTarget(userId, targetUserId); err != nil {
+		return
+	}
+	c := new(dao.Contact)
+	if c.IsBlocked(targetUserId, userId) {
+		return errors.New("you have been blocked by this user")
+	}
+	if c.GetStatus(userId, targetUserId) == config.ContactStatusFriend {
+		return errors.New("already friends")
+	}
+	f := new(dao.FriendRequest)
+	if reverse := f.GetPending(targetUserId, userId); reverse.Id > 0 {
+		if err = reverse.Accept(); err != nil {
+			logrus.Errorf("logic,SendFriendRequest accept reverse request err:%s", err.Error())
+			return
+		}
+		reverse.Status = config.FriendRequestStatusAccepted
+		if err = logic.pushContactEvent(targetUserId, config.ContactEventAccept, &reverse); err != nil {
+			logrus.Warnf("logic,SendFriendRequest push contact event err:%s", err.Error())
+			err = nil
+		}
+		reply.Code = config.SuccessReplyCode
+		return
+	}
+	f.FromUserId = userId
+	f.ToUserId = targetUserId
+	f.Message = req.Message
+	if err = f.Add(); err != nil {
+		logrus.Errorf("logic,SendFriendRequest add request err:%s", err.Error())
+		return
+	}
+	if err = logic.pushContactEvent(targetUserId, config.ContactEventRequest, f); err != nil {
+		logrus.Warnf("logic,SendFriendRequest push contact event err:%s", err.Error())
+		err = nil
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 接受好友申请，只有被申请人可以操作
+func (rpc *RpcLogic) AcceptFriendRequest(ctx context.Context, req *logic_pb.ContactRequest, reply *logic_pb.ContactReply) (err error) {
+	reply.Code = config.FailReplyCode
+	f := new(dao.FriendRequest).GetById(req.RequestId)
+	if f.Id == 0 || f.ToUserId != int(req.UserId) || f.Status != config.FriendRequestStatusPending {
+		return errors.New("friend request not found")
+	}
+	if err = f.Accept(); err != nil {
+		logrus.Errorf("logic,AcceptFriendRequest err:%s", err.Error())
+		return
+	}
+	f.Status = config.FriendRequestStatusAccepted
+	logic := new(Logic)
+	if err = logic.pushContactEvent(f.FromUserId, config.ContactEventAccept, &f); err != nil {
+		logrus.Warnf("logic,AcceptFriendRequest push contact event err:%s", err.Error())
+		err = nil
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 拒绝好友申请，不通知申请人
+func (rpc *RpcLogic) DeclineFriendRequest(ctx context.Context, req *logic_pb.ContactRequest, reply *logic_pb.ContactReply) (err error) {
+	reply.Code = config.FailReplyCode
+	f := new(dao.FriendRequest).GetById(req.RequestId)
+	if f.Id == 0 || f.ToUserId != int(req.UserId) || f.Status != config.FriendRequestStatusPending {
+		return errors.New("friend request not found")
+	}
+	if err = f.Decline(); err != nil {
+		logrus.Errorf("logic,DeclineFriendRequest err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 删除好友
+func (rpc *RpcLogic) RemoveContact(ctx context.Context, req *logic_pb.ContactRequest, reply *logic_pb.ContactReply) (err error) {
+	reply.Code = config.FailReplyCode
+	targetUserId := new(Logic).getContactTargetId(req)
+	if req.UserId <= 0 || targetUserId <= 0 {
+		return errors.New("target user id invalid")
+	}
+	if err = new(dao.Contact).RemoveFriend(int(req.UserId), targetUserId); err != nil {
+		logrus.Errorf("logic,RemoveContact err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 拉黑，被拉黑的人不能再给自己发单聊消息和好友申请
+func (rpc *RpcLogic) BlockUser(ctx context.Context, req *logic_pb.ContactRequest, reply *logic_pb.ContactReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	targetUserId := logic.getContactTargetId(req)
+	if err = logic.checkContactTarget(int(req.UserId), targetUserId); err != nil {
+		return
+	}
+	if err = new(dao.Contact).Block(int(req.UserId), targetUserId); err != nil {
+		logrus.Errorf("logic,BlockUser err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+func (rpc *RpcLogic) UnblockUser(ctx context.Context, req *logic_pb.ContactRequest, reply *logic_pb.ContactReply) (err error) {
+	reply.Code = config.FailReplyCode
+	targetUserId := new(Logic).getContactTargetId(req)
+	if req.UserId <= 0 || targetUserId <= 0 {
+		return errors.New("target user id invalid")
+	}
+	if err = new(dao.Contact).Unblock(int(req.UserId), targetUserId); err != nil {
+		logrus.Errorf("logic,UnblockUser err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 联系人列表和待处理的好友申请
+func (rpc *RpcLogic) GetContacts(ctx context.Context, req *logic_pb.ContactListRequest, reply *logic_pb.ContactListReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	logic := new(Logic)
+	reply.Contacts = logic.getContacts(int(req.UserId))
+	for _, f := range new(dao.FriendRequest).GetPendingByUserId(int(req.UserId)) {
+		reply.Requests = append(reply.Requests, logic.toFriendRequestPb(&f))
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 创建房间，创建者就是房主
+func (rpc *RpcLogic) CreateRoom(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.Name == "" {
+		return errors.New("room name empty")
+	}
+	if req.Visibility != "" && req.Visibility != config.RoomVisibilityPublic && req.Visibility != config.RoomVisibilityPrivate {
+		return errors.New("visibility must be public or private")
+	}
+	r := new(dao.Room)
+	if old := r.GetByName(req.Name); old.Id > 0 {
+		return errors.New("room name already exists")
+	}
+	r.Name = req.Name
+	r.Topic = req.Topic
+	r.OwnerId = int(req.UserId)
+	r.Visibility = req.Visibility
+	if err = r.Add(); err != nil {
+		logrus.Errorf("logic,CreateRoom err:%s", err.Error())
+		return
+	}
+	reply.Room = new(Logic).toRoomPb(r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 加入公开房间
+func (rpc *RpcLogic) JoinRoom(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	if new(dao.RoomBan).IsBanned(r.Id, int(req.UserId)) {
+		return errors.New("you are banned from this room")
+	}
+	roomMember := &dao.RoomMember{RoomId: r.Id, UserId: int(req.UserId)}
+	if r.Visibility != config.RoomVisibilityPublic && !roomMember.IsMember(r.Id, int(req.UserId)) {
+		return errors.New("private room can not be joined")
+	}
+	if err = roomMember.Add(); err != nil {
+		logrus.Errorf("logic,JoinRoom err:%s", err.Error())
+		return
+	}
+	reply.Room = new(Logic).toRoomPb(&r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 退出房间，房主不能退出
+func (rpc *RpcLogic) LeaveRoom(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	if r.OwnerId == int(req.UserId) {
+		return errors.New("room owner can not leave")
+	}
+	if err = new(dao.RoomMember).Remove(r.Id, int(req.UserId)); err != nil {
+		logrus.Errorf("logic,LeaveRoom err:%s", err.Error())
+		return
+	}
+	// 和踢人一样清掉在线名单，并让connect层把这个人的连接移出房间
+	if err = new(Logic).pushRoomEvent(&r, int(req.UserId), int(req.UserId), config.RoomEventLeave, ""); err != nil {
+		logrus.Warnf("logic,LeaveRoom push event err:%s", err.Error())
+		err = nil
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 用户加入的房间
+func (rpc *RpcLogic) ListRooms(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomListReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	reply.Rooms = new(Logic).toRoomListPb(new(dao.Room).GetByUserId(int(req.UserId)))
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 搜索公开房间
+func (rpc *RpcLogic) SearchRooms(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomListReply) (err error) {
+	reply.Code = config.FailReplyCode
+	reply.Rooms = new(Logic).toRoomListPb(new(dao.Room).SearchPublic(req.Keyword, config.RoomSearchLimit))
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 设置成员角色：房主任免管理员，管理员可以禁言和解除禁言
+func (rpc *RpcLogic) SetRoomRole(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	logic := new(Logic)
+	switch req.Role {
+	case config.RoomRoleAdmin, config.RoomRoleMember, config.RoomRoleMuted:
+	default:
+		return errors.New("role must be admin, member or muted")
+	}
+	targetRole, err := logic.checkRoomManage(r.Id, int(req.UserId), int(req.TargetUserId))
+	if err != nil {
+		return
+	}
+	// 任免管理员只有房主能做
+	if (req.Role == config.RoomRoleAdmin || targetRole == config.RoomRoleAdmin) && r.OwnerId != int(req.UserId) {
+		return errors.New("only room owner can change admins")
+	}
+	if err = new(dao.RoomMember).SetRole(r.Id, int(req.TargetUserId), req.Role); err != nil {
+		logrus.Errorf("logic,SetRoomRole err:%s", err.Error())
+		return
+	}
+	if err = logic.pushRoomEvent(&r, int(req.UserId), int(req.TargetUserId), config.RoomEventRole, req.Role); err != nil {
+		logrus.Warnf("logic,SetRoomRole push event err:%s", err.Error())
+		err = nil
+	}
+	reply.Room = logic.toRoomPb(&r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 踢出房间，被踢的人还可以重新加入
+func (rpc *RpcLogic) KickRoomMember(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	logic := new(Logic)
+	if _, err = logic.checkRoomManage(r.Id, int(req.UserId), int(req.TargetUserId)); err != nil {
+		return
+	}
+	if err = new(dao.RoomMember).Remove(r.Id, int(req.TargetUserId)); err != nil {
+		logrus.Errorf("logic,KickRoomMember err:%s", err.Error())
+		return
+	}
+	if err = logic.pushRoomEvent(&r, int(req.UserId), int(req.TargetUserId), config.RoomEventKick, ""); err != nil {
+		logrus.Warnf("logic,KickRoomMember push event err:%s", err.Error())
+		err = nil
+	}
+	reply.Room = logic.toRoomPb(&r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 封禁：移出房间并且不能再加入
+func (rpc *RpcLogic) BanRoomMember(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	logic := new(Logic)
+	if _, err = logic.checkRoomManage(r.Id, int(req.UserId), int(req.TargetUserId)); err != nil {
+		return
+	}
+	roomBan := &dao.RoomBan{RoomId: r.Id, UserId: int(req.TargetUserId), OperatorId: int(req.UserId)}
+	if err = roomBan.Add(); err != nil {
+		logrus.Errorf("logic,BanRoomMember err:%s", err.Error())
+		return
+	}
+	if err = logic.pushRoomEvent(&r, int(req.UserId), int(req.TargetUserId), config.RoomEventBan, ""); err != nil {
+		logrus.Warnf("logic,BanRoomMember push event err:%s", err.Error())
+		err = nil
+	}
+	reply.Room = logic.toRoomPb(&r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 解除封禁，解除后需要自己重新加入
+func (rpc *RpcLogic) UnbanRoomMember(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	logic := new(Logic)
+	if !logic.isRoomManager(r.Id, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	if err = new(dao.RoomBan).Remove(r.Id, int(req.TargetUserId)); err != nil {
+		logrus.Errorf("logic,UnbanRoomMember err:%s", err.Error())
+		return
+	}
+	reply.Room = logic.toRoomPb(&r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 改房间名或话题，房主和管理员可以操作，空房间名表示不改，话题看 TopicSet，可以清空
+func (rpc *RpcLogic) UpdateRoom(ctx context.Context, req *logic_pb.RoomRequest, reply *logic_pb.RoomReply) (err error) {
+	reply.Code = config.FailReplyCode
+	r := new(dao.Room).GetById(int(req.RoomId))
+	if r.Id == 0 {
+		return errors.New("room not exist")
+	}
+	logic := new(Logic)
+	if !logic.isRoomManager(r.Id, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	name, topic := r.Name, r.Topic
+	if req.Name != "" && req.Name != r.Name {
+		if old := new(dao.Room).GetByName(req.Name); old.Id > 0 {
+			return errors.New("room name already exists")
+		}
+		name = req.Name
+	}
+	if req.TopicSet || req.Topic != "" {
+		topic = req.Topic
+	}
+	if name == r.Name && topic == r.Topic {
+		return errors.New("nothing to update")
+	}
+	if err = r.Update(name, topic); err != nil {
+		logrus.Errorf("logic,UpdateRoom err:%s", err.Error())
+		return
+	}
+	renamed, topicChanged := name != r.Name, topic != r.Topic
+	r.Name, r.Topic = name, topic
+	if renamed {
+		if err = logic.pushRoomEvent(&r, int(req.UserId), 0, config.RoomEventRename, ""); err != nil {
+			logrus.Warnf("logic,UpdateRoom push event err:%s", err.Error())
+		}
+	}
+	if topicChanged {
+		if err = logic.pushRoomEvent(&r, int(req.UserId), 0, config.RoomEventTopic, ""); err != nil {
+			logrus.Warnf("logic,UpdateRoom push event err:%s", err.Error())
 		}
 	}
-	//below code can optimize send a signal to queue,another process get a signal from queue,then push event to websocket
-	// 下方代码可优化为：发送信号到队列，再由另一个进程从队列获取信号并推送事件到WebSocket
-	// 但是我看来这其实就已经推送到队列中，这个logic层的逻辑从来不自己处理逻辑，都是推送到队列中
-	roomUserInfo, err := RedisClient.HGetAll(roomUserKey).Result()
+	err = nil
+	reply.Room = logic.toRoomPb(&r)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// connect层心跳，刷新设备在线和空闲状态
+func (rpc *RpcLogic) Heartbeat(ctx context.Context, req *logic_pb.HeartbeatRequest, reply *logic_pb.HeartbeatReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	logic := new(Logic)
+	ok, err := logic.refreshDeviceServer(int(req.UserId), req.Device, req.ServerId)
 	if err != nil {
-		logrus.Warnf("RedisCli HGetAll roomUserInfo key:%s, err: %s", roomUserKey, err)
+		logrus.Warnf("logic,Heartbeat refresh device server err:%s", err.Error())
+		return
+	}
+	if !ok {
+		return errors.New("device is not connected to this server")
+	}
+	if err = logic.touchPresence(int(req.UserId), req.Device, req.Idle); err != nil {
+		logrus.Warnf("logic,Heartbeat touch presence err:%s", err.Error())
+		return
+	}
+	if err = logic.refreshPresence(int(req.UserId)); err != nil {
+		logrus.Warnf("logic,Heartbeat refresh presence err:%s", err.Error())
+		err = nil
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 手动设置状态：away / dnd，设置 online 表示恢复自动
+func (rpc *RpcLogic) SetPresence(ctx context.Context, req *logic_pb.PresenceRequest, reply *logic_pb.PresenceReply) (err error) {
+	reply.Code = config.FailReplyCode
+	switch req.Status {
+	case config.PresenceOnline, config.PresenceAway, config.PresenceDnd:
+	default:
+		return errors.New("status must be online, away or dnd")
+	}
+	logic := new(Logic)
+	if err = logic.setManualPresence(int(req.UserId), req.Status); err != nil {
+		logrus.Errorf("logic,SetPresence err:%s", err.Error())
+		return
 	}
-	if err = logic.RedisPublishRoomSend(int(args.RoomId), len(roomUserInfo), roomUserInfo, nil); err != nil {
-		logrus.Warnf("publish RedisPublishRoomCount err: %s", err.Error())
+	reply.Presences = []*logic_pb.Presence{logic.getPresence(int(req.UserId))}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 批量查询在线状态，只能查自己、好友和同房间的人
+func (rpc *RpcLogic) GetPresence(ctx context.Context, req *logic_pb.PresenceRequest, reply *logic_pb.PresenceReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	if len(req.UserIds) > config.PresenceQueryLimit {
+		return fmt.Errorf("query at most %d users", config.PresenceQueryLimit)
+	}
+	logic := new(Logic)
+	visible := logic.presenceVisible(int(req.UserId), req.UserIds)
+	for _, userId := range req.UserIds {
+		if !visible[int(userId)] {
+			return fmt.Errorf("no permission to see presence of user %d", userId)
+		}
+	}
+	for _, userId := range req.UserIds {
+		reply.Presences = append(reply.Presences, logic.getPresence(int(userId)))
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 客户端上报输入状态，节流后推给会话里的其他人
+func (rpc *RpcLogic) Typing(ctx context.Context, req *logic_pb.TypingRequest, reply *logic_pb.TypingReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	if err = logic.checkTyping(req); err != nil {
+		return
+	}
+	if logic.throttleTyping(req) {
+		if err = logic.pushTyping(req); err != nil {
+			logrus.Warnf("logic,Typing push err:%s", err.Error())
+			return
+		}
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 编辑消息，只有发送者可以编辑，删除后不能再编辑
+func (rpc *RpcLogic) EditMessage(ctx context.Context, req *logic_pb.MsgUpdateRequest, reply *logic_pb.MsgUpdateReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.Content == "" {
+		return errors.New("content empty")
+	}
+	logic := new(Logic)
+	m := new(dao.Message).GetMessageById(req.MsgId)
+	if m.Id == 0 {
+		return errors.New("message not exist")
+	}
+	if m.FromUserId != int(req.UserId) {
+		return errors.New("only the author can edit this message")
+	}
+	if !canEditPayload(&m) {
+		return errors.New("only text messages can be edited")
+	}
+	if m.Deleted {
+		return errors.New("message has been deleted")
+	}
+	if err = m.Edit(req.Content, int(req.UserId)); err != nil {
+		logrus.Errorf("logic,EditMessage err:%s", err.Error())
+		return
+	}
+	if err = logic.pushMsgUpdate(&m, config.OpMsgEdit); err != nil {
+		logrus.Warnf("logic,EditMessage push err:%s", err.Error())
+		err = nil
+	}
+	reply.Msg = logic.toSendMsg(&m)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 删除消息，发送者可以删自己的，房主和管理员可以删房间里任何人的
+func (rpc *RpcLogic) DeleteMessage(ctx context.Context, req *logic_pb.MsgUpdateRequest, reply *logic_pb.MsgUpdateReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	m := new(dao.Message).GetMessageById(req.MsgId)
+	if m.Id == 0 {
+		return errors.New("message not exist")
+	}
+	if !logic.canDeleteMessage(&m, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	if m.Deleted {
+		reply.Msg = logic.toSendMsg(&m)
+		reply.Code = config.SuccessReplyCode
+		return
+	}
+	if err = m.Delete(int(req.UserId)); err != nil {
+		logrus.Errorf("logic,DeleteMessage err:%s", err.Error())
+		return
+	}
+	if err = logic.pushMsgUpdate(&m, config.OpMsgDelete); err != nil {
+		logrus.Warnf("logic,DeleteMessage push err:%s", err.Error())
+		err = nil
+	}
+	reply.Msg = logic.toSendMsg(&m)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 添加或取消表情回应，重复添加、取消没回应过的都当作成功，只有真的变化了才推送
+func (rpc *RpcLogic) AddReaction(ctx context.Context, req *logic_pb.ReactionRequest, reply *logic_pb.ReactionReply) (err error) {
+	return rpc.updateReaction(req, reply, config.ReactionActionAdd)
+}
+
+func (rpc *RpcLogic) RemoveReaction(ctx context.Context, req *logic_pb.ReactionRequest, reply *logic_pb.ReactionReply) (err error) {
+	return rpc.updateReaction(req, reply, config.ReactionActionRemove)
+}
+
+func (rpc *RpcLogic) updateReaction(req *logic_pb.ReactionRequest, reply *logic_pb.ReactionReply, action string) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	if err = logic.checkEmoji(req.Emoji); err != nil {
+		return
+	}
+	m := new(dao.Message).GetMessageById(req.MsgId)
+	if m.Id == 0 {
+		return errors.New("message not exist")
+	}
+	if !logic.canSeeMessage(&m, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	if m.Deleted {
+		return errors.New("message has been deleted")
+	}
+	reactionDao := new(dao.MessageReaction)
+	var changed bool
+	if action == config.ReactionActionAdd {
+		reaction := &dao.MessageReaction{MessageId: m.Id, UserId: int(req.UserId), Emoji: req.Emoji}
+		changed, err = reaction.Add()
+	} else {
+		changed, err = reactionDao.Remove(m.Id, int(req.UserId), req.Emoji)
+	}
+	if err != nil {
+		logrus.Errorf("logic,updateReaction %s err:%s", action, err.Error())
 		return
 	}
+	reply.Reactions = logic.getReactions(m.Id, int(req.UserId))
+	if changed {
+		if err = logic.pushReaction(&m, int(req.UserId), req.Emoji, action, reply.Reactions); err != nil {
+			logrus.Warnf("logic,updateReaction push err:%s", err.Error())
+			err = nil
+		}
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 查询一条消息的表情回应汇总
+func (rpc *RpcLogic) GetReactions(ctx context.Context, req *logic_pb.ReactionRequest, reply *logic_pb.ReactionReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	m := new(dao.Message).GetMessageById(req.MsgId)
+	if m.Id == 0 {
+		return errors.New("message not exist")
+	}
+	if !logic.canSeeMessage(&m, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	reply.Reactions = logic.getReactions(m.Id, int(req.UserId))
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 查话题：根消息加上分页的回复，能看到根消息的人才能查
+func (rpc *RpcLogic) GetThreadHistory(ctx context.Context, req *logic_pb.HistoryRequest, reply *logic_pb.HistoryReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	root := new(dao.Message).GetMessageById(req.ThreadId)
+	if root.Id == 0 || root.ReplyTo > 0 {
+		return errors.New("thread not exist")
+	}
+	if !logic.canSeeMessage(&root, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	after := req.Direction == config.HistoryDirectionAfter
+	list, hasMore, err := new(dao.Message).GetThreadHistory(root.Id, req.CursorSeq, historyCursorTime(req), after, historyLimit(req))
+	if err != nil {
+		logrus.Errorf("logic,GetThreadHistory err:%s", err.Error())
+		return
+	}
+	reply.Root = logic.toSendMsg(&root)
+	logic.fillMsgExtras([]*logic_pb.SendMsg{reply.Root}, int(req.UserId))
+	logic.fillHistoryReply(list, hasMore, int(req.UserId), reply)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 房间里有回复的话题，带回复数和最后回复时间，按最后回复时间倒序，用 cursor_time 翻页
+func (rpc *RpcLogic) GetRoomThreads(ctx context.Context, req *logic_pb.HistoryRequest, reply *logic_pb.HistoryReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.RoomId <= 0 {
+		return errors.New("user id or room id empty")
+	}
+	logic := new(Logic)
+	if !logic.isRoomMember(int(req.RoomId), int(req.UserId)) {
+		return errors.New("not a member of this room")
+	}
+	conversationId := dao.GetRoomConversationId(int(req.RoomId))
+	list, hasMore, err := new(dao.Message).GetThreads(conversationId, historyCursorTime(req), historyLimit(req))
+	if err != nil {
+		logrus.Errorf("logic,GetRoomThreads err:%s", err.Error())
+		return
+	}
+	logic.fillHistoryReply(list, hasMore, int(req.UserId), reply)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 关注、取消关注话题
+func (rpc *RpcLogic) FollowThread(ctx context.Context, req *logic_pb.ThreadFollowRequest, reply *logic_pb.ThreadFollowReply) (err error) {
+	reply.Code = config.FailReplyCode
+	logic := new(Logic)
+	root, err := logic.getThreadRoot(req.MsgId, "")
+	if err != nil {
+		return
+	}
+	if !logic.canSeeMessage(&root, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	if err = new(dao.ThreadFollower).Follow(root.Id, int(req.UserId)); err != nil {
+		logrus.Errorf("logic,FollowThread err:%s", err.Error())
+		return
+	}
+	reply.ThreadId = root.Id
+	reply.Following = true
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+func (rpc *RpcLogic) UnfollowThread(ctx context.Context, req *logic_pb.ThreadFollowRequest, reply *logic_pb.ThreadFollowReply) (err error) {
+	reply.Code = config.FailReplyCode
+	root := new(dao.Message).GetMessageById(req.MsgId)
+	if root.ReplyTo > 0 {
+		root = new(dao.Message).GetMessageById(root.ReplyTo)
+	}
+	if root.Id == 0 {
+		return errors.New("thread not exist")
+	}
+	if err = new(dao.ThreadFollower).Unfollow(root.Id, int(req.UserId)); err != nil {
+		logrus.Errorf("logic,UnfollowThread err:%s", err.Error())
+		return
+	}
+	reply.ThreadId = root.Id
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 查询自己被@的消息
+func (rpc *RpcLogic) GetMentions(ctx context.Context, req *logic_pb.MentionRequest, reply *logic_pb.MentionReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	new(Logic).getMentions(req, reply)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// api层把文件存好之后登记附件，拿到附件ID再随消息发出
+func (rpc *RpcLogic) CreateAttachment(ctx context.Context, req *logic_pb.AttachmentRequest, reply *logic_pb.AttachmentReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.Attachment == nil || req.StorageKey == "" {
+		return errors.New("attachment info empty")
+	}
+	if req.Attachment.Name == "" || len(req.Attachment.Name) > config.AttachmentNameMaxLen {
+		return errors.New("invalid attachment name")
+	}
+	a := &dao.Attachment{
+		UploaderId: int(req.UserId),
+		Name:       req.Attachment.Name,
+		Size:       req.Attachment.Size,
+		MimeType:   req.Attachment.MimeType,
+		Checksum:   req.Attachment.Checksum,
+		StorageKey: req.StorageKey,
+	}
+	if err = a.AddWithQuota(req.Quota); err != nil {
+		if err != dao.ErrAttachmentQuota {
+			logrus.Errorf("logic,CreateAttachment err:%s", err.Error())
+		}
+		return
+	}
+	reply.Attachment = toAttachmentPb(a)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 下载前检查权限，返回存储key
+func (rpc *RpcLogic) GetAttachment(ctx context.Context, req *logic_pb.AttachmentRequest, reply *logic_pb.AttachmentReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.Attachment == nil {
+		return errors.New("attachment id empty")
+	}
+	a := new(dao.Attachment).GetById(req.Attachment.Id)
+	if a.Id == 0 {
+		return errors.New("attachment not exist")
+	}
+	if !new(Logic).canSeeAttachment(&a, int(req.UserId)) {
+		return errors.New("permission denied")
+	}
+	reply.Attachment = toAttachmentPb(&a)
+	reply.StorageKey = a.StorageKey
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// api层定时清理上传后一直没发出去的附件，这里删记录，文件由api层删
+func (rpc *RpcLogic) ClaimOrphanAttachments(ctx context.Context, req *logic_pb.OrphanAttachmentRequest, reply *logic_pb.OrphanAttachmentReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.Before <= 0 || req.Limit <= 0 {
+		return errors.New("before or limit empty")
+	}
+	if reply.StorageKeys, err = new(dao.Attachment).ClaimOrphans(time.UnixMilli(req.Before), int(req.Limit)); err != nil {
+		logrus.Errorf("logic,ClaimOrphanAttachments err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// 搜索消息，只返回调用者参与的会话里的
+func (rpc *RpcLogic) SearchMessages(ctx context.Context, req *logic_pb.SearchRequest, reply *logic_pb.SearchReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	if err = new(Logic).search(req, reply); err != nil {
+		logrus.Warnf("logic,SearchMessages err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// jwt 模式下用刷新令牌换新的访问令牌和刷新令牌
+func (rpc *RpcLogic) RefreshToken(ctx context.Context, req *logic_pb.RefreshTokenRequest, reply *logic_pb.RefreshTokenReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if !auth.Enabled() {
+		return errors.New("refresh token is only available in jwt auth mode")
+	}
+	tokens, err := new(Logic).rotateTokens(req.RefreshToken)
+	if err != nil {
+		logrus.Infof("logic,RefreshToken err:%s", err.Error())
+		return
+	}
+	reply.AuthToken = tokens.AccessToken
+	reply.RefreshToken = tokens.RefreshToken
+	reply.ExpiresAt = tokens.ExpiresAt
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// connect层在Channel入桶后登记设备在线，再取离线收件箱，推完再调 TrimOffline 删掉推出去的
+// 设备登记之前单聊不会路由到这个connect层，所以登记之后推过来的都能找到Channel
+func (rpc *RpcLogic) Online(ctx context.Context, req *logic_pb.OnlineRequest, reply *logic_pb.OnlineReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 || req.ServerId == "" {
+		return errors.New("user id or server id empty")
+	}
+	logic := new(Logic)
+	userId := int(req.UserId)
+	logrus.Infof("logic redis set user:%d device:%s, serverId : %s", userId, req.Device, req.ServerId)
+	// 记录用户设备 - 服务器 映射
+	// yoyichat_device_29185 : {laptop: s01, phone: s02}
+	if err = logic.setDeviceServer(userId, req.Device, req.ServerId); err != nil {
+		logrus.Errorf("logic,Online set device server err:%s", err.Error())
+		return
+	}
+	if err = logic.touchPresence(userId, req.Device, false); err != nil {
+		logrus.Warnf("logic touch presence err:%s", err)
+	}
+	if err = logic.refreshPresence(userId); err != nil {
+		logrus.Warnf("logic refresh presence err:%s", err)
+	}
+	msgs, err := logic.getOfflineMsgs(userId)
+	if err != nil {
+		logrus.Errorf("logic,Online get offline msg err:%s", err.Error())
+		return
+	}
+	reply.OfflineMsgs = logic.refreshOfflineMsgs(msgs)
+	reply.Code = config.SuccessReplyCode
+	return
+}
+
+// connect层已经把离线消息写给客户端，从收件箱里删掉
+func (rpc *RpcLogic) TrimOffline(ctx context.Context, req *logic_pb.OfflineTrimRequest, reply *logic_pb.OfflineTrimReply) (err error) {
+	reply.Code = config.FailReplyCode
+	if req.UserId <= 0 {
+		return errors.New("user id empty")
+	}
+	if err = new(Logic).trimOfflineMsgs(int(req.UserId), req.MsgIds); err != nil {
+		logrus.Errorf("logic,TrimOffline err:%s", err.Error())
+		return
+	}
+	reply.Code = config.SuccessReplyCode
 	return
 }
@@ -0,0 +1,79 @@
+package logic
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 把搜索请求转成查询条件，会话范围限定在调用者参与的单聊和所在的房间
+func (logic *Logic) searchFilter(req *logic_pb.SearchRequest) (f *dao.SearchFilter, err error) {
+	keyword := strings.TrimSpace(req.Keyword)
+	if keyword == "" {
+		return nil, errors.New("keyword empty")
+	}
+	if utf8.RuneCountInString(keyword) > config.SearchKeywordMaxLen {
+		return nil, errors.New("keyword too long")
+	}
+	switch req.Attachment {
+	case config.SearchAttachmentAny, config.SearchAttachmentWith, config.SearchAttachmentWithout:
+	default:
+		return nil, errors.New("invalid attachment filter")
+	}
+	f = &dao.SearchFilter{
+		UserId:     int(req.UserId),
+		Keyword:    keyword,
+		FromUserId: int(req.FromUserId),
+		Attachment: int(req.Attachment),
+		CursorId:   req.CursorId,
+		Limit:      int(req.Limit),
+	}
+	if f.Limit <= 0 {
+		f.Limit = config.SearchDefaultLimit
+	}
+	if f.Limit > config.SearchMaxLimit {
+		f.Limit = config.SearchMaxLimit
+	}
+	if req.StartTime > 0 {
+		f.StartTime = time.UnixMilli(req.StartTime)
+	}
+	if req.EndTime > 0 {
+		f.EndTime = time.UnixMilli(req.EndTime)
+	}
+	if req.RoomId > 0 {
+		if !logic.isRoomMember(int(req.RoomId), int(req.UserId)) {
+			return nil, errors.New("not a member of this room")
+		}
+		f.RoomId = int(req.RoomId)
+		return
+	}
+	f.RoomIds = new(dao.RoomMember).GetRoomIdsByUserId(int(req.UserId))
+	return
+}
+
+func (logic *Logic) search(req *logic_pb.SearchRequest, reply *logic_pb.SearchReply) (err error) {
+	f, err := logic.searchFilter(req)
+	if err != nil {
+		return
+	}
+	hits, hasMore, err := new(dao.Message).Search(f)
+	if err != nil {
+		return
+	}
+	msgs := make([]*logic_pb.SendMsg, 0, len(hits))
+	for i := range hits {
+		msg := logic.toSendMsg(&hits[i].Message)
+		msgs = append(msgs, msg)
+		reply.Hits = append(reply.Hits, &logic_pb.SearchHit{
+			Msg:     msg,
+			Snippet: hits[i].Snippet,
+		})
+	}
+	logic.fillMsgExtras(msgs, int(req.UserId))
+	reply.HasMore = hasMore
+	return
+}
@@ -0,0 +1,69 @@
+package logic
+
+import (
+	"errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 话题只有一层：回复一条回复，算作回复它的根消息
+// 回复必须和根消息在同一个会话里
+func (logic *Logic) getThreadRoot(msgId int64, conversationId string) (root dao.Message, err error) {
+	root = new(dao.Message).GetMessageById(msgId)
+	if root.Id > 0 && root.ReplyTo > 0 {
+		root = new(dao.Message).GetMessageById(root.ReplyTo)
+	}
+	if root.Id == 0 {
+		return root, errors.New("reply to message not exist")
+	}
+	if conversationId != "" && root.ConversationId != conversationId {
+		return root, errors.New("reply to message not in this conversation")
+	}
+	if root.Deleted {
+		return root, errors.New("reply to message has been deleted")
+	}
+	return
+}
+
+// 回复的人自动关注话题，第一条回复出现时根消息的作者也自动关注
+// 之后作者取消关注了，再有回复也不会重新关注
+func (logic *Logic) followOnReply(root *dao.Message, userId int) {
+	follower := new(dao.ThreadFollower)
+	if root.ReplyCount == 0 && root.FromUserId != userId {
+		if err := follower.Follow(root.Id, root.FromUserId); err != nil {
+			logrus.Warnf("logic,followOnReply follow root author err:%s", err.Error())
+		}
+	}
+	if err := follower.Follow(root.Id, userId); err != nil {
+		logrus.Warnf("logic,followOnReply err:%s", err.Error())
+	}
+}
+
+// 群聊话题有新回复时通知关注的人，回复本身已经广播到房间了，这里是单独的提醒，
+// 没在当前连接上订阅这个房间的设备也能收到；已经不在房间里的人不再通知
+// 单聊的回复本身就会推给对方，不再重复通知
+func (logic *Logic) notifyThreadFollowers(sendData *logic_pb.SendMsg) (err error) {
+	if sendData.ReplyTo == 0 || sendData.RoomId == 0 {
+		return
+	}
+	notifyMsg := proto.Clone(sendData).(*logic_pb.SendMsg)
+	notifyMsg.Op = config.OpThreadReply
+	body, err := proto.Marshal(notifyMsg)
+	if err != nil {
+		return
+	}
+	for _, userId := range new(dao.ThreadFollower).GetFollowerIds(sendData.ReplyTo) {
+		if userId == int(sendData.FromUserId) || !logic.isRoomMember(int(sendData.RoomId), userId) {
+			continue
+		}
+		for _, serverId := range logic.getUserServerIds(userId) {
+			if err = logic.RedisPublishEvent(config.OpThreadReply, serverId, userId, 0, body); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,168 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+	"yoyichat/auth"
+	"yoyichat/config"
+	"yoyichat/tools"
+)
+
+// jwt 模式：访问令牌由 auth 包签发和验签，刷新令牌存在 redis 里，用一次就换新的
+// 每个设备同时只有一个有效的刷新令牌，和会话模式一样记在 GetDeviceSessionKey 哈希里
+
+type authTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    int64
+}
+
+// 登录、注册时按认证模式发令牌，会话模式只有 AccessToken
+func (logic *Logic) createAuthTokens(userId int, userName string, device string) (tokens authTokens, err error) {
+	if !auth.Enabled() {
+		tokens.AccessToken, err = logic.createSession(userId, userName, device)
+		return
+	}
+	return logic.issueTokens(userId, userName, device)
+}
+
+// 签发一对新令牌，同一设备之前的令牌一起作废
+func (logic *Logic) issueTokens(userId int, userName string, device string) (tokens authTokens, err error) {
+	if device == "" {
+		device = tools.GetRandomToken(8)
+	}
+	deviceSessionKey := tools.GetDeviceSessionKey(userId)
+	if oldToken, _ := RedisSessClient.HGet(deviceSessionKey, device).Result(); oldToken != "" {
+		logic.dropRefreshToken(oldToken)
+	}
+	accessToken, claims, err := auth.Issue(userId, userName, device)
+	if err != nil {
+		return
+	}
+	refreshToken := tools.GetRandomToken(32)
+	refreshKey := config.RedisRefreshPrefix + refreshToken
+	validTime := auth.RefreshTokenTTL()
+	pipe := RedisSessClient.TxPipeline()
+	pipe.HMSet(refreshKey, map[string]interface{}{
+		"userId":   userId,
+		"userName": userName,
+		"device":   device,
+		"jti":      claims.Id,
+		"exp":      claims.ExpiresAt,
+	})
+	pipe.Expire(refreshKey, validTime)
+	pipe.HSet(deviceSessionKey, device, refreshToken)
+	pipe.Expire(deviceSessionKey, validTime)
+	if _, err = pipe.Exec(); err != nil {
+		return
+	}
+	tokens = authTokens{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresAt: claims.ExpiresAt}
+	return
+}
+
+// 用刷新令牌换一对新令牌，旧的刷新令牌和它对应的访问令牌都作废
+// 已经换过的刷新令牌又被拿来用，说明可能泄露了，这个设备的令牌全部作废，需要重新登录
+func (logic *Logic) rotateTokens(refreshToken string) (tokens authTokens, err error) {
+	refreshKey := config.RedisRefreshPrefix + refreshToken
+	data, err := RedisSessClient.HGetAll(refreshKey).Result()
+	if err != nil {
+		return
+	}
+	if len(data) == 0 {
+		if owner, _ := RedisSessClient.Get(config.RedisRefreshUsedPrefix + refreshToken).Result(); owner != "" {
+			logic.revokeDeviceTokens(owner)
+			return tokens, errors.New("refresh token reused, please login again")
+		}
+		return tokens, errors.New("invalid refresh token")
+	}
+	// 同一个刷新令牌并发换令牌时只有删成功的那个能继续
+	deleted, err := RedisSessClient.Del(refreshKey).Result()
+	if err != nil {
+		return
+	}
+	if deleted == 0 {
+		return tokens, errors.New("invalid refresh token")
+	}
+	userId, _ := strconv.Atoi(data["userId"])
+	owner := fmt.Sprintf("%d:%s", userId, data["device"])
+	if err = RedisSessClient.Set(config.RedisRefreshUsedPrefix+refreshToken, owner, auth.RefreshTokenTTL()).Err(); err != nil {
+		return
+	}
+	logic.revokeAccessToken(data)
+	return logic.issueTokens(userId, data["userName"], data["device"])
+}
+
+// 退出登录：吊销这个访问令牌，删掉设备的刷新令牌
+func (logic *Logic) revokeTokens(accessToken string) (err error) {
+	claims, err := auth.Parse(accessToken)
+	if err != nil {
+		return
+	}
+	if err = auth.Revoke(claims); err != nil {
+		return
+	}
+	deviceSessionKey := tools.GetDeviceSessionKey(claims.UserId)
+	if refreshToken, _ := RedisSessClient.HGet(deviceSessionKey, claims.Device).Result(); refreshToken != "" {
+		logic.dropRefreshToken(refreshToken)
+	}
+	return RedisSessClient.HDel(deviceSessionKey, claims.Device).Err()
+}
+
+// owner 是 "userId:device"
+func (logic *Logic) revokeDeviceTokens(owner string) {
+	parts := strings.SplitN(owner, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	userId, _ := strconv.Atoi(parts[0])
+	deviceSessionKey := tools.GetDeviceSessionKey(userId)
+	if refreshToken, _ := RedisSessClient.HGet(deviceSessionKey, parts[1]).Result(); refreshToken != "" {
+		logic.dropRefreshToken(refreshToken)
+	}
+	if err := RedisSessClient.HDel(deviceSessionKey, parts[1]).Err(); err != nil {
+		logrus.Warnf("revoke device tokens of %s err:%s", owner, err.Error())
+	}
+}
+
+// 删掉刷新令牌，同时吊销和它一起签发的访问令牌
+func (logic *Logic) dropRefreshToken(refreshToken string) {
+	refreshKey := config.RedisRefreshPrefix + refreshToken
+	data, err := RedisSessClient.HGetAll(refreshKey).Result()
+	if err != nil || len(data) == 0 {
+		return
+	}
+	if err = RedisSessClient.Del(refreshKey).Err(); err != nil {
+		logrus.Warnf("drop refresh token err:%s", err.Error())
+		return
+	}
+	logic.revokeAccessToken(data)
+}
+
+func (logic *Logic) revokeAccessToken(refreshData map[string]string) {
+	exp, _ := strconv.ParseInt(refreshData["exp"], 10, 64)
+	if err := auth.Revoke(&auth.Claims{Id: refreshData["jti"], ExpiresAt: exp}); err != nil {
+		logrus.Warnf("revoke access token err:%s", err.Error())
+	}
+}
+
+// 用令牌查用户：会话模式查 redis 里的会话，jwt 模式本地验签
+// 令牌无效时 userId 为0，只有查 redis 出错时返回错误
+func (logic *Logic) getAuthUser(token string) (userId int, userName string, device string, err error) {
+	if auth.Enabled() {
+		claims, parseErr := auth.Parse(token)
+		if parseErr != nil {
+			logrus.Infof("parse access token fail:%s", parseErr.Error())
+			return
+		}
+		return claims.UserId, claims.UserName, claims.Device, nil
+	}
+	userDataMap, err := RedisSessClient.HGetAll(tools.GetSessionName(token)).Result()
+	if err != nil || len(userDataMap) == 0 {
+		return
+	}
+	userId, _ = strconv.Atoi(userDataMap["userId"])
+	return userId, userDataMap["userName"], userDataMap["device"], nil
+}
@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"strings"
+	"testing"
+	"yoyichat/auth"
+	"yoyichat/config"
+)
+
+// jwt 模式加一个内存 redis，测试结束后还原
+func setupTokenTest(t *testing.T) {
+	t.Helper()
+	oldAuth, oldClient := config.Conf.Common.CommonAuth, RedisSessClient
+	config.Conf.Common.CommonAuth = config.CommonAuth{
+		Mode:           config.AuthModeJwt,
+		AccessTokenTTL: 60,
+		SigningKid:     "k1",
+		SigningKeys:    map[string]string{"k1": "test-secret-0123456789abcdefghijklmnop"},
+	}
+	mr := miniredis.RunT(t)
+	RedisSessClient = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		RedisSessClient.Close()
+		config.Conf.Common.CommonAuth, RedisSessClient = oldAuth, oldClient
+	})
+	if err := auth.Init(RedisSessClient); err != nil {
+		t.Fatalf("auth init err: %s", err)
+	}
+}
+
+func TestRotateTokens(t *testing.T) {
+	setupTokenTest(t)
+	logic := new(Logic)
+	first, err := logic.issueTokens(1, "alice", "pc")
+	if err != nil {
+		t.Fatalf("issueTokens err: %s", err)
+	}
+	second, err := logic.rotateTokens(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotateTokens err: %s", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatalf("rotateTokens should issue new tokens")
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"old access token revoked", first.AccessToken, auth.ErrTokenRevoked},
+		{"new access token valid", second.AccessToken, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.Parse(tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Parse err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRotateTokensReuse(t *testing.T) {
+	setupTokenTest(t)
+	logic := new(Logic)
+	first, err := logic.issueTokens(1, "alice", "pc")
+	if err != nil {
+		t.Fatalf("issueTokens err: %s", err)
+	}
+	other, err := logic.issueTokens(1, "alice", "phone")
+	if err != nil {
+		t.Fatalf("issueTokens err: %s", err)
+	}
+	second, err := logic.rotateTokens(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotateTokens err: %s", err)
+	}
+
+	// 换过的刷新令牌又被拿来用，这个设备的令牌全部作废
+	if _, err = logic.rotateTokens(first.RefreshToken); err == nil || !strings.Contains(err.Error(), "reused") {
+		t.Fatalf("reuse rotated refresh token err = %v, want reuse detected", err)
+	}
+	tests := []struct {
+		name         string
+		refreshToken string
+		accessToken  string
+		wantRotate   bool
+		wantParseErr error
+	}{
+		{"rotated tokens of the device revoked", second.RefreshToken, second.AccessToken, false, auth.ErrTokenRevoked},
+		{"other device not affected", other.RefreshToken, other.AccessToken, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.Parse(tt.accessToken); !errors.Is(err, tt.wantParseErr) {
+				t.Errorf("Parse err = %v, want %v", err, tt.wantParseErr)
+			}
+			if _, err := logic.rotateTokens(tt.refreshToken); (err == nil) != tt.wantRotate {
+				t.Errorf("rotateTokens err = %v, want success %v", err, tt.wantRotate)
+			}
+		})
+	}
+}
+
+func TestRotateTokensInvalid(t *testing.T) {
+	setupTokenTest(t)
+	logic := new(Logic)
+	tokens, err := logic.issueTokens(1, "alice", "pc")
+	if err != nil {
+		t.Fatalf("issueTokens err: %s", err)
+	}
+	tests := []struct {
+		name         string
+		refreshToken string
+	}{
+		{"unknown", "not-a-refresh-token"},
+		{"empty", ""},
+		{"access token as refresh token", tokens.AccessToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := logic.rotateTokens(tt.refreshToken)
+			if err == nil || err.Error() != "invalid refresh token" {
+				t.Errorf("rotateTokens err = %v, want invalid refresh token", err)
+			}
+		})
+	}
+	// 无效的令牌不会影响正常的刷新令牌
+	if _, err = logic.rotateTokens(tokens.RefreshToken); err != nil {
+		t.Errorf("rotateTokens err: %s", err)
+	}
+}
@@ -0,0 +1,74 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"yoyichat/config"
+	"yoyichat/logic/dao"
+	"yoyichat/pb/logic_pb"
+)
+
+// 输入状态是临时事件：不落库、不进离线收件箱，推不到就算了
+// 开始输入在 TypingThrottle 内只推一次，停止输入总是推，顺便清掉节流
+
+func (logic *Logic) getTypingKey(userId int, req *logic_pb.TypingRequest) string {
+	if req.RoomId > 0 {
+		return fmt.Sprintf("%s%d_room_%d", config.RedisTypingPrefix, userId, req.RoomId)
+	}
+	return fmt.Sprintf("%s%d_user_%d", config.RedisTypingPrefix, userId, req.ToUserId)
+}
+
+// 节流，返回这次是否需要推送
+func (logic *Logic) throttleTyping(req *logic_pb.TypingRequest) bool {
+	typingKey := logic.getTypingKey(int(req.UserId), req)
+	if !req.Typing {
+		RedisClient.Del(typingKey)
+		return true
+	}
+	ok, err := RedisClient.SetNX(typingKey, 1, config.TypingThrottle).Result()
+	return err == nil && ok
+}
+
+// 检查能不能在这个会话里输入：单聊不能被拉黑，群聊要是成员且没被禁言
+func (logic *Logic) checkTyping(req *logic_pb.TypingRequest) error {
+	if req.RoomId > 0 {
+		switch new(dao.RoomMember).GetRole(int(req.RoomId), int(req.UserId)) {
+		case "", config.RoomRoleMuted:
+			return errors.New("can not type in this room")
+		}
+		return nil
+	}
+	if req.ToUserId <= 0 {
+		return errors.New("to user id or room id empty")
+	}
+	if new(dao.Contact).IsBlocked(int(req.ToUserId), int(req.UserId)) {
+		return errors.New("you have been blocked by this user")
+	}
+	return nil
+}
+
+// 群聊广播到房间，单聊推给对方所有在线设备
+func (logic *Logic) pushTyping(req *logic_pb.TypingRequest) (err error) {
+	body, err := proto.Marshal(&logic_pb.TypingMsg{
+		Op:       config.OpTyping,
+		UserId:   req.UserId,
+		UserName: new(dao.User).GetUserNameByUserId(int(req.UserId)),
+		ToUserId: req.ToUserId,
+		RoomId:   req.RoomId,
+		Typing:   req.Typing,
+		ExpireMs: config.TypingExpire.Milliseconds(),
+	})
+	if err != nil {
+		return
+	}
+	if req.RoomId > 0 {
+		return logic.RedisPublishEvent(config.OpTyping, "", 0, int(req.RoomId), body)
+	}
+	for _, serverId := range logic.getUserServerIds(int(req.ToUserId)) {
+		if err = logic.RedisPublishEvent(config.OpTyping, serverId, int(req.ToUserId), 0, body); err != nil {
+			return
+		}
+	}
+	return
+}
@@ -14,8 +14,10 @@ import (
 )
 
 func main() {
-	var module string
+	var module, action, id string
 	flag.StringVar(&module, "module", "", "assign run module")
+	flag.StringVar(&action, "action", "list", "dead_letter module action: list or replay")
+	flag.StringVar(&id, "id", "", "dead letter id to replay, empty means all")
 	flag.Parse()
 	fmt.Println(fmt.Sprintf("start run %s module", module))
 	switch module {
@@ -27,16 +29,22 @@ func main() {
 		connect.New().RunTcp()
 	case "task":
 		task.New().Run()
+	case "logic_task": // logic和task跑在同一个进程，可以配合 memory 队列使用
+		logic.New().Run()
+		task.New().Run()
 	case "api":
 		api.New().Run()
 	case "client": // 开启终端客户端
 		client.New().Run()
+	case "dead_letter": // 查看、重放死信，执行完直接退出
+		task.New().RunDeadLetterAdmin(action, id)
+		return
 	default:
 		fmt.Println("exiting,module param error!")
 		return
 	}
 	fmt.Println(fmt.Sprintf("run %s module done!", module))
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	<-quit
 	fmt.Println("Server exiting")
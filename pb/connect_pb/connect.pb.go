@@ -166,8 +166,9 @@ func (x *PushMsgRequest) GetMsg() *Msg {
 //	}
 type PushRoomMsgRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	RoomId        int32                  `protobuf:"varint,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"` // 目标房间ID
-	Msg           *Msg                   `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                      // 要推送的消息
+	RoomId        int32                  `protobuf:"varint,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                            // 目标房间ID
+	Msg           *Msg                   `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                                                 // 要推送的消息
+	EvictUserIds  []int32                `protobuf:"varint,3,rep,packed,name=evict_user_ids,json=evictUserIds,proto3" json:"evict_user_ids,omitempty"` // 推送后移出房间的用户 (被踢、被封禁)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -216,6 +217,13 @@ func (x *PushRoomMsgRequest) GetMsg() *Msg {
 	return nil
 }
 
+func (x *PushRoomMsgRequest) GetEvictUserIds() []int32 {
+	if x != nil {
+		return x.EvictUserIds
+	}
+	return nil
+}
+
 // PushRoomCountRequest 更新房间用户计数请求
 // 对应源结构体:
 //
@@ -288,10 +296,11 @@ const file_connect_proto_rawDesc = "" +
 	"\x04body\x18\x04 \x01(\fR\x04body\"L\n" +
 	"\x0ePushMsgRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12!\n" +
-	"\x03msg\x18\x02 \x01(\v2\x0f.connect_pb.MsgR\x03msg\"P\n" +
+	"\x03msg\x18\x02 \x01(\v2\x0f.connect_pb.MsgR\x03msg\"v\n" +
 	"\x12PushRoomMsgRequest\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\x05R\x06roomId\x12!\n" +
-	"\x03msg\x18\x02 \x01(\v2\x0f.connect_pb.MsgR\x03msg\"E\n" +
+	"\x03msg\x18\x02 \x01(\v2\x0f.connect_pb.MsgR\x03msg\x12$\n" +
+	"\x0eevict_user_ids\x18\x03 \x03(\x05R\fevictUserIds\"E\n" +
 	"\x14PushRoomCountRequest\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\x05R\x06roomId\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x05R\x05countB\x18Z\x16yoyichat/pb/connect_pbb\x06proto3"
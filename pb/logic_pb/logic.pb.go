@@ -26,6 +26,7 @@ type LoginRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`         // 用户名
 	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"` // 密码
+	Device        string                 `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`     // 设备标识，同一设备重复登录会顶掉旧令牌，不传时每次登录都算新设备
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -74,11 +75,20 @@ func (x *LoginRequest) GetPassword() string {
 	return ""
 }
 
+func (x *LoginRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
 // LoginResponse 登录响应
 type LoginResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                           // 状态码
-	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"` // 认证令牌
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                    // 状态码
+	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`          // 认证令牌，jwt 模式下是短期的访问令牌
+	RefreshToken  string                 `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"` // 刷新令牌，只有 jwt 模式下有
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`         // 访问令牌过期时间，秒级时间戳，只有 jwt 模式下有
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -127,11 +137,26 @@ func (x *LoginResponse) GetAuthToken() string {
 	return ""
 }
 
+func (x *LoginResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
 // RegisterRequest 注册请求
 type RegisterRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`         // 用户名
 	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"` // 密码
+	Device        string                 `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`     // 设备标识
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -180,11 +205,20 @@ func (x *RegisterRequest) GetPassword() string {
 	return ""
 }
 
+func (x *RegisterRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
 // RegisterReply 注册响应
 type RegisterReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                           // 状态码
-	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"` // 认证令牌
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                    // 状态码
+	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`          // 认证令牌
+	RefreshToken  string                 `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"` // 刷新令牌
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`         // 访问令牌过期时间
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -233,6 +267,134 @@ func (x *RegisterReply) GetAuthToken() string {
 	return ""
 }
 
+func (x *RegisterReply) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *RegisterReply) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+// RefreshTokenRequest 用刷新令牌换一对新令牌，旧的刷新令牌随即作废
+type RefreshTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTokenRequest) Reset() {
+	*x = RefreshTokenRequest{}
+	mi := &file_logic_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTokenRequest) ProtoMessage() {}
+
+func (x *RefreshTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTokenRequest.ProtoReflect.Descriptor instead.
+func (*RefreshTokenRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RefreshTokenRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+// RefreshTokenReply 刷新令牌响应
+type RefreshTokenReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	AuthToken     string                 `protobuf:"bytes,2,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`
+	RefreshToken  string                 `protobuf:"bytes,3,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTokenReply) Reset() {
+	*x = RefreshTokenReply{}
+	mi := &file_logic_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTokenReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTokenReply) ProtoMessage() {}
+
+func (x *RefreshTokenReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTokenReply.ProtoReflect.Descriptor instead.
+func (*RefreshTokenReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RefreshTokenReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *RefreshTokenReply) GetAuthToken() string {
+	if x != nil {
+		return x.AuthToken
+	}
+	return ""
+}
+
+func (x *RefreshTokenReply) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *RefreshTokenReply) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
 // LogoutRequest 登出请求
 type LogoutRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -243,7 +405,7 @@ type LogoutRequest struct {
 
 func (x *LogoutRequest) Reset() {
 	*x = LogoutRequest{}
-	mi := &file_logic_proto_msgTypes[4]
+	mi := &file_logic_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -255,7 +417,7 @@ func (x *LogoutRequest) String() string {
 func (*LogoutRequest) ProtoMessage() {}
 
 func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[4]
+	mi := &file_logic_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -268,7 +430,7 @@ func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogoutRequest.ProtoReflect.Descriptor instead.
 func (*LogoutRequest) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{4}
+	return file_logic_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *LogoutRequest) GetAuthToken() string {
@@ -288,7 +450,7 @@ type LogoutResponse struct {
 
 func (x *LogoutResponse) Reset() {
 	*x = LogoutResponse{}
-	mi := &file_logic_proto_msgTypes[5]
+	mi := &file_logic_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -300,7 +462,7 @@ func (x *LogoutResponse) String() string {
 func (*LogoutResponse) ProtoMessage() {}
 
 func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[5]
+	mi := &file_logic_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -313,7 +475,7 @@ func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogoutResponse.ProtoReflect.Descriptor instead.
 func (*LogoutResponse) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{5}
+	return file_logic_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *LogoutResponse) GetCode() int32 {
@@ -333,7 +495,7 @@ type CheckAuthRequest struct {
 
 func (x *CheckAuthRequest) Reset() {
 	*x = CheckAuthRequest{}
-	mi := &file_logic_proto_msgTypes[6]
+	mi := &file_logic_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -345,7 +507,7 @@ func (x *CheckAuthRequest) String() string {
 func (*CheckAuthRequest) ProtoMessage() {}
 
 func (x *CheckAuthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[6]
+	mi := &file_logic_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -358,7 +520,7 @@ func (x *CheckAuthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckAuthRequest.ProtoReflect.Descriptor instead.
 func (*CheckAuthRequest) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{6}
+	return file_logic_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CheckAuthRequest) GetAuthToken() string {
@@ -374,13 +536,14 @@ type CheckAuthResponse struct {
 	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                        // 状态码
 	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`      // 用户ID
 	UserName      string                 `protobuf:"bytes,3,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"` // 用户名
+	Device        string                 `protobuf:"bytes,4,opt,name=device,proto3" json:"device,omitempty"`                     // 令牌所属设备
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CheckAuthResponse) Reset() {
 	*x = CheckAuthResponse{}
-	mi := &file_logic_proto_msgTypes[7]
+	mi := &file_logic_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -392,7 +555,7 @@ func (x *CheckAuthResponse) String() string {
 func (*CheckAuthResponse) ProtoMessage() {}
 
 func (x *CheckAuthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[7]
+	mi := &file_logic_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -405,7 +568,7 @@ func (x *CheckAuthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckAuthResponse.ProtoReflect.Descriptor instead.
 func (*CheckAuthResponse) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{7}
+	return file_logic_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CheckAuthResponse) GetCode() int32 {
@@ -429,6 +592,13 @@ func (x *CheckAuthResponse) GetUserName() string {
 	return ""
 }
 
+func (x *CheckAuthResponse) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
 // GetUserInfoRequest 获取用户信息请求
 type GetUserInfoRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -439,7 +609,7 @@ type GetUserInfoRequest struct {
 
 func (x *GetUserInfoRequest) Reset() {
 	*x = GetUserInfoRequest{}
-	mi := &file_logic_proto_msgTypes[8]
+	mi := &file_logic_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -451,7 +621,7 @@ func (x *GetUserInfoRequest) String() string {
 func (*GetUserInfoRequest) ProtoMessage() {}
 
 func (x *GetUserInfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[8]
+	mi := &file_logic_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -464,7 +634,7 @@ func (x *GetUserInfoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserInfoRequest.ProtoReflect.Descriptor instead.
 func (*GetUserInfoRequest) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{8}
+	return file_logic_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *GetUserInfoRequest) GetUserId() int32 {
@@ -486,7 +656,7 @@ type GetUserInfoResponse struct {
 
 func (x *GetUserInfoResponse) Reset() {
 	*x = GetUserInfoResponse{}
-	mi := &file_logic_proto_msgTypes[9]
+	mi := &file_logic_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -498,7 +668,7 @@ func (x *GetUserInfoResponse) String() string {
 func (*GetUserInfoResponse) ProtoMessage() {}
 
 func (x *GetUserInfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[9]
+	mi := &file_logic_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -511,7 +681,7 @@ func (x *GetUserInfoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserInfoResponse.ProtoReflect.Descriptor instead.
 func (*GetUserInfoResponse) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{9}
+	return file_logic_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *GetUserInfoResponse) GetCode() int32 {
@@ -541,13 +711,14 @@ type ConnectRequest struct {
 	AuthToken     string                 `protobuf:"bytes,1,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"` // 认证令牌
 	RoomId        int32                  `protobuf:"varint,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`         // 房间ID
 	ServerId      string                 `protobuf:"bytes,3,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`    // 服务器ID
+	Ack           bool                   `protobuf:"varint,4,opt,name=ack,proto3" json:"ack,omitempty"`                             // 客户端会回送达确认，不带的连接不做确认跟踪和重传
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ConnectRequest) Reset() {
 	*x = ConnectRequest{}
-	mi := &file_logic_proto_msgTypes[10]
+	mi := &file_logic_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -559,7 +730,7 @@ func (x *ConnectRequest) String() string {
 func (*ConnectRequest) ProtoMessage() {}
 
 func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[10]
+	mi := &file_logic_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -572,7 +743,7 @@ func (x *ConnectRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectRequest.ProtoReflect.Descriptor instead.
 func (*ConnectRequest) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{10}
+	return file_logic_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ConnectRequest) GetAuthToken() string {
@@ -596,17 +767,26 @@ func (x *ConnectRequest) GetServerId() string {
 	return ""
 }
 
+func (x *ConnectRequest) GetAck() bool {
+	if x != nil {
+		return x.Ack
+	}
+	return false
+}
+
 // ConnectReply 连接响应
 type ConnectReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 用户ID
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`               // 用户ID
+	OfflineMsgs   [][]byte               `protobuf:"bytes,2,rep,name=offline_msgs,json=offlineMsgs,proto3" json:"offline_msgs,omitempty"` // 已废弃，connect层在Channel入桶后用 Online 取离线消息
+	Device        string                 `protobuf:"bytes,3,opt,name=device,proto3" json:"device,omitempty"`                              // 令牌所属设备，connect层按 用户+设备 管理连接
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ConnectReply) Reset() {
 	*x = ConnectReply{}
-	mi := &file_logic_proto_msgTypes[11]
+	mi := &file_logic_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -618,7 +798,7 @@ func (x *ConnectReply) String() string {
 func (*ConnectReply) ProtoMessage() {}
 
 func (x *ConnectReply) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[11]
+	mi := &file_logic_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -631,7 +811,7 @@ func (x *ConnectReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectReply.ProtoReflect.Descriptor instead.
 func (*ConnectReply) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{11}
+	return file_logic_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ConnectReply) GetUserId() int32 {
@@ -641,18 +821,37 @@ func (x *ConnectReply) GetUserId() int32 {
 	return 0
 }
 
+func (x *ConnectReply) GetOfflineMsgs() [][]byte {
+	if x != nil {
+		return x.OfflineMsgs
+	}
+	return nil
+}
+
+func (x *ConnectReply) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
 // DisConnectRequest 断开连接请求
 type DisConnectRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	RoomId        int32                  `protobuf:"varint,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"` // 房间ID
-	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 用户ID
+	RoomId        int32                  `protobuf:"varint,1,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`               // 房间ID
+	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`               // 用户ID
+	UnackedMsgs   [][]byte               `protobuf:"bytes,3,rep,name=unacked_msgs,json=unackedMsgs,proto3" json:"unacked_msgs,omitempty"` // 断开时还没被客户端确认的单聊消息，转存离线
+	RoomIds       []int32                `protobuf:"varint,4,rep,packed,name=room_ids,json=roomIds,proto3" json:"room_ids,omitempty"`     // 连接订阅的所有房间
+	Device        string                 `protobuf:"bytes,5,opt,name=device,proto3" json:"device,omitempty"`                              // 断开的设备
+	ServerId      string                 `protobuf:"bytes,6,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`          // 断开的connect层
+	Replaced      bool                   `protobuf:"varint,7,opt,name=replaced,proto3" json:"replaced,omitempty"`                         // 这个连接已经被同一设备的新连接替换，设备映射和在线状态属于新连接，不要删
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *DisConnectRequest) Reset() {
 	*x = DisConnectRequest{}
-	mi := &file_logic_proto_msgTypes[12]
+	mi := &file_logic_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -664,7 +863,7 @@ func (x *DisConnectRequest) String() string {
 func (*DisConnectRequest) ProtoMessage() {}
 
 func (x *DisConnectRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[12]
+	mi := &file_logic_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -677,7 +876,7 @@ func (x *DisConnectRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DisConnectRequest.ProtoReflect.Descriptor instead.
 func (*DisConnectRequest) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{12}
+	return file_logic_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *DisConnectRequest) GetRoomId() int32 {
@@ -694,6 +893,41 @@ func (x *DisConnectRequest) GetUserId() int32 {
 	return 0
 }
 
+func (x *DisConnectRequest) GetUnackedMsgs() [][]byte {
+	if x != nil {
+		return x.UnackedMsgs
+	}
+	return nil
+}
+
+func (x *DisConnectRequest) GetRoomIds() []int32 {
+	if x != nil {
+		return x.RoomIds
+	}
+	return nil
+}
+
+func (x *DisConnectRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *DisConnectRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *DisConnectRequest) GetReplaced() bool {
+	if x != nil {
+		return x.Replaced
+	}
+	return false
+}
+
 // DisConnectReply 断开连接响应
 type DisConnectReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -704,7 +938,7 @@ type DisConnectReply struct {
 
 func (x *DisConnectReply) Reset() {
 	*x = DisConnectReply{}
-	mi := &file_logic_proto_msgTypes[13]
+	mi := &file_logic_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -716,7 +950,7 @@ func (x *DisConnectReply) String() string {
 func (*DisConnectReply) ProtoMessage() {}
 
 func (x *DisConnectReply) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[13]
+	mi := &file_logic_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -729,7 +963,7 @@ func (x *DisConnectReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DisConnectReply.ProtoReflect.Descriptor instead.
 func (*DisConnectReply) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{13}
+	return file_logic_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *DisConnectReply) GetHas() bool {
@@ -741,23 +975,38 @@ func (x *DisConnectReply) GetHas() bool {
 
 // SendMsg 通用消息结构
 type SendMsg struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                      // 状态码
-	Msg           string                 `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                                         // 消息内容
-	FromUserId    int32                  `protobuf:"varint,3,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`      // 发送方用户ID
-	FromUserName  string                 `protobuf:"bytes,4,opt,name=from_user_name,json=fromUserName,proto3" json:"from_user_name,omitempty"` // 发送方用户名
-	ToUserId      int32                  `protobuf:"varint,5,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`            // 接收方用户ID
-	ToUserName    string                 `protobuf:"bytes,6,opt,name=to_user_name,json=toUserName,proto3" json:"to_user_name,omitempty"`       // 接收方用户名
-	RoomId        int32                  `protobuf:"varint,7,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                    // 房间ID
-	Op            int32                  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`                                          // 操作类型
-	CreateTime    string                 `protobuf:"bytes,9,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`         // 创建时间
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Code           int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                                     // 状态码
+	Msg            string                 `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                                                        // 消息内容
+	FromUserId     int32                  `protobuf:"varint,3,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`                     // 发送方用户ID
+	FromUserName   string                 `protobuf:"bytes,4,opt,name=from_user_name,json=fromUserName,proto3" json:"from_user_name,omitempty"`                // 发送方用户名
+	ToUserId       int32                  `protobuf:"varint,5,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`                           // 接收方用户ID
+	ToUserName     string                 `protobuf:"bytes,6,opt,name=to_user_name,json=toUserName,proto3" json:"to_user_name,omitempty"`                      // 接收方用户名
+	RoomId         int32                  `protobuf:"varint,7,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                                   // 房间ID
+	Op             int32                  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`                                                         // 操作类型
+	CreateTime     string                 `protobuf:"bytes,9,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`                        // 创建时间
+	MsgId          int64                  `protobuf:"varint,10,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`                                     // 消息ID (持久化后生成)
+	ConversationId string                 `protobuf:"bytes,11,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"`           // 会话ID
+	Seq            int64                  `protobuf:"varint,12,opt,name=seq,proto3" json:"seq,omitempty"`                                                      // 会话内递增序号
+	Timestamp      int64                  `protobuf:"varint,13,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                          // 服务端时间戳 (毫秒)
+	Edited         bool                   `protobuf:"varint,14,opt,name=edited,proto3" json:"edited,omitempty"`                                                // 是否被编辑过
+	Deleted        bool                   `protobuf:"varint,15,opt,name=deleted,proto3" json:"deleted,omitempty"`                                              // 是否已删除，删除后内容为空
+	EditTime       int64                  `protobuf:"varint,16,opt,name=edit_time,json=editTime,proto3" json:"edit_time,omitempty"`                            // 最后一次编辑或删除的时间 (毫秒)
+	Reactions      []*ReactionCount       `protobuf:"bytes,17,rep,name=reactions,proto3" json:"reactions,omitempty"`                                           // 表情回应汇总 (查历史时返回)
+	ReplyTo        int64                  `protobuf:"varint,18,opt,name=reply_to,json=replyTo,proto3" json:"reply_to,omitempty"`                               // 回复的消息ID，不为0时这条消息属于该消息的话题
+	ReplyCount     int32                  `protobuf:"varint,19,opt,name=reply_count,json=replyCount,proto3" json:"reply_count,omitempty"`                      // 话题回复数 (话题根消息才有)
+	LastReplyTime  int64                  `protobuf:"varint,20,opt,name=last_reply_time,json=lastReplyTime,proto3" json:"last_reply_time,omitempty"`           // 话题最后一条回复的时间 (毫秒)
+	MentionUserIds []int32                `protobuf:"varint,21,rep,packed,name=mention_user_ids,json=mentionUserIds,proto3" json:"mention_user_ids,omitempty"` // 被@的用户ID
+	MentionAll     bool                   `protobuf:"varint,22,opt,name=mention_all,json=mentionAll,proto3" json:"mention_all,omitempty"`                      // 是否@了所有人
+	Attachments    []*Attachment          `protobuf:"bytes,23,rep,name=attachments,proto3" json:"attachments,omitempty"`                                       // 附件，发送时只需要填附件ID
+	Payload        *MsgPayload            `protobuf:"bytes,24,opt,name=payload,proto3" json:"payload,omitempty"`                                               // 结构化消息体，msg 字段是它的文本形式，给只认文本的老客户端用
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *SendMsg) Reset() {
 	*x = SendMsg{}
-	mi := &file_logic_proto_msgTypes[14]
+	mi := &file_logic_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -769,7 +1018,7 @@ func (x *SendMsg) String() string {
 func (*SendMsg) ProtoMessage() {}
 
 func (x *SendMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[14]
+	mi := &file_logic_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -782,7 +1031,7 @@ func (x *SendMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendMsg.ProtoReflect.Descriptor instead.
 func (*SendMsg) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{14}
+	return file_logic_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *SendMsg) GetCode() int32 {
@@ -848,38 +1097,3957 @@ func (x *SendMsg) GetCreateTime() string {
 	return ""
 }
 
-// SendTcpMsg TCP专用消息结构
-type SendTcpMsg struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                      // 状态码
-	Msg           string                 `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                                         // 消息内容
-	FromUserId    int32                  `protobuf:"varint,3,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`      // 发送方用户ID
-	FromUserName  string                 `protobuf:"bytes,4,opt,name=from_user_name,json=fromUserName,proto3" json:"from_user_name,omitempty"` // 发送方用户名
-	ToUserId      int32                  `protobuf:"varint,5,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`            // 接收方用户ID
-	ToUserName    string                 `protobuf:"bytes,6,opt,name=to_user_name,json=toUserName,proto3" json:"to_user_name,omitempty"`       // 接收方用户名
-	RoomId        int32                  `protobuf:"varint,7,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                    // 房间ID
-	Op            int32                  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`                                          // 操作类型
-	CreateTime    string                 `protobuf:"bytes,9,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`         // 创建时间
-	AuthToken     string                 `protobuf:"bytes,10,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`           // 认证令牌 (TCP专用)
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *SendMsg) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
 }
 
-func (x *SendTcpMsg) Reset() {
-	*x = SendTcpMsg{}
-	mi := &file_logic_proto_msgTypes[15]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
+func (x *SendMsg) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *SendMsg) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SendMsg) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SendMsg) GetEdited() bool {
+	if x != nil {
+		return x.Edited
+	}
+	return false
+}
+
+func (x *SendMsg) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+func (x *SendMsg) GetEditTime() int64 {
+	if x != nil {
+		return x.EditTime
+	}
+	return 0
+}
+
+func (x *SendMsg) GetReactions() []*ReactionCount {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+func (x *SendMsg) GetReplyTo() int64 {
+	if x != nil {
+		return x.ReplyTo
+	}
+	return 0
+}
+
+func (x *SendMsg) GetReplyCount() int32 {
+	if x != nil {
+		return x.ReplyCount
+	}
+	return 0
+}
+
+func (x *SendMsg) GetLastReplyTime() int64 {
+	if x != nil {
+		return x.LastReplyTime
+	}
+	return 0
+}
+
+func (x *SendMsg) GetMentionUserIds() []int32 {
+	if x != nil {
+		return x.MentionUserIds
+	}
+	return nil
+}
+
+func (x *SendMsg) GetMentionAll() bool {
+	if x != nil {
+		return x.MentionAll
+	}
+	return false
+}
+
+func (x *SendMsg) GetAttachments() []*Attachment {
+	if x != nil {
+		return x.Attachments
+	}
+	return nil
+}
+
+func (x *SendMsg) GetPayload() *MsgPayload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+// SendTcpMsg TCP专用消息结构
+type SendTcpMsg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                      // 状态码
+	Msg           string                 `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                                         // 消息内容
+	FromUserId    int32                  `protobuf:"varint,3,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`      // 发送方用户ID
+	FromUserName  string                 `protobuf:"bytes,4,opt,name=from_user_name,json=fromUserName,proto3" json:"from_user_name,omitempty"` // 发送方用户名
+	ToUserId      int32                  `protobuf:"varint,5,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`            // 接收方用户ID
+	ToUserName    string                 `protobuf:"bytes,6,opt,name=to_user_name,json=toUserName,proto3" json:"to_user_name,omitempty"`       // 接收方用户名
+	RoomId        int32                  `protobuf:"varint,7,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                    // 房间ID
+	Op            int32                  `protobuf:"varint,8,opt,name=op,proto3" json:"op,omitempty"`                                          // 操作类型
+	CreateTime    string                 `protobuf:"bytes,9,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`         // 创建时间
+	AuthToken     string                 `protobuf:"bytes,10,opt,name=auth_token,json=authToken,proto3" json:"auth_token,omitempty"`           // 认证令牌 (TCP专用)
+	MsgId         int64                  `protobuf:"varint,11,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`                      // 消息ID (确认送达等操作使用)
+	Seq           int64                  `protobuf:"varint,12,opt,name=seq,proto3" json:"seq,omitempty"`                                       // 会话内序号 (已读回执使用)
+	Typing        bool                   `protobuf:"varint,13,opt,name=typing,proto3" json:"typing,omitempty"`                                 // 开始/停止输入 (输入状态使用)
+	ReplyTo       int64                  `protobuf:"varint,14,opt,name=reply_to,json=replyTo,proto3" json:"reply_to,omitempty"`                // 回复的消息ID (群聊发消息使用)
+	Version       int32                  `protobuf:"varint,15,opt,name=version,proto3" json:"version,omitempty"`                               // 客户端支持的协议版本 (建连时使用)，不传的当作只认文本的老客户端
+	Ack           bool                   `protobuf:"varint,16,opt,name=ack,proto3" json:"ack,omitempty"`                                       // 客户端会回送达确认 (建连时使用)，不传的不做确认跟踪和重传
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendTcpMsg) Reset() {
+	*x = SendTcpMsg{}
+	mi := &file_logic_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendTcpMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendTcpMsg) ProtoMessage() {}
+
+func (x *SendTcpMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendTcpMsg.ProtoReflect.Descriptor instead.
+func (*SendTcpMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SendTcpMsg) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+func (x *SendTcpMsg) GetFromUserId() int32 {
+	if x != nil {
+		return x.FromUserId
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetFromUserName() string {
+	if x != nil {
+		return x.FromUserName
+	}
+	return ""
+}
+
+func (x *SendTcpMsg) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetToUserName() string {
+	if x != nil {
+		return x.ToUserName
+	}
+	return ""
+}
+
+func (x *SendTcpMsg) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetCreateTime() string {
+	if x != nil {
+		return x.CreateTime
+	}
+	return ""
+}
+
+func (x *SendTcpMsg) GetAuthToken() string {
+	if x != nil {
+		return x.AuthToken
+	}
+	return ""
+}
+
+func (x *SendTcpMsg) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetTyping() bool {
+	if x != nil {
+		return x.Typing
+	}
+	return false
+}
+
+func (x *SendTcpMsg) GetReplyTo() int64 {
+	if x != nil {
+		return x.ReplyTo
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *SendTcpMsg) GetAck() bool {
+	if x != nil {
+		return x.Ack
+	}
+	return false
+}
+
+// HistoryRequest 历史消息分页查询请求
+type HistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`             // 查询者用户ID
+	ToUserId      int32                  `protobuf:"varint,2,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`     // 单聊对方用户ID
+	RoomId        int32                  `protobuf:"varint,3,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`             // 房间ID
+	CursorSeq     int64                  `protobuf:"varint,4,opt,name=cursor_seq,json=cursorSeq,proto3" json:"cursor_seq,omitempty"`    // seq游标 (不含)，为0时不按seq过滤
+	CursorTime    int64                  `protobuf:"varint,5,opt,name=cursor_time,json=cursorTime,proto3" json:"cursor_time,omitempty"` // 时间戳游标 毫秒 (不含)，为0时不按时间过滤
+	Direction     int32                  `protobuf:"varint,6,opt,name=direction,proto3" json:"direction,omitempty"`                     // 翻页方向 0:往前翻 1:往后翻
+	Limit         int32                  `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`                             // 每页条数
+	ThreadId      int64                  `protobuf:"varint,8,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`       // 话题根消息ID (查话题时使用)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_logic_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *HistoryRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetCursorSeq() int64 {
+	if x != nil {
+		return x.CursorSeq
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetCursorTime() int64 {
+	if x != nil {
+		return x.CursorTime
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetDirection() int32 {
+	if x != nil {
+		return x.Direction
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetThreadId() int64 {
+	if x != nil {
+		return x.ThreadId
+	}
+	return 0
+}
+
+// HistoryReply 历史消息分页查询响应
+type HistoryReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                      // 状态码
+	Msgs          []*SendMsg             `protobuf:"bytes,2,rep,name=msgs,proto3" json:"msgs,omitempty"`                       // 消息列表，按seq升序
+	HasMore       bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"` // 翻页方向上是否还有更多
+	Root          *SendMsg               `protobuf:"bytes,4,opt,name=root,proto3" json:"root,omitempty"`                       // 话题根消息 (查话题时返回)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryReply) Reset() {
+	*x = HistoryReply{}
+	mi := &file_logic_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryReply) ProtoMessage() {}
+
+func (x *HistoryReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryReply.ProtoReflect.Descriptor instead.
+func (*HistoryReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HistoryReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *HistoryReply) GetMsgs() []*SendMsg {
+	if x != nil {
+		return x.Msgs
+	}
+	return nil
+}
+
+func (x *HistoryReply) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+func (x *HistoryReply) GetRoot() *SendMsg {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+// OnlineRequest Channel入桶之后登记设备在线并取离线收件箱
+// 设备登记之前单聊不会推到这个connect层，登记之后推过来的一定能找到Channel，离线消息不会漏
+type OnlineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`      // 用户ID
+	Device        string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`                     // 设备
+	ServerId      string                 `protobuf:"bytes,3,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"` // 设备所在的connect层
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnlineRequest) Reset() {
+	*x = OnlineRequest{}
+	mi := &file_logic_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnlineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnlineRequest) ProtoMessage() {}
+
+func (x *OnlineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnlineRequest.ProtoReflect.Descriptor instead.
+func (*OnlineRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *OnlineRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *OnlineRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *OnlineRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+// OnlineReply 离线期间积压的单聊消息，推完再由connect层调 TrimOffline 删掉
+type OnlineReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                 // 状态码
+	OfflineMsgs   [][]byte               `protobuf:"bytes,2,rep,name=offline_msgs,json=offlineMsgs,proto3" json:"offline_msgs,omitempty"` // 离线消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OnlineReply) Reset() {
+	*x = OnlineReply{}
+	mi := &file_logic_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OnlineReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OnlineReply) ProtoMessage() {}
+
+func (x *OnlineReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OnlineReply.ProtoReflect.Descriptor instead.
+func (*OnlineReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *OnlineReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *OnlineReply) GetOfflineMsgs() [][]byte {
+	if x != nil {
+		return x.OfflineMsgs
+	}
+	return nil
+}
+
+// OfflineTrimRequest 离线消息已经写给客户端，从收件箱里删掉
+type OfflineTrimRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`        // 用户ID
+	MsgIds        []int64                `protobuf:"varint,2,rep,packed,name=msg_ids,json=msgIds,proto3" json:"msg_ids,omitempty"` // 已经推出去的消息ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OfflineTrimRequest) Reset() {
+	*x = OfflineTrimRequest{}
+	mi := &file_logic_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OfflineTrimRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OfflineTrimRequest) ProtoMessage() {}
+
+func (x *OfflineTrimRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OfflineTrimRequest.ProtoReflect.Descriptor instead.
+func (*OfflineTrimRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *OfflineTrimRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *OfflineTrimRequest) GetMsgIds() []int64 {
+	if x != nil {
+		return x.MsgIds
+	}
+	return nil
+}
+
+// OfflineTrimReply 删除离线消息响应
+type OfflineTrimReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OfflineTrimReply) Reset() {
+	*x = OfflineTrimReply{}
+	mi := &file_logic_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OfflineTrimReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OfflineTrimReply) ProtoMessage() {}
+
+func (x *OfflineTrimReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OfflineTrimReply.ProtoReflect.Descriptor instead.
+func (*OfflineTrimReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *OfflineTrimReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// AckRequest 客户端确认收到消息
+type AckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 确认者 (消息接收方) 用户ID
+	MsgId         int64                  `protobuf:"varint,2,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`    // 消息ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckRequest) Reset() {
+	*x = AckRequest{}
+	mi := &file_logic_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckRequest) ProtoMessage() {}
+
+func (x *AckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckRequest.ProtoReflect.Descriptor instead.
+func (*AckRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *AckRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *AckRequest) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+// AckReply 确认响应
+type AckReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckReply) Reset() {
+	*x = AckReply{}
+	mi := &file_logic_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckReply) ProtoMessage() {}
+
+func (x *AckReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckReply.ProtoReflect.Descriptor instead.
+func (*AckReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AckReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// DeliveryStateMsg 消息投递状态，推给消息发送方
+type DeliveryStateMsg struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Op             int32                  `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`                                              // 操作类型
+	MsgId          int64                  `protobuf:"varint,2,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`                           // 消息ID
+	ConversationId string                 `protobuf:"bytes,3,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"` // 会话ID
+	Seq            int64                  `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`                                            // 会话内序号
+	ToUserId       int32                  `protobuf:"varint,5,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`                // 消息接收方用户ID
+	State          string                 `protobuf:"bytes,6,opt,name=state,proto3" json:"state,omitempty"`                                         // 投递状态 sent / delivered
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeliveryStateMsg) Reset() {
+	*x = DeliveryStateMsg{}
+	mi := &file_logic_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryStateMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryStateMsg) ProtoMessage() {}
+
+func (x *DeliveryStateMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryStateMsg.ProtoReflect.Descriptor instead.
+func (*DeliveryStateMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *DeliveryStateMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *DeliveryStateMsg) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+func (x *DeliveryStateMsg) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *DeliveryStateMsg) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *DeliveryStateMsg) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *DeliveryStateMsg) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+// ReadReceiptRequest 上报会话已读位置
+type ReadReceiptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`         // 上报者用户ID
+	ToUserId      int32                  `protobuf:"varint,2,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"` // 单聊对方用户ID
+	RoomId        int32                  `protobuf:"varint,3,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`         // 房间ID
+	Seq           int64                  `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`                             // 已读到的会话内序号
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadReceiptRequest) Reset() {
+	*x = ReadReceiptRequest{}
+	mi := &file_logic_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadReceiptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadReceiptRequest) ProtoMessage() {}
+
+func (x *ReadReceiptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadReceiptRequest.ProtoReflect.Descriptor instead.
+func (*ReadReceiptRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ReadReceiptRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReadReceiptRequest) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *ReadReceiptRequest) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *ReadReceiptRequest) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// ReadReceiptReply 上报已读位置响应
+type ReadReceiptReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadReceiptReply) Reset() {
+	*x = ReadReceiptReply{}
+	mi := &file_logic_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadReceiptReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadReceiptReply) ProtoMessage() {}
+
+func (x *ReadReceiptReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadReceiptReply.ProtoReflect.Descriptor instead.
+func (*ReadReceiptReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ReadReceiptReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// ReadReceiptMsg 已读回执事件，推给会话中的其他人
+type ReadReceiptMsg struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Op             int32                  `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`                                              // 操作类型
+	ConversationId string                 `protobuf:"bytes,2,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"` // 会话ID
+	UserId         int32                  `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                        // 已读者用户ID
+	UserName       string                 `protobuf:"bytes,4,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`                   // 已读者用户名
+	RoomId         int32                  `protobuf:"varint,5,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                        // 房间ID (群聊)
+	Seq            int64                  `protobuf:"varint,6,opt,name=seq,proto3" json:"seq,omitempty"`                                            // 已读到的会话内序号
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReadReceiptMsg) Reset() {
+	*x = ReadReceiptMsg{}
+	mi := &file_logic_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadReceiptMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadReceiptMsg) ProtoMessage() {}
+
+func (x *ReadReceiptMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadReceiptMsg.ProtoReflect.Descriptor instead.
+func (*ReadReceiptMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ReadReceiptMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *ReadReceiptMsg) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ReadReceiptMsg) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReadReceiptMsg) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *ReadReceiptMsg) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *ReadReceiptMsg) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+// UnreadRequest 未读数查询请求
+type UnreadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 用户ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnreadRequest) Reset() {
+	*x = UnreadRequest{}
+	mi := &file_logic_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnreadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnreadRequest) ProtoMessage() {}
+
+func (x *UnreadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnreadRequest.ProtoReflect.Descriptor instead.
+func (*UnreadRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *UnreadRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// UnreadCount 单个会话的未读数
+type UnreadCount struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ConversationId string                 `protobuf:"bytes,1,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"` // 会话ID
+	RoomId         int32                  `protobuf:"varint,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                        // 房间ID (群聊)
+	ToUserId       int32                  `protobuf:"varint,3,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`                // 对方用户ID (单聊)
+	ReadSeq        int64                  `protobuf:"varint,4,opt,name=read_seq,json=readSeq,proto3" json:"read_seq,omitempty"`                     // 已读到的序号
+	LastSeq        int64                  `protobuf:"varint,5,opt,name=last_seq,json=lastSeq,proto3" json:"last_seq,omitempty"`                     // 会话最新序号
+	Unread         int64                  `protobuf:"varint,6,opt,name=unread,proto3" json:"unread,omitempty"`                                      // 未读条数 (不含自己发的)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UnreadCount) Reset() {
+	*x = UnreadCount{}
+	mi := &file_logic_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnreadCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnreadCount) ProtoMessage() {}
+
+func (x *UnreadCount) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnreadCount.ProtoReflect.Descriptor instead.
+func (*UnreadCount) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *UnreadCount) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *UnreadCount) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *UnreadCount) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *UnreadCount) GetReadSeq() int64 {
+	if x != nil {
+		return x.ReadSeq
+	}
+	return 0
+}
+
+func (x *UnreadCount) GetLastSeq() int64 {
+	if x != nil {
+		return x.LastSeq
+	}
+	return 0
+}
+
+func (x *UnreadCount) GetUnread() int64 {
+	if x != nil {
+		return x.Unread
+	}
+	return 0
+}
+
+// UnreadReply 未读数查询响应
+type UnreadReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`    // 状态码
+	Counts        []*UnreadCount         `protobuf:"bytes,2,rep,name=counts,proto3" json:"counts,omitempty"` // 各会话未读数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnreadReply) Reset() {
+	*x = UnreadReply{}
+	mi := &file_logic_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnreadReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnreadReply) ProtoMessage() {}
+
+func (x *UnreadReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnreadReply.ProtoReflect.Descriptor instead.
+func (*UnreadReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *UnreadReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *UnreadReply) GetCounts() []*UnreadCount {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+// ContactRequest 联系人操作请求 (加好友、接受、拒绝、删除、拉黑)
+type ContactRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                          // 操作者用户ID
+	TargetUserId   int32                  `protobuf:"varint,2,opt,name=target_user_id,json=targetUserId,proto3" json:"target_user_id,omitempty"`      // 对方用户ID
+	RequestId      int64                  `protobuf:"varint,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`                 // 好友申请ID (接受/拒绝时使用)
+	Message        string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`                                       // 申请附言
+	TargetUserName string                 `protobuf:"bytes,5,opt,name=target_user_name,json=targetUserName,proto3" json:"target_user_name,omitempty"` // 对方用户名，没有填对方用户ID时按用户名查找
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ContactRequest) Reset() {
+	*x = ContactRequest{}
+	mi := &file_logic_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactRequest) ProtoMessage() {}
+
+func (x *ContactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactRequest.ProtoReflect.Descriptor instead.
+func (*ContactRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ContactRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ContactRequest) GetTargetUserId() int32 {
+	if x != nil {
+		return x.TargetUserId
+	}
+	return 0
+}
+
+func (x *ContactRequest) GetRequestId() int64 {
+	if x != nil {
+		return x.RequestId
+	}
+	return 0
+}
+
+func (x *ContactRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ContactRequest) GetTargetUserName() string {
+	if x != nil {
+		return x.TargetUserName
+	}
+	return ""
+}
+
+// ContactReply 联系人操作响应
+type ContactReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactReply) Reset() {
+	*x = ContactReply{}
+	mi := &file_logic_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactReply) ProtoMessage() {}
+
+func (x *ContactReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactReply.ProtoReflect.Descriptor instead.
+func (*ContactReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ContactReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// Contact 联系人
+type Contact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`      // 联系人用户ID
+	UserName      string                 `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"` // 联系人用户名
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                     // 关系状态 friend / blocked
+	Online        bool                   `protobuf:"varint,4,opt,name=online,proto3" json:"online,omitempty"`                    // 是否在线
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Contact) Reset() {
+	*x = Contact{}
+	mi := &file_logic_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Contact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Contact) ProtoMessage() {}
+
+func (x *Contact) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Contact.ProtoReflect.Descriptor instead.
+func (*Contact) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Contact) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Contact) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *Contact) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Contact) GetOnline() bool {
+	if x != nil {
+		return x.Online
+	}
+	return false
+}
+
+// FriendRequest 好友申请
+type FriendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`                                          // 申请ID
+	FromUserId    int32                  `protobuf:"varint,2,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"`      // 申请人用户ID
+	FromUserName  string                 `protobuf:"bytes,3,opt,name=from_user_name,json=fromUserName,proto3" json:"from_user_name,omitempty"` // 申请人用户名
+	ToUserId      int32                  `protobuf:"varint,4,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"`            // 被申请人用户ID
+	ToUserName    string                 `protobuf:"bytes,5,opt,name=to_user_name,json=toUserName,proto3" json:"to_user_name,omitempty"`       // 被申请人用户名
+	Message       string                 `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`                                 // 申请附言
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`                                   // 申请状态 pending / accepted / declined
+	CreateTime    string                 `protobuf:"bytes,8,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`         // 申请时间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FriendRequest) Reset() {
+	*x = FriendRequest{}
+	mi := &file_logic_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FriendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FriendRequest) ProtoMessage() {}
+
+func (x *FriendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FriendRequest.ProtoReflect.Descriptor instead.
+func (*FriendRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *FriendRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *FriendRequest) GetFromUserId() int32 {
+	if x != nil {
+		return x.FromUserId
+	}
+	return 0
+}
+
+func (x *FriendRequest) GetFromUserName() string {
+	if x != nil {
+		return x.FromUserName
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *FriendRequest) GetToUserName() string {
+	if x != nil {
+		return x.ToUserName
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *FriendRequest) GetCreateTime() string {
+	if x != nil {
+		return x.CreateTime
+	}
+	return ""
+}
+
+// ContactListRequest 联系人列表请求
+type ContactListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 用户ID
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactListRequest) Reset() {
+	*x = ContactListRequest{}
+	mi := &file_logic_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactListRequest) ProtoMessage() {}
+
+func (x *ContactListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactListRequest.ProtoReflect.Descriptor instead.
+func (*ContactListRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ContactListRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+// ContactListReply 联系人列表响应，带上收到和发出的待处理申请
+type ContactListReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`        // 状态码
+	Contacts      []*Contact             `protobuf:"bytes,2,rep,name=contacts,proto3" json:"contacts,omitempty"` // 联系人
+	Requests      []*FriendRequest       `protobuf:"bytes,3,rep,name=requests,proto3" json:"requests,omitempty"` // 待处理的好友申请
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactListReply) Reset() {
+	*x = ContactListReply{}
+	mi := &file_logic_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactListReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactListReply) ProtoMessage() {}
+
+func (x *ContactListReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactListReply.ProtoReflect.Descriptor instead.
+func (*ContactListReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ContactListReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ContactListReply) GetContacts() []*Contact {
+	if x != nil {
+		return x.Contacts
+	}
+	return nil
+}
+
+func (x *ContactListReply) GetRequests() []*FriendRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+// ContactEventMsg 联系人事件，推给对方
+type ContactEventMsg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            int32                  `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`          // 操作类型
+	Event         string                 `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`     // 事件类型 request / accept
+	Request       *FriendRequest         `protobuf:"bytes,3,opt,name=request,proto3" json:"request,omitempty"` // 相关的好友申请
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContactEventMsg) Reset() {
+	*x = ContactEventMsg{}
+	mi := &file_logic_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContactEventMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContactEventMsg) ProtoMessage() {}
+
+func (x *ContactEventMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContactEventMsg.ProtoReflect.Descriptor instead.
+func (*ContactEventMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ContactEventMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *ContactEventMsg) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *ContactEventMsg) GetRequest() *FriendRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+// Room 房间
+type Room struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`                                      // 房间ID
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                                   // 房间名
+	Topic         string                 `protobuf:"bytes,3,opt,name=topic,proto3" json:"topic,omitempty"`                                 // 房间话题
+	OwnerId       int32                  `protobuf:"varint,4,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`             // 房主用户ID
+	OwnerName     string                 `protobuf:"bytes,5,opt,name=owner_name,json=ownerName,proto3" json:"owner_name,omitempty"`        // 房主用户名
+	Visibility    string                 `protobuf:"bytes,6,opt,name=visibility,proto3" json:"visibility,omitempty"`                       // 可见性 public / private
+	MemberCount   int32                  `protobuf:"varint,7,opt,name=member_count,json=memberCount,proto3" json:"member_count,omitempty"` // 成员数
+	CreateTime    string                 `protobuf:"bytes,8,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`     // 创建时间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Room) Reset() {
+	*x = Room{}
+	mi := &file_logic_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Room) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Room) ProtoMessage() {}
+
+func (x *Room) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Room.ProtoReflect.Descriptor instead.
+func (*Room) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *Room) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Room) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Room) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Room) GetOwnerId() int32 {
+	if x != nil {
+		return x.OwnerId
+	}
+	return 0
+}
+
+func (x *Room) GetOwnerName() string {
+	if x != nil {
+		return x.OwnerName
+	}
+	return ""
+}
+
+func (x *Room) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Room) GetMemberCount() int32 {
+	if x != nil {
+		return x.MemberCount
+	}
+	return 0
+}
+
+func (x *Room) GetCreateTime() string {
+	if x != nil {
+		return x.CreateTime
+	}
+	return ""
+}
+
+// RoomRequest 房间操作请求 (创建、加入、离开、搜索)
+type RoomRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                     // 操作者用户ID
+	RoomId        int32                  `protobuf:"varint,2,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                     // 房间ID
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`                                        // 房间名 (创建时使用)
+	Topic         string                 `protobuf:"bytes,4,opt,name=topic,proto3" json:"topic,omitempty"`                                      // 房间话题 (创建时使用)
+	Visibility    string                 `protobuf:"bytes,5,opt,name=visibility,proto3" json:"visibility,omitempty"`                            // 可见性 (创建时使用)
+	Keyword       string                 `protobuf:"bytes,6,opt,name=keyword,proto3" json:"keyword,omitempty"`                                  // 搜索关键字
+	TargetUserId  int32                  `protobuf:"varint,7,opt,name=target_user_id,json=targetUserId,proto3" json:"target_user_id,omitempty"` // 被操作的成员 (任免、踢人、封禁时使用)
+	Role          string                 `protobuf:"bytes,8,opt,name=role,proto3" json:"role,omitempty"`                                        // 要设置的角色
+	TopicSet      bool                   `protobuf:"varint,9,opt,name=topic_set,json=topicSet,proto3" json:"topic_set,omitempty"`               // 修改房间时带了话题字段，这时空话题表示清空
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomRequest) Reset() {
+	*x = RoomRequest{}
+	mi := &file_logic_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomRequest) ProtoMessage() {}
+
+func (x *RoomRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomRequest.ProtoReflect.Descriptor instead.
+func (*RoomRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *RoomRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *RoomRequest) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *RoomRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RoomRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *RoomRequest) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *RoomRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *RoomRequest) GetTargetUserId() int32 {
+	if x != nil {
+		return x.TargetUserId
+	}
+	return 0
+}
+
+func (x *RoomRequest) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *RoomRequest) GetTopicSet() bool {
+	if x != nil {
+		return x.TopicSet
+	}
+	return false
+}
+
+// RoomReply 房间操作响应
+type RoomReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	Room          *Room                  `protobuf:"bytes,2,opt,name=room,proto3" json:"room,omitempty"`  // 房间信息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomReply) Reset() {
+	*x = RoomReply{}
+	mi := &file_logic_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomReply) ProtoMessage() {}
+
+func (x *RoomReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomReply.ProtoReflect.Descriptor instead.
+func (*RoomReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *RoomReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *RoomReply) GetRoom() *Room {
+	if x != nil {
+		return x.Room
+	}
+	return nil
+}
+
+// RoomListReply 房间列表响应
+type RoomListReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`  // 状态码
+	Rooms         []*Room                `protobuf:"bytes,2,rep,name=rooms,proto3" json:"rooms,omitempty"` // 房间列表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoomListReply) Reset() {
+	*x = RoomListReply{}
+	mi := &file_logic_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomListReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomListReply) ProtoMessage() {}
+
+func (x *RoomListReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomListReply.ProtoReflect.Descriptor instead.
+func (*RoomListReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *RoomListReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *RoomListReply) GetRooms() []*Room {
+	if x != nil {
+		return x.Rooms
+	}
+	return nil
+}
+
+// HeartbeatRequest connect层心跳上报，刷新设备在线和最后活跃时间
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`      // 用户ID
+	Device        string                 `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`                     // 设备
+	ServerId      string                 `protobuf:"bytes,3,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"` // connect层
+	Idle          bool                   `protobuf:"varint,4,opt,name=idle,proto3" json:"idle,omitempty"`                        // 客户端一段时间没有操作
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	mi := &file_logic_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *HeartbeatRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *HeartbeatRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetIdle() bool {
+	if x != nil {
+		return x.Idle
+	}
+	return false
+}
+
+// HeartbeatReply 心跳响应
+type HeartbeatReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatReply) Reset() {
+	*x = HeartbeatReply{}
+	mi := &file_logic_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatReply) ProtoMessage() {}
+
+func (x *HeartbeatReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatReply.ProtoReflect.Descriptor instead.
+func (*HeartbeatReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *HeartbeatReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// Presence 用户在线状态
+type Presence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`       // 用户ID
+	UserName      string                 `protobuf:"bytes,2,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`  // 用户名
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`                      // online / away / dnd / offline
+	LastSeen      int64                  `protobuf:"varint,4,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"` // 最后在线时间，毫秒时间戳
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Presence) Reset() {
+	*x = Presence{}
+	mi := &file_logic_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Presence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Presence) ProtoMessage() {}
+
+func (x *Presence) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Presence.ProtoReflect.Descriptor instead.
+func (*Presence) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *Presence) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Presence) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *Presence) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Presence) GetLastSeen() int64 {
+	if x != nil {
+		return x.LastSeen
+	}
+	return 0
+}
+
+// PresenceRequest 设置自己的状态或批量查询
+type PresenceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`           // 操作者用户ID
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                          // 手动设置的状态 (设置时使用)
+	UserIds       []int32                `protobuf:"varint,3,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"` // 要查询的用户 (查询时使用)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceRequest) Reset() {
+	*x = PresenceRequest{}
+	mi := &file_logic_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceRequest) ProtoMessage() {}
+
+func (x *PresenceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceRequest.ProtoReflect.Descriptor instead.
+func (*PresenceRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *PresenceRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *PresenceRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PresenceRequest) GetUserIds() []int32 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+// PresenceReply 在线状态响应
+type PresenceReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`          // 状态码
+	Presences     []*Presence            `protobuf:"bytes,2,rep,name=presences,proto3" json:"presences,omitempty"` // 在线状态列表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceReply) Reset() {
+	*x = PresenceReply{}
+	mi := &file_logic_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceReply) ProtoMessage() {}
+
+func (x *PresenceReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceReply.ProtoReflect.Descriptor instead.
+func (*PresenceReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *PresenceReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *PresenceReply) GetPresences() []*Presence {
+	if x != nil {
+		return x.Presences
+	}
+	return nil
+}
+
+// PresenceMsg 在线状态变化事件，推给联系人和同房间的人
+type PresenceMsg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            int32                  `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`            // 操作类型
+	Presence      *Presence              `protobuf:"bytes,2,opt,name=presence,proto3" json:"presence,omitempty"` // 变化后的状态
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PresenceMsg) Reset() {
+	*x = PresenceMsg{}
+	mi := &file_logic_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PresenceMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PresenceMsg) ProtoMessage() {}
+
+func (x *PresenceMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PresenceMsg.ProtoReflect.Descriptor instead.
+func (*PresenceMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *PresenceMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *PresenceMsg) GetPresence() *Presence {
+	if x != nil {
+		return x.Presence
+	}
+	return nil
+}
+
+// TypingRequest 客户端上报开始/停止输入，单聊带 to_user_id，群聊带 room_id
+type TypingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`         // 输入者用户ID
+	ToUserId      int32                  `protobuf:"varint,2,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"` // 单聊对方用户ID
+	RoomId        int32                  `protobuf:"varint,3,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`         // 房间ID
+	Typing        bool                   `protobuf:"varint,4,opt,name=typing,proto3" json:"typing,omitempty"`                       // true 开始输入，false 停止输入
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypingRequest) Reset() {
+	*x = TypingRequest{}
+	mi := &file_logic_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypingRequest) ProtoMessage() {}
+
+func (x *TypingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypingRequest.ProtoReflect.Descriptor instead.
+func (*TypingRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *TypingRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *TypingRequest) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *TypingRequest) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *TypingRequest) GetTyping() bool {
+	if x != nil {
+		return x.Typing
+	}
+	return false
+}
+
+// TypingReply 输入状态响应
+type TypingReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypingReply) Reset() {
+	*x = TypingReply{}
+	mi := &file_logic_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypingReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypingReply) ProtoMessage() {}
+
+func (x *TypingReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypingReply.ProtoReflect.Descriptor instead.
+func (*TypingReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *TypingReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+// TypingMsg 输入状态事件，不落库也不进离线收件箱
+type TypingMsg struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            int32                  `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`                               // 操作类型
+	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`         // 输入者用户ID
+	UserName      string                 `protobuf:"bytes,3,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`    // 输入者用户名
+	ToUserId      int32                  `protobuf:"varint,4,opt,name=to_user_id,json=toUserId,proto3" json:"to_user_id,omitempty"` // 单聊对方用户ID
+	RoomId        int32                  `protobuf:"varint,5,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`         // 房间ID
+	Typing        bool                   `protobuf:"varint,6,opt,name=typing,proto3" json:"typing,omitempty"`                       // 是否正在输入
+	ExpireMs      int64                  `protobuf:"varint,7,opt,name=expire_ms,json=expireMs,proto3" json:"expire_ms,omitempty"`   // 客户端多久没收到新的输入事件就自动清掉
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TypingMsg) Reset() {
+	*x = TypingMsg{}
+	mi := &file_logic_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TypingMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypingMsg) ProtoMessage() {}
+
+func (x *TypingMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypingMsg.ProtoReflect.Descriptor instead.
+func (*TypingMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *TypingMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *TypingMsg) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *TypingMsg) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *TypingMsg) GetToUserId() int32 {
+	if x != nil {
+		return x.ToUserId
+	}
+	return 0
+}
+
+func (x *TypingMsg) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *TypingMsg) GetTyping() bool {
+	if x != nil {
+		return x.Typing
+	}
+	return false
+}
+
+func (x *TypingMsg) GetExpireMs() int64 {
+	if x != nil {
+		return x.ExpireMs
+	}
+	return 0
+}
+
+// MsgUpdateRequest 编辑或删除消息请求
+type MsgUpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 操作者用户ID
+	MsgId         int64                  `protobuf:"varint,2,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`    // 消息ID
+	Content       string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`              // 新内容 (编辑时使用)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MsgUpdateRequest) Reset() {
+	*x = MsgUpdateRequest{}
+	mi := &file_logic_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MsgUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgUpdateRequest) ProtoMessage() {}
+
+func (x *MsgUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgUpdateRequest.ProtoReflect.Descriptor instead.
+func (*MsgUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *MsgUpdateRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *MsgUpdateRequest) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+func (x *MsgUpdateRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// MsgUpdateReply 编辑或删除消息响应
+type MsgUpdateReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"` // 状态码
+	Msg           *SendMsg               `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`    // 更新后的消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MsgUpdateReply) Reset() {
+	*x = MsgUpdateReply{}
+	mi := &file_logic_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MsgUpdateReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgUpdateReply) ProtoMessage() {}
+
+func (x *MsgUpdateReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgUpdateReply.ProtoReflect.Descriptor instead.
+func (*MsgUpdateReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *MsgUpdateReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *MsgUpdateReply) GetMsg() *SendMsg {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+// ReactionCount 某个表情的回应人数
+type ReactionCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Emoji         string                 `protobuf:"bytes,1,opt,name=emoji,proto3" json:"emoji,omitempty"`      // 表情
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`     // 回应人数
+	Reacted       bool                   `protobuf:"varint,3,opt,name=reacted,proto3" json:"reacted,omitempty"` // 查询者自己是否回应过
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactionCount) Reset() {
+	*x = ReactionCount{}
+	mi := &file_logic_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionCount) ProtoMessage() {}
+
+func (x *ReactionCount) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionCount.ProtoReflect.Descriptor instead.
+func (*ReactionCount) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *ReactionCount) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *ReactionCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ReactionCount) GetReacted() bool {
+	if x != nil {
+		return x.Reacted
+	}
+	return false
+}
+
+// ReactionRequest 添加、取消或查询表情回应
+type ReactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 操作者用户ID
+	MsgId         int64                  `protobuf:"varint,2,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`    // 消息ID
+	Emoji         string                 `protobuf:"bytes,3,opt,name=emoji,proto3" json:"emoji,omitempty"`                  // 表情 (查询时不用传)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactionRequest) Reset() {
+	*x = ReactionRequest{}
+	mi := &file_logic_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionRequest) ProtoMessage() {}
+
+func (x *ReactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionRequest.ProtoReflect.Descriptor instead.
+func (*ReactionRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *ReactionRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReactionRequest) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+func (x *ReactionRequest) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+// ReactionReply 表情回应响应，返回该消息当前的汇总
+type ReactionReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`          // 状态码
+	Reactions     []*ReactionCount       `protobuf:"bytes,2,rep,name=reactions,proto3" json:"reactions,omitempty"` // 各表情的回应人数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReactionReply) Reset() {
+	*x = ReactionReply{}
+	mi := &file_logic_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionReply) ProtoMessage() {}
+
+func (x *ReactionReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionReply.ProtoReflect.Descriptor instead.
+func (*ReactionReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *ReactionReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ReactionReply) GetReactions() []*ReactionCount {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+// ReactionMsg 表情回应事件，推给会话里的人
+type ReactionMsg struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Op             int32                  `protobuf:"varint,1,opt,name=op,proto3" json:"op,omitempty"`                                              // 操作类型
+	MsgId          int64                  `protobuf:"varint,2,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`                           // 消息ID
+	ConversationId string                 `protobuf:"bytes,3,opt,name=conversation_id,json=conversationId,proto3" json:"conversation_id,omitempty"` // 会话ID
+	RoomId         int32                  `protobuf:"varint,4,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`                        // 房间ID (群聊)
+	UserId         int32                  `protobuf:"varint,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`                        // 操作者用户ID
+	UserName       string                 `protobuf:"bytes,6,opt,name=user_name,json=userName,proto3" json:"user_name,omitempty"`                   // 操作者用户名
+	Emoji          string                 `protobuf:"bytes,7,opt,name=emoji,proto3" json:"emoji,omitempty"`                                         // 表情
+	Action         string                 `protobuf:"bytes,8,opt,name=action,proto3" json:"action,omitempty"`                                       // add / remove
+	Reactions      []*ReactionCount       `protobuf:"bytes,9,rep,name=reactions,proto3" json:"reactions,omitempty"`                                 // 变化后的汇总 (reacted 字段无意义)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReactionMsg) Reset() {
+	*x = ReactionMsg{}
+	mi := &file_logic_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReactionMsg) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReactionMsg) ProtoMessage() {}
+
+func (x *ReactionMsg) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReactionMsg.ProtoReflect.Descriptor instead.
+func (*ReactionMsg) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *ReactionMsg) GetOp() int32 {
+	if x != nil {
+		return x.Op
+	}
+	return 0
+}
+
+func (x *ReactionMsg) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+func (x *ReactionMsg) GetConversationId() string {
+	if x != nil {
+		return x.ConversationId
+	}
+	return ""
+}
+
+func (x *ReactionMsg) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *ReactionMsg) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ReactionMsg) GetUserName() string {
+	if x != nil {
+		return x.UserName
+	}
+	return ""
+}
+
+func (x *ReactionMsg) GetEmoji() string {
+	if x != nil {
+		return x.Emoji
+	}
+	return ""
+}
+
+func (x *ReactionMsg) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *ReactionMsg) GetReactions() []*ReactionCount {
+	if x != nil {
+		return x.Reactions
+	}
+	return nil
+}
+
+// ThreadFollowRequest 关注或取消关注话题
+type ThreadFollowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // 用户ID
+	MsgId         int64                  `protobuf:"varint,2,opt,name=msg_id,json=msgId,proto3" json:"msg_id,omitempty"`    // 话题根消息ID，传回复的ID时按其根消息处理
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreadFollowRequest) Reset() {
+	*x = ThreadFollowRequest{}
+	mi := &file_logic_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreadFollowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreadFollowRequest) ProtoMessage() {}
+
+func (x *ThreadFollowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreadFollowRequest.ProtoReflect.Descriptor instead.
+func (*ThreadFollowRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *ThreadFollowRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ThreadFollowRequest) GetMsgId() int64 {
+	if x != nil {
+		return x.MsgId
+	}
+	return 0
+}
+
+// ThreadFollowReply 关注话题响应
+type ThreadFollowReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                         // 状态码
+	ThreadId      int64                  `protobuf:"varint,2,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"` // 话题根消息ID
+	Following     bool                   `protobuf:"varint,3,opt,name=following,proto3" json:"following,omitempty"`               // 操作后是否在关注
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreadFollowReply) Reset() {
+	*x = ThreadFollowReply{}
+	mi := &file_logic_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreadFollowReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreadFollowReply) ProtoMessage() {}
+
+func (x *ThreadFollowReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreadFollowReply.ProtoReflect.Descriptor instead.
+func (*ThreadFollowReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *ThreadFollowReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ThreadFollowReply) GetThreadId() int64 {
+	if x != nil {
+		return x.ThreadId
+	}
+	return 0
+}
+
+func (x *ThreadFollowReply) GetFollowing() bool {
+	if x != nil {
+		return x.Following
+	}
+	return false
+}
+
+// MentionRequest 查询自己被@的消息，按时间倒序翻页
+type MentionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`       // 用户ID
+	CursorId      int64                  `protobuf:"varint,2,opt,name=cursor_id,json=cursorId,proto3" json:"cursor_id,omitempty"` // 上一页最后一条提及记录的ID (不含)，为0时从最新的开始
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`                       // 每页条数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MentionRequest) Reset() {
+	*x = MentionRequest{}
+	mi := &file_logic_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MentionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MentionRequest) ProtoMessage() {}
+
+func (x *MentionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MentionRequest.ProtoReflect.Descriptor instead.
+func (*MentionRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *MentionRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *MentionRequest) GetCursorId() int64 {
+	if x != nil {
+		return x.CursorId
+	}
+	return 0
+}
+
+func (x *MentionRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Mention 一条提及记录
+type Mention struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`   // 提及记录ID，翻页用
+	Msg           *SendMsg               `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`  // 被@的消息
+	All           bool                   `protobuf:"varint,3,opt,name=all,proto3" json:"all,omitempty"` // 是否是 @all
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mention) Reset() {
+	*x = Mention{}
+	mi := &file_logic_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mention) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mention) ProtoMessage() {}
+
+func (x *Mention) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mention.ProtoReflect.Descriptor instead.
+func (*Mention) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *Mention) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Mention) GetMsg() *SendMsg {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+func (x *Mention) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+// MentionReply 提及列表响应
+type MentionReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                      // 状态码
+	Mentions      []*Mention             `protobuf:"bytes,2,rep,name=mentions,proto3" json:"mentions,omitempty"`               // 提及列表
+	HasMore       bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"` // 是否还有更多
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MentionReply) Reset() {
+	*x = MentionReply{}
+	mi := &file_logic_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MentionReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MentionReply) ProtoMessage() {}
+
+func (x *MentionReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MentionReply.ProtoReflect.Descriptor instead.
+func (*MentionReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *MentionReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *MentionReply) GetMentions() []*Mention {
+	if x != nil {
+		return x.Mentions
+	}
+	return nil
+}
+
+func (x *MentionReply) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+// Attachment 附件元信息，文件本身存在api层的存储里
+type Attachment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`                            // 附件ID
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`                         // 文件名
+	Size          int64                  `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`                        // 大小 (字节)
+	MimeType      string                 `protobuf:"bytes,4,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"` // MIME类型
+	Checksum      string                 `protobuf:"bytes,5,opt,name=checksum,proto3" json:"checksum,omitempty"`                 // sha256，十六进制
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attachment) Reset() {
+	*x = Attachment{}
+	mi := &file_logic_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attachment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attachment) ProtoMessage() {}
+
+func (x *Attachment) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attachment.ProtoReflect.Descriptor instead.
+func (*Attachment) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *Attachment) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Attachment) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attachment) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Attachment) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *Attachment) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+// AttachmentRequest 登记上传的附件，或者查询附件 (只需要 attachment.id)
+type AttachmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`            // 用户ID
+	Attachment    *Attachment            `protobuf:"bytes,2,opt,name=attachment,proto3" json:"attachment,omitempty"`                   // 附件信息
+	StorageKey    string                 `protobuf:"bytes,3,opt,name=storage_key,json=storageKey,proto3" json:"storage_key,omitempty"` // 在存储里的key (登记时使用)
+	Quota         int64                  `protobuf:"varint,4,opt,name=quota,proto3" json:"quota,omitempty"`                            // 用户附件总大小上限，字节，0 表示不限制 (登记时使用)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachmentRequest) Reset() {
+	*x = AttachmentRequest{}
+	mi := &file_logic_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachmentRequest) ProtoMessage() {}
+
+func (x *AttachmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachmentRequest.ProtoReflect.Descriptor instead.
+func (*AttachmentRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *AttachmentRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *AttachmentRequest) GetAttachment() *Attachment {
+	if x != nil {
+		return x.Attachment
+	}
+	return nil
+}
+
+func (x *AttachmentRequest) GetStorageKey() string {
+	if x != nil {
+		return x.StorageKey
+	}
+	return ""
+}
+
+func (x *AttachmentRequest) GetQuota() int64 {
+	if x != nil {
+		return x.Quota
+	}
+	return 0
+}
+
+// AttachmentReply 附件响应
+type AttachmentReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                              // 状态码
+	Attachment    *Attachment            `protobuf:"bytes,2,opt,name=attachment,proto3" json:"attachment,omitempty"`                   // 附件信息
+	StorageKey    string                 `protobuf:"bytes,3,opt,name=storage_key,json=storageKey,proto3" json:"storage_key,omitempty"` // 在存储里的key (查询时返回，给api层下载用)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachmentReply) Reset() {
+	*x = AttachmentReply{}
+	mi := &file_logic_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachmentReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachmentReply) ProtoMessage() {}
+
+func (x *AttachmentReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttachmentReply.ProtoReflect.Descriptor instead.
+func (*AttachmentReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *AttachmentReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *AttachmentReply) GetAttachment() *Attachment {
+	if x != nil {
+		return x.Attachment
+	}
+	return nil
+}
+
+func (x *AttachmentReply) GetStorageKey() string {
+	if x != nil {
+		return x.StorageKey
+	}
+	return ""
+}
+
+// OrphanAttachmentRequest 认领上传后一直没有随消息发出的附件，认领的记录会被删掉
+type OrphanAttachmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Before        int64                  `protobuf:"varint,1,opt,name=before,proto3" json:"before,omitempty"` // 上传时间早于这个时间的 (毫秒)
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`   // 一次最多认领多少个
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrphanAttachmentRequest) Reset() {
+	*x = OrphanAttachmentRequest{}
+	mi := &file_logic_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrphanAttachmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrphanAttachmentRequest) ProtoMessage() {}
+
+func (x *OrphanAttachmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrphanAttachmentRequest.ProtoReflect.Descriptor instead.
+func (*OrphanAttachmentRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *OrphanAttachmentRequest) GetBefore() int64 {
+	if x != nil {
+		return x.Before
+	}
+	return 0
+}
+
+func (x *OrphanAttachmentRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// OrphanAttachmentReply 认领到的附件在存储里的key，由api层删除文件
+type OrphanAttachmentReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                                 // 状态码
+	StorageKeys   []string               `protobuf:"bytes,2,rep,name=storage_keys,json=storageKeys,proto3" json:"storage_keys,omitempty"` // 存储key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrphanAttachmentReply) Reset() {
+	*x = OrphanAttachmentReply{}
+	mi := &file_logic_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrphanAttachmentReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrphanAttachmentReply) ProtoMessage() {}
+
+func (x *OrphanAttachmentReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrphanAttachmentReply.ProtoReflect.Descriptor instead.
+func (*OrphanAttachmentReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *OrphanAttachmentReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *OrphanAttachmentReply) GetStorageKeys() []string {
+	if x != nil {
+		return x.StorageKeys
+	}
+	return nil
+}
+
+// SearchRequest 搜索消息，只在调用者参与的会话里搜
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`               // 调用者用户ID
+	Keyword       string                 `protobuf:"bytes,2,opt,name=keyword,proto3" json:"keyword,omitempty"`                            // 关键词
+	RoomId        int32                  `protobuf:"varint,3,opt,name=room_id,json=roomId,proto3" json:"room_id,omitempty"`               // 只搜这个房间，为0时搜所有会话
+	FromUserId    int32                  `protobuf:"varint,4,opt,name=from_user_id,json=fromUserId,proto3" json:"from_user_id,omitempty"` // 只搜这个人发的
+	StartTime     int64                  `protobuf:"varint,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`      // 起始时间 毫秒 (含)，为0时不限
+	EndTime       int64                  `protobuf:"varint,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`            // 结束时间 毫秒 (不含)，为0时不限
+	Attachment    int32                  `protobuf:"varint,7,opt,name=attachment,proto3" json:"attachment,omitempty"`                     // 附件过滤 0:不限 1:带附件 2:不带附件
+	CursorId      int64                  `protobuf:"varint,8,opt,name=cursor_id,json=cursorId,proto3" json:"cursor_id,omitempty"`         // 上一页最后一条的消息ID (不含)
+	Limit         int32                  `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"`                               // 每页条数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_logic_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *SearchRequest) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetRoomId() int32 {
+	if x != nil {
+		return x.RoomId
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetFromUserId() int32 {
+	if x != nil {
+		return x.FromUserId
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetStartTime() int64 {
+	if x != nil {
+		return x.StartTime
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetEndTime() int64 {
+	if x != nil {
+		return x.EndTime
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetAttachment() int32 {
+	if x != nil {
+		return x.Attachment
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetCursorId() int64 {
+	if x != nil {
+		return x.CursorId
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// SearchHit 搜索命中
+type SearchHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Msg           *SendMsg               `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`         // 命中的消息
+	Snippet       string                 `protobuf:"bytes,2,opt,name=snippet,proto3" json:"snippet,omitempty"` // 关键词高亮的片段
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchHit) Reset() {
+	*x = SearchHit{}
+	mi := &file_logic_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchHit) ProtoMessage() {}
+
+func (x *SearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchHit.ProtoReflect.Descriptor instead.
+func (*SearchHit) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *SearchHit) GetMsg() *SendMsg {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+func (x *SearchHit) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+// SearchReply 搜索响应，按消息从新到旧
+type SearchReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`                      // 状态码
+	Hits          []*SearchHit           `protobuf:"bytes,2,rep,name=hits,proto3" json:"hits,omitempty"`                       // 命中列表
+	HasMore       bool                   `protobuf:"varint,3,opt,name=has_more,json=hasMore,proto3" json:"has_more,omitempty"` // 是否还有更多
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchReply) Reset() {
+	*x = SearchReply{}
+	mi := &file_logic_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchReply) ProtoMessage() {}
+
+func (x *SearchReply) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchReply.ProtoReflect.Descriptor instead.
+func (*SearchReply) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *SearchReply) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *SearchReply) GetHits() []*SearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *SearchReply) GetHasMore() bool {
+	if x != nil {
+		return x.HasMore
+	}
+	return false
+}
+
+// MsgPayload 消息体，协议版本 2 开始支持，只能是其中一种
+type MsgPayload struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Body:
+	//
+	//	*MsgPayload_Text
+	//	*MsgPayload_Markdown
+	//	*MsgPayload_Attachment
+	//	*MsgPayload_System
+	//	*MsgPayload_Location
+	//	*MsgPayload_Custom
+	Body          isMsgPayload_Body `protobuf_oneof:"body"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MsgPayload) Reset() {
+	*x = MsgPayload{}
+	mi := &file_logic_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MsgPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MsgPayload) ProtoMessage() {}
+
+func (x *MsgPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MsgPayload.ProtoReflect.Descriptor instead.
+func (*MsgPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *MsgPayload) GetBody() isMsgPayload_Body {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
 
-func (x *SendTcpMsg) String() string {
+func (x *MsgPayload) GetText() *TextPayload {
+	if x != nil {
+		if x, ok := x.Body.(*MsgPayload_Text); ok {
+			return x.Text
+		}
+	}
+	return nil
+}
+
+func (x *MsgPayload) GetMarkdown() *MarkdownPayload {
+	if x != nil {
+		if x, ok := x.Body.(*MsgPayload_Markdown); ok {
+			return x.Markdown
+		}
+	}
+	return nil
+}
+
+func (x *MsgPayload) GetAttachment() *AttachmentPayload {
+	if x != nil {
+		if x, ok := x.Body.(*MsgPayload_Attachment); ok {
+			return x.Attachment
+		}
+	}
+	return nil
+}
+
+func (x *MsgPayload) GetSystem() *SystemPayload {
+	if x != nil {
+		if x, ok := x.Body.(*MsgPayload_System); ok {
+			return x.System
+		}
+	}
+	return nil
+}
+
+func (x *MsgPayload) GetLocation() *LocationPayload {
+	if x != nil {
+		if x, ok := x.Body.(*MsgPayload_Location); ok {
+			return x.Location
+		}
+	}
+	return nil
+}
+
+func (x *MsgPayload) GetCustom() *CustomPayload {
+	if x != nil {
+		if x, ok := x.Body.(*MsgPayload_Custom); ok {
+			return x.Custom
+		}
+	}
+	return nil
+}
+
+type isMsgPayload_Body interface {
+	isMsgPayload_Body()
+}
+
+type MsgPayload_Text struct {
+	Text *TextPayload `protobuf:"bytes,1,opt,name=text,proto3,oneof"`
+}
+
+type MsgPayload_Markdown struct {
+	Markdown *MarkdownPayload `protobuf:"bytes,2,opt,name=markdown,proto3,oneof"`
+}
+
+type MsgPayload_Attachment struct {
+	Attachment *AttachmentPayload `protobuf:"bytes,3,opt,name=attachment,proto3,oneof"`
+}
+
+type MsgPayload_System struct {
+	System *SystemPayload `protobuf:"bytes,4,opt,name=system,proto3,oneof"`
+}
+
+type MsgPayload_Location struct {
+	Location *LocationPayload `protobuf:"bytes,5,opt,name=location,proto3,oneof"`
+}
+
+type MsgPayload_Custom struct {
+	Custom *CustomPayload `protobuf:"bytes,6,opt,name=custom,proto3,oneof"`
+}
+
+func (*MsgPayload_Text) isMsgPayload_Body() {}
+
+func (*MsgPayload_Markdown) isMsgPayload_Body() {}
+
+func (*MsgPayload_Attachment) isMsgPayload_Body() {}
+
+func (*MsgPayload_System) isMsgPayload_Body() {}
+
+func (*MsgPayload_Location) isMsgPayload_Body() {}
+
+func (*MsgPayload_Custom) isMsgPayload_Body() {}
+
+// TextPayload 纯文本
+type TextPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TextPayload) Reset() {
+	*x = TextPayload{}
+	mi := &file_logic_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TextPayload) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendTcpMsg) ProtoMessage() {}
+func (*TextPayload) ProtoMessage() {}
 
-func (x *SendTcpMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_logic_proto_msgTypes[15]
+func (x *TextPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[73]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -890,77 +5058,287 @@ func (x *SendTcpMsg) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendTcpMsg.ProtoReflect.Descriptor instead.
-func (*SendTcpMsg) Descriptor() ([]byte, []int) {
-	return file_logic_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use TextPayload.ProtoReflect.Descriptor instead.
+func (*TextPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{73}
 }
 
-func (x *SendTcpMsg) GetCode() int32 {
+func (x *TextPayload) GetText() string {
 	if x != nil {
-		return x.Code
+		return x.Text
 	}
-	return 0
+	return ""
 }
 
-func (x *SendTcpMsg) GetMsg() string {
+// MarkdownPayload markdown 文本，老客户端看到原文
+type MarkdownPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Markdown      string                 `protobuf:"bytes,1,opt,name=markdown,proto3" json:"markdown,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkdownPayload) Reset() {
+	*x = MarkdownPayload{}
+	mi := &file_logic_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkdownPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkdownPayload) ProtoMessage() {}
+
+func (x *MarkdownPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[74]
 	if x != nil {
-		return x.Msg
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkdownPayload.ProtoReflect.Descriptor instead.
+func (*MarkdownPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *MarkdownPayload) GetMarkdown() string {
+	if x != nil {
+		return x.Markdown
 	}
 	return ""
 }
 
-func (x *SendTcpMsg) GetFromUserId() int32 {
+// AttachmentPayload 附件消息，附件本身在 SendMsg.attachments 里
+type AttachmentPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Caption       string                 `protobuf:"bytes,1,opt,name=caption,proto3" json:"caption,omitempty"` // 附件说明 (可选)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AttachmentPayload) Reset() {
+	*x = AttachmentPayload{}
+	mi := &file_logic_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AttachmentPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttachmentPayload) ProtoMessage() {}
+
+func (x *AttachmentPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[75]
 	if x != nil {
-		return x.FromUserId
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *SendTcpMsg) GetFromUserName() string {
+// Deprecated: Use AttachmentPayload.ProtoReflect.Descriptor instead.
+func (*AttachmentPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *AttachmentPayload) GetCaption() string {
 	if x != nil {
-		return x.FromUserName
+		return x.Caption
 	}
 	return ""
 }
 
-func (x *SendTcpMsg) GetToUserId() int32 {
+// SystemPayload 系统通知，只能由服务端发出
+type SystemPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Event         string                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"` // 事件类型
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`   // 展示的文本
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SystemPayload) Reset() {
+	*x = SystemPayload{}
+	mi := &file_logic_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SystemPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SystemPayload) ProtoMessage() {}
+
+func (x *SystemPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[76]
 	if x != nil {
-		return x.ToUserId
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *SendTcpMsg) GetToUserName() string {
+// Deprecated: Use SystemPayload.ProtoReflect.Descriptor instead.
+func (*SystemPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *SystemPayload) GetEvent() string {
 	if x != nil {
-		return x.ToUserName
+		return x.Event
 	}
 	return ""
 }
 
-func (x *SendTcpMsg) GetRoomId() int32 {
+func (x *SystemPayload) GetText() string {
 	if x != nil {
-		return x.RoomId
+		return x.Text
+	}
+	return ""
+}
+
+// LocationPayload 位置
+type LocationPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Latitude      float64                `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`   // 纬度
+	Longitude     float64                `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"` // 经度
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`             // 地点名称 (可选)
+	Address       string                 `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`       // 地址 (可选)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocationPayload) Reset() {
+	*x = LocationPayload{}
+	mi := &file_logic_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocationPayload) ProtoMessage() {}
+
+func (x *LocationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocationPayload.ProtoReflect.Descriptor instead.
+func (*LocationPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *LocationPayload) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
 	}
 	return 0
 }
 
-func (x *SendTcpMsg) GetOp() int32 {
+func (x *LocationPayload) GetLongitude() float64 {
 	if x != nil {
-		return x.Op
+		return x.Longitude
 	}
 	return 0
 }
 
-func (x *SendTcpMsg) GetCreateTime() string {
+func (x *LocationPayload) GetName() string {
 	if x != nil {
-		return x.CreateTime
+		return x.Name
 	}
 	return ""
 }
 
-func (x *SendTcpMsg) GetAuthToken() string {
+func (x *LocationPayload) GetAddress() string {
 	if x != nil {
-		return x.AuthToken
+		return x.Address
+	}
+	return ""
+}
+
+// CustomPayload 自定义消息，服务端只检查是合法的JSON
+type CustomPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`         // 自定义类型，由客户端约定
+	Json          string                 `protobuf:"bytes,2,opt,name=json,proto3" json:"json,omitempty"`         // JSON 内容
+	Fallback      string                 `protobuf:"bytes,3,opt,name=fallback,proto3" json:"fallback,omitempty"` // 不认识这个类型的客户端显示的文本 (可选)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CustomPayload) Reset() {
+	*x = CustomPayload{}
+	mi := &file_logic_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomPayload) ProtoMessage() {}
+
+func (x *CustomPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_logic_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomPayload.ProtoReflect.Descriptor instead.
+func (*CustomPayload) Descriptor() ([]byte, []int) {
+	return file_logic_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *CustomPayload) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CustomPayload) GetJson() string {
+	if x != nil {
+		return x.Json
+	}
+	return ""
+}
+
+func (x *CustomPayload) GetFallback() string {
+	if x != nil {
+		return x.Fallback
 	}
 	return ""
 }
@@ -969,21 +5347,38 @@ var File_logic_proto protoreflect.FileDescriptor
 
 const file_logic_proto_rawDesc = "" +
 	"\n" +
-	"\vlogic.proto\x12\blogic_pb\">\n" +
+	"\vlogic.proto\x12\blogic_pb\"V\n" +
 	"\fLoginRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"B\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x16\n" +
+	"\x06device\x18\x03 \x01(\tR\x06device\"\x86\x01\n" +
 	"\rLoginResponse\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x1d\n" +
 	"\n" +
-	"auth_token\x18\x02 \x01(\tR\tauthToken\"A\n" +
+	"auth_token\x18\x02 \x01(\tR\tauthToken\x12#\n" +
+	"\rrefresh_token\x18\x03 \x01(\tR\frefreshToken\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\"Y\n" +
 	"\x0fRegisterRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"B\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x16\n" +
+	"\x06device\x18\x03 \x01(\tR\x06device\"\x86\x01\n" +
 	"\rRegisterReply\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x1d\n" +
 	"\n" +
-	"auth_token\x18\x02 \x01(\tR\tauthToken\".\n" +
+	"auth_token\x18\x02 \x01(\tR\tauthToken\x12#\n" +
+	"\rrefresh_token\x18\x03 \x01(\tR\frefreshToken\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\":\n" +
+	"\x13RefreshTokenRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\"\x8a\x01\n" +
+	"\x11RefreshTokenReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x1d\n" +
+	"\n" +
+	"auth_token\x18\x02 \x01(\tR\tauthToken\x12#\n" +
+	"\rrefresh_token\x18\x03 \x01(\tR\frefreshToken\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\".\n" +
 	"\rLogoutRequest\x12\x1d\n" +
 	"\n" +
 	"auth_token\x18\x01 \x01(\tR\tauthToken\"$\n" +
@@ -991,29 +5386,38 @@ const file_logic_proto_rawDesc = "" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\"1\n" +
 	"\x10CheckAuthRequest\x12\x1d\n" +
 	"\n" +
-	"auth_token\x18\x01 \x01(\tR\tauthToken\"]\n" +
+	"auth_token\x18\x01 \x01(\tR\tauthToken\"u\n" +
 	"\x11CheckAuthResponse\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x1b\n" +
-	"\tuser_name\x18\x03 \x01(\tR\buserName\"-\n" +
+	"\tuser_name\x18\x03 \x01(\tR\buserName\x12\x16\n" +
+	"\x06device\x18\x04 \x01(\tR\x06device\"-\n" +
 	"\x12GetUserInfoRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\x05R\x06userId\"_\n" +
 	"\x13GetUserInfoResponse\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x1b\n" +
-	"\tuser_name\x18\x03 \x01(\tR\buserName\"e\n" +
+	"\tuser_name\x18\x03 \x01(\tR\buserName\"w\n" +
 	"\x0eConnectRequest\x12\x1d\n" +
 	"\n" +
 	"auth_token\x18\x01 \x01(\tR\tauthToken\x12\x17\n" +
 	"\aroom_id\x18\x02 \x01(\x05R\x06roomId\x12\x1b\n" +
-	"\tserver_id\x18\x03 \x01(\tR\bserverId\"'\n" +
+	"\tserver_id\x18\x03 \x01(\tR\bserverId\x12\x10\n" +
+	"\x03ack\x18\x04 \x01(\bR\x03ack\"b\n" +
 	"\fConnectReply\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\x05R\x06userId\"E\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12!\n" +
+	"\foffline_msgs\x18\x02 \x03(\fR\vofflineMsgs\x12\x16\n" +
+	"\x06device\x18\x03 \x01(\tR\x06device\"\xd4\x01\n" +
 	"\x11DisConnectRequest\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\x05R\x06roomId\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\x05R\x06userId\"#\n" +
+	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12!\n" +
+	"\funacked_msgs\x18\x03 \x03(\fR\vunackedMsgs\x12\x19\n" +
+	"\broom_ids\x18\x04 \x03(\x05R\aroomIds\x12\x16\n" +
+	"\x06device\x18\x05 \x01(\tR\x06device\x12\x1b\n" +
+	"\tserver_id\x18\x06 \x01(\tR\bserverId\x12\x1a\n" +
+	"\breplaced\x18\a \x01(\bR\breplaced\"#\n" +
 	"\x0fDisConnectReply\x12\x10\n" +
-	"\x03has\x18\x01 \x01(\bR\x03has\"\x81\x02\n" +
+	"\x03has\x18\x01 \x01(\bR\x03has\"\x8e\x06\n" +
 	"\aSendMsg\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x10\n" +
 	"\x03msg\x18\x02 \x01(\tR\x03msg\x12 \n" +
@@ -1027,7 +5431,25 @@ const file_logic_proto_rawDesc = "" +
 	"\aroom_id\x18\a \x01(\x05R\x06roomId\x12\x0e\n" +
 	"\x02op\x18\b \x01(\x05R\x02op\x12\x1f\n" +
 	"\vcreate_time\x18\t \x01(\tR\n" +
-	"createTime\"\xa3\x02\n" +
+	"createTime\x12\x15\n" +
+	"\x06msg_id\x18\n" +
+	" \x01(\x03R\x05msgId\x12'\n" +
+	"\x0fconversation_id\x18\v \x01(\tR\x0econversationId\x12\x10\n" +
+	"\x03seq\x18\f \x01(\x03R\x03seq\x12\x1c\n" +
+	"\ttimestamp\x18\r \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06edited\x18\x0e \x01(\bR\x06edited\x12\x18\n" +
+	"\adeleted\x18\x0f \x01(\bR\adeleted\x12\x1b\n" +
+	"\tedit_time\x18\x10 \x01(\x03R\beditTime\x125\n" +
+	"\treactions\x18\x11 \x03(\v2\x17.logic_pb.ReactionCountR\treactions\x12\x19\n" +
+	"\breply_to\x18\x12 \x01(\x03R\areplyTo\x12\x1f\n" +
+	"\vreply_count\x18\x13 \x01(\x05R\n" +
+	"replyCount\x12&\n" +
+	"\x0flast_reply_time\x18\x14 \x01(\x03R\rlastReplyTime\x12(\n" +
+	"\x10mention_user_ids\x18\x15 \x03(\x05R\x0ementionUserIds\x12\x1f\n" +
+	"\vmention_all\x18\x16 \x01(\bR\n" +
+	"mentionAll\x126\n" +
+	"\vattachments\x18\x17 \x03(\v2\x14.logic_pb.AttachmentR\vattachments\x12.\n" +
+	"\apayload\x18\x18 \x01(\v2\x14.logic_pb.MsgPayloadR\apayload\"\xab\x03\n" +
 	"\n" +
 	"SendTcpMsg\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x10\n" +
@@ -1045,7 +5467,316 @@ const file_logic_proto_rawDesc = "" +
 	"createTime\x12\x1d\n" +
 	"\n" +
 	"auth_token\x18\n" +
-	" \x01(\tR\tauthTokenB\x16Z\x14yoyichat/pb/logic_pbb\x06proto3"
+	" \x01(\tR\tauthToken\x12\x15\n" +
+	"\x06msg_id\x18\v \x01(\x03R\x05msgId\x12\x10\n" +
+	"\x03seq\x18\f \x01(\x03R\x03seq\x12\x16\n" +
+	"\x06typing\x18\r \x01(\bR\x06typing\x12\x19\n" +
+	"\breply_to\x18\x0e \x01(\x03R\areplyTo\x12\x18\n" +
+	"\aversion\x18\x0f \x01(\x05R\aversion\x12\x10\n" +
+	"\x03ack\x18\x10 \x01(\bR\x03ack\"\xf1\x01\n" +
+	"\x0eHistoryRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x02 \x01(\x05R\btoUserId\x12\x17\n" +
+	"\aroom_id\x18\x03 \x01(\x05R\x06roomId\x12\x1d\n" +
+	"\n" +
+	"cursor_seq\x18\x04 \x01(\x03R\tcursorSeq\x12\x1f\n" +
+	"\vcursor_time\x18\x05 \x01(\x03R\n" +
+	"cursorTime\x12\x1c\n" +
+	"\tdirection\x18\x06 \x01(\x05R\tdirection\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\x12\x1b\n" +
+	"\tthread_id\x18\b \x01(\x03R\bthreadId\"\x8b\x01\n" +
+	"\fHistoryReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12%\n" +
+	"\x04msgs\x18\x02 \x03(\v2\x11.logic_pb.SendMsgR\x04msgs\x12\x19\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore\x12%\n" +
+	"\x04root\x18\x04 \x01(\v2\x11.logic_pb.SendMsgR\x04root\"]\n" +
+	"\rOnlineRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x1b\n" +
+	"\tserver_id\x18\x03 \x01(\tR\bserverId\"D\n" +
+	"\vOnlineReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12!\n" +
+	"\foffline_msgs\x18\x02 \x03(\fR\vofflineMsgs\"F\n" +
+	"\x12OfflineTrimRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x17\n" +
+	"\amsg_ids\x18\x02 \x03(\x03R\x06msgIds\"&\n" +
+	"\x10OfflineTrimReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\"<\n" +
+	"\n" +
+	"AckRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x15\n" +
+	"\x06msg_id\x18\x02 \x01(\x03R\x05msgId\"\x1e\n" +
+	"\bAckReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\"\xa8\x01\n" +
+	"\x10DeliveryStateMsg\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\x05R\x02op\x12\x15\n" +
+	"\x06msg_id\x18\x02 \x01(\x03R\x05msgId\x12'\n" +
+	"\x0fconversation_id\x18\x03 \x01(\tR\x0econversationId\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x03R\x03seq\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x05 \x01(\x05R\btoUserId\x12\x14\n" +
+	"\x05state\x18\x06 \x01(\tR\x05state\"v\n" +
+	"\x12ReadReceiptRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x02 \x01(\x05R\btoUserId\x12\x17\n" +
+	"\aroom_id\x18\x03 \x01(\x05R\x06roomId\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x03R\x03seq\"&\n" +
+	"\x10ReadReceiptReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\"\xaa\x01\n" +
+	"\x0eReadReceiptMsg\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\x05R\x02op\x12'\n" +
+	"\x0fconversation_id\x18\x02 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tuser_name\x18\x04 \x01(\tR\buserName\x12\x17\n" +
+	"\aroom_id\x18\x05 \x01(\x05R\x06roomId\x12\x10\n" +
+	"\x03seq\x18\x06 \x01(\x03R\x03seq\"(\n" +
+	"\rUnreadRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\"\xbb\x01\n" +
+	"\vUnreadCount\x12'\n" +
+	"\x0fconversation_id\x18\x01 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\x05R\x06roomId\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x03 \x01(\x05R\btoUserId\x12\x19\n" +
+	"\bread_seq\x18\x04 \x01(\x03R\areadSeq\x12\x19\n" +
+	"\blast_seq\x18\x05 \x01(\x03R\alastSeq\x12\x16\n" +
+	"\x06unread\x18\x06 \x01(\x03R\x06unread\"P\n" +
+	"\vUnreadReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12-\n" +
+	"\x06counts\x18\x02 \x03(\v2\x15.logic_pb.UnreadCountR\x06counts\"\xb2\x01\n" +
+	"\x0eContactRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12$\n" +
+	"\x0etarget_user_id\x18\x02 \x01(\x05R\ftargetUserId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\x03R\trequestId\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12(\n" +
+	"\x10target_user_name\x18\x05 \x01(\tR\x0etargetUserName\"\"\n" +
+	"\fContactReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\"o\n" +
+	"\aContact\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tuser_name\x18\x02 \x01(\tR\buserName\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x16\n" +
+	"\x06online\x18\x04 \x01(\bR\x06online\"\xfa\x01\n" +
+	"\rFriendRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12 \n" +
+	"\ffrom_user_id\x18\x02 \x01(\x05R\n" +
+	"fromUserId\x12$\n" +
+	"\x0efrom_user_name\x18\x03 \x01(\tR\ffromUserName\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x04 \x01(\x05R\btoUserId\x12 \n" +
+	"\fto_user_name\x18\x05 \x01(\tR\n" +
+	"toUserName\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12\x1f\n" +
+	"\vcreate_time\x18\b \x01(\tR\n" +
+	"createTime\"-\n" +
+	"\x12ContactListRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\"\x8a\x01\n" +
+	"\x10ContactListReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12-\n" +
+	"\bcontacts\x18\x02 \x03(\v2\x11.logic_pb.ContactR\bcontacts\x123\n" +
+	"\brequests\x18\x03 \x03(\v2\x17.logic_pb.FriendRequestR\brequests\"j\n" +
+	"\x0fContactEventMsg\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\x05R\x02op\x12\x14\n" +
+	"\x05event\x18\x02 \x01(\tR\x05event\x121\n" +
+	"\arequest\x18\x03 \x01(\v2\x17.logic_pb.FriendRequestR\arequest\"\xde\x01\n" +
+	"\x04Room\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05topic\x18\x03 \x01(\tR\x05topic\x12\x19\n" +
+	"\bowner_id\x18\x04 \x01(\x05R\aownerId\x12\x1d\n" +
+	"\n" +
+	"owner_name\x18\x05 \x01(\tR\townerName\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x06 \x01(\tR\n" +
+	"visibility\x12!\n" +
+	"\fmember_count\x18\a \x01(\x05R\vmemberCount\x12\x1f\n" +
+	"\vcreate_time\x18\b \x01(\tR\n" +
+	"createTime\"\xfa\x01\n" +
+	"\vRoomRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x17\n" +
+	"\aroom_id\x18\x02 \x01(\x05R\x06roomId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x14\n" +
+	"\x05topic\x18\x04 \x01(\tR\x05topic\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x05 \x01(\tR\n" +
+	"visibility\x12\x18\n" +
+	"\akeyword\x18\x06 \x01(\tR\akeyword\x12$\n" +
+	"\x0etarget_user_id\x18\a \x01(\x05R\ftargetUserId\x12\x12\n" +
+	"\x04role\x18\b \x01(\tR\x04role\x12\x1b\n" +
+	"\ttopic_set\x18\t \x01(\bR\btopicSet\"C\n" +
+	"\tRoomReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\"\n" +
+	"\x04room\x18\x02 \x01(\v2\x0e.logic_pb.RoomR\x04room\"I\n" +
+	"\rRoomListReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12$\n" +
+	"\x05rooms\x18\x02 \x03(\v2\x0e.logic_pb.RoomR\x05rooms\"t\n" +
+	"\x10HeartbeatRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x16\n" +
+	"\x06device\x18\x02 \x01(\tR\x06device\x12\x1b\n" +
+	"\tserver_id\x18\x03 \x01(\tR\bserverId\x12\x12\n" +
+	"\x04idle\x18\x04 \x01(\bR\x04idle\"$\n" +
+	"\x0eHeartbeatReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\"u\n" +
+	"\bPresence\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tuser_name\x18\x02 \x01(\tR\buserName\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1b\n" +
+	"\tlast_seen\x18\x04 \x01(\x03R\blastSeen\"]\n" +
+	"\x0fPresenceRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x19\n" +
+	"\buser_ids\x18\x03 \x03(\x05R\auserIds\"U\n" +
+	"\rPresenceReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x120\n" +
+	"\tpresences\x18\x02 \x03(\v2\x12.logic_pb.PresenceR\tpresences\"M\n" +
+	"\vPresenceMsg\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\x05R\x02op\x12.\n" +
+	"\bpresence\x18\x02 \x01(\v2\x12.logic_pb.PresenceR\bpresence\"w\n" +
+	"\rTypingRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x02 \x01(\x05R\btoUserId\x12\x17\n" +
+	"\aroom_id\x18\x03 \x01(\x05R\x06roomId\x12\x16\n" +
+	"\x06typing\x18\x04 \x01(\bR\x06typing\"!\n" +
+	"\vTypingReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\"\xbd\x01\n" +
+	"\tTypingMsg\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\x05R\x02op\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tuser_name\x18\x03 \x01(\tR\buserName\x12\x1c\n" +
+	"\n" +
+	"to_user_id\x18\x04 \x01(\x05R\btoUserId\x12\x17\n" +
+	"\aroom_id\x18\x05 \x01(\x05R\x06roomId\x12\x16\n" +
+	"\x06typing\x18\x06 \x01(\bR\x06typing\x12\x1b\n" +
+	"\texpire_ms\x18\a \x01(\x03R\bexpireMs\"\\\n" +
+	"\x10MsgUpdateRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x15\n" +
+	"\x06msg_id\x18\x02 \x01(\x03R\x05msgId\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\"I\n" +
+	"\x0eMsgUpdateReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12#\n" +
+	"\x03msg\x18\x02 \x01(\v2\x11.logic_pb.SendMsgR\x03msg\"U\n" +
+	"\rReactionCount\x12\x14\n" +
+	"\x05emoji\x18\x01 \x01(\tR\x05emoji\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x18\n" +
+	"\areacted\x18\x03 \x01(\bR\areacted\"W\n" +
+	"\x0fReactionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x15\n" +
+	"\x06msg_id\x18\x02 \x01(\x03R\x05msgId\x12\x14\n" +
+	"\x05emoji\x18\x03 \x01(\tR\x05emoji\"Z\n" +
+	"\rReactionReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x125\n" +
+	"\treactions\x18\x02 \x03(\v2\x17.logic_pb.ReactionCountR\treactions\"\x91\x02\n" +
+	"\vReactionMsg\x12\x0e\n" +
+	"\x02op\x18\x01 \x01(\x05R\x02op\x12\x15\n" +
+	"\x06msg_id\x18\x02 \x01(\x03R\x05msgId\x12'\n" +
+	"\x0fconversation_id\x18\x03 \x01(\tR\x0econversationId\x12\x17\n" +
+	"\aroom_id\x18\x04 \x01(\x05R\x06roomId\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tuser_name\x18\x06 \x01(\tR\buserName\x12\x14\n" +
+	"\x05emoji\x18\a \x01(\tR\x05emoji\x12\x16\n" +
+	"\x06action\x18\b \x01(\tR\x06action\x125\n" +
+	"\treactions\x18\t \x03(\v2\x17.logic_pb.ReactionCountR\treactions\"E\n" +
+	"\x13ThreadFollowRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x15\n" +
+	"\x06msg_id\x18\x02 \x01(\x03R\x05msgId\"b\n" +
+	"\x11ThreadFollowReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x1b\n" +
+	"\tthread_id\x18\x02 \x01(\x03R\bthreadId\x12\x1c\n" +
+	"\tfollowing\x18\x03 \x01(\bR\tfollowing\"\\\n" +
+	"\x0eMentionRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x1b\n" +
+	"\tcursor_id\x18\x02 \x01(\x03R\bcursorId\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"P\n" +
+	"\aMention\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12#\n" +
+	"\x03msg\x18\x02 \x01(\v2\x11.logic_pb.SendMsgR\x03msg\x12\x10\n" +
+	"\x03all\x18\x03 \x01(\bR\x03all\"l\n" +
+	"\fMentionReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12-\n" +
+	"\bmentions\x18\x02 \x03(\v2\x11.logic_pb.MentionR\bmentions\x12\x19\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore\"}\n" +
+	"\n" +
+	"Attachment\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x1b\n" +
+	"\tmime_type\x18\x04 \x01(\tR\bmimeType\x12\x1a\n" +
+	"\bchecksum\x18\x05 \x01(\tR\bchecksum\"\x99\x01\n" +
+	"\x11AttachmentRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x124\n" +
+	"\n" +
+	"attachment\x18\x02 \x01(\v2\x14.logic_pb.AttachmentR\n" +
+	"attachment\x12\x1f\n" +
+	"\vstorage_key\x18\x03 \x01(\tR\n" +
+	"storageKey\x12\x14\n" +
+	"\x05quota\x18\x04 \x01(\x03R\x05quota\"|\n" +
+	"\x0fAttachmentReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x124\n" +
+	"\n" +
+	"attachment\x18\x02 \x01(\v2\x14.logic_pb.AttachmentR\n" +
+	"attachment\x12\x1f\n" +
+	"\vstorage_key\x18\x03 \x01(\tR\n" +
+	"storageKey\"G\n" +
+	"\x17OrphanAttachmentRequest\x12\x16\n" +
+	"\x06before\x18\x01 \x01(\x03R\x06before\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"N\n" +
+	"\x15OrphanAttachmentReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12!\n" +
+	"\fstorage_keys\x18\x02 \x03(\tR\vstorageKeys\"\x8a\x02\n" +
+	"\rSearchRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x18\n" +
+	"\akeyword\x18\x02 \x01(\tR\akeyword\x12\x17\n" +
+	"\aroom_id\x18\x03 \x01(\x05R\x06roomId\x12 \n" +
+	"\ffrom_user_id\x18\x04 \x01(\x05R\n" +
+	"fromUserId\x12\x1d\n" +
+	"\n" +
+	"start_time\x18\x05 \x01(\x03R\tstartTime\x12\x19\n" +
+	"\bend_time\x18\x06 \x01(\x03R\aendTime\x12\x1e\n" +
+	"\n" +
+	"attachment\x18\a \x01(\x05R\n" +
+	"attachment\x12\x1b\n" +
+	"\tcursor_id\x18\b \x01(\x03R\bcursorId\x12\x14\n" +
+	"\x05limit\x18\t \x01(\x05R\x05limit\"J\n" +
+	"\tSearchHit\x12#\n" +
+	"\x03msg\x18\x01 \x01(\v2\x11.logic_pb.SendMsgR\x03msg\x12\x18\n" +
+	"\asnippet\x18\x02 \x01(\tR\asnippet\"e\n" +
+	"\vSearchReply\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12'\n" +
+	"\x04hits\x18\x02 \x03(\v2\x13.logic_pb.SearchHitR\x04hits\x12\x19\n" +
+	"\bhas_more\x18\x03 \x01(\bR\ahasMore\"\xd8\x02\n" +
+	"\n" +
+	"MsgPayload\x12+\n" +
+	"\x04text\x18\x01 \x01(\v2\x15.logic_pb.TextPayloadH\x00R\x04text\x127\n" +
+	"\bmarkdown\x18\x02 \x01(\v2\x19.logic_pb.MarkdownPayloadH\x00R\bmarkdown\x12=\n" +
+	"\n" +
+	"attachment\x18\x03 \x01(\v2\x1b.logic_pb.AttachmentPayloadH\x00R\n" +
+	"attachment\x121\n" +
+	"\x06system\x18\x04 \x01(\v2\x17.logic_pb.SystemPayloadH\x00R\x06system\x127\n" +
+	"\blocation\x18\x05 \x01(\v2\x19.logic_pb.LocationPayloadH\x00R\blocation\x121\n" +
+	"\x06custom\x18\x06 \x01(\v2\x17.logic_pb.CustomPayloadH\x00R\x06customB\x06\n" +
+	"\x04body\"!\n" +
+	"\vTextPayload\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"-\n" +
+	"\x0fMarkdownPayload\x12\x1a\n" +
+	"\bmarkdown\x18\x01 \x01(\tR\bmarkdown\"-\n" +
+	"\x11AttachmentPayload\x12\x18\n" +
+	"\acaption\x18\x01 \x01(\tR\acaption\"9\n" +
+	"\rSystemPayload\x12\x14\n" +
+	"\x05event\x18\x01 \x01(\tR\x05event\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"y\n" +
+	"\x0fLocationPayload\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aaddress\"S\n" +
+	"\rCustomPayload\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04json\x18\x02 \x01(\tR\x04json\x12\x1a\n" +
+	"\bfallback\x18\x03 \x01(\tR\bfallbackB\x16Z\x14yoyichat/pb/logic_pbb\x06proto3"
 
 var (
 	file_logic_proto_rawDescOnce sync.Once
@@ -1059,31 +5790,122 @@ func file_logic_proto_rawDescGZIP() []byte {
 	return file_logic_proto_rawDescData
 }
 
-var file_logic_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_logic_proto_msgTypes = make([]protoimpl.MessageInfo, 79)
 var file_logic_proto_goTypes = []any{
-	(*LoginRequest)(nil),        // 0: logic_pb.LoginRequest
-	(*LoginResponse)(nil),       // 1: logic_pb.LoginResponse
-	(*RegisterRequest)(nil),     // 2: logic_pb.RegisterRequest
-	(*RegisterReply)(nil),       // 3: logic_pb.RegisterReply
-	(*LogoutRequest)(nil),       // 4: logic_pb.LogoutRequest
-	(*LogoutResponse)(nil),      // 5: logic_pb.LogoutResponse
-	(*CheckAuthRequest)(nil),    // 6: logic_pb.CheckAuthRequest
-	(*CheckAuthResponse)(nil),   // 7: logic_pb.CheckAuthResponse
-	(*GetUserInfoRequest)(nil),  // 8: logic_pb.GetUserInfoRequest
-	(*GetUserInfoResponse)(nil), // 9: logic_pb.GetUserInfoResponse
-	(*ConnectRequest)(nil),      // 10: logic_pb.ConnectRequest
-	(*ConnectReply)(nil),        // 11: logic_pb.ConnectReply
-	(*DisConnectRequest)(nil),   // 12: logic_pb.DisConnectRequest
-	(*DisConnectReply)(nil),     // 13: logic_pb.DisConnectReply
-	(*SendMsg)(nil),             // 14: logic_pb.SendMsg
-	(*SendTcpMsg)(nil),          // 15: logic_pb.SendTcpMsg
+	(*LoginRequest)(nil),            // 0: logic_pb.LoginRequest
+	(*LoginResponse)(nil),           // 1: logic_pb.LoginResponse
+	(*RegisterRequest)(nil),         // 2: logic_pb.RegisterRequest
+	(*RegisterReply)(nil),           // 3: logic_pb.RegisterReply
+	(*RefreshTokenRequest)(nil),     // 4: logic_pb.RefreshTokenRequest
+	(*RefreshTokenReply)(nil),       // 5: logic_pb.RefreshTokenReply
+	(*LogoutRequest)(nil),           // 6: logic_pb.LogoutRequest
+	(*LogoutResponse)(nil),          // 7: logic_pb.LogoutResponse
+	(*CheckAuthRequest)(nil),        // 8: logic_pb.CheckAuthRequest
+	(*CheckAuthResponse)(nil),       // 9: logic_pb.CheckAuthResponse
+	(*GetUserInfoRequest)(nil),      // 10: logic_pb.GetUserInfoRequest
+	(*GetUserInfoResponse)(nil),     // 11: logic_pb.GetUserInfoResponse
+	(*ConnectRequest)(nil),          // 12: logic_pb.ConnectRequest
+	(*ConnectReply)(nil),            // 13: logic_pb.ConnectReply
+	(*DisConnectRequest)(nil),       // 14: logic_pb.DisConnectRequest
+	(*DisConnectReply)(nil),         // 15: logic_pb.DisConnectReply
+	(*SendMsg)(nil),                 // 16: logic_pb.SendMsg
+	(*SendTcpMsg)(nil),              // 17: logic_pb.SendTcpMsg
+	(*HistoryRequest)(nil),          // 18: logic_pb.HistoryRequest
+	(*HistoryReply)(nil),            // 19: logic_pb.HistoryReply
+	(*OnlineRequest)(nil),           // 20: logic_pb.OnlineRequest
+	(*OnlineReply)(nil),             // 21: logic_pb.OnlineReply
+	(*OfflineTrimRequest)(nil),      // 22: logic_pb.OfflineTrimRequest
+	(*OfflineTrimReply)(nil),        // 23: logic_pb.OfflineTrimReply
+	(*AckRequest)(nil),              // 24: logic_pb.AckRequest
+	(*AckReply)(nil),                // 25: logic_pb.AckReply
+	(*DeliveryStateMsg)(nil),        // 26: logic_pb.DeliveryStateMsg
+	(*ReadReceiptRequest)(nil),      // 27: logic_pb.ReadReceiptRequest
+	(*ReadReceiptReply)(nil),        // 28: logic_pb.ReadReceiptReply
+	(*ReadReceiptMsg)(nil),          // 29: logic_pb.ReadReceiptMsg
+	(*UnreadRequest)(nil),           // 30: logic_pb.UnreadRequest
+	(*UnreadCount)(nil),             // 31: logic_pb.UnreadCount
+	(*UnreadReply)(nil),             // 32: logic_pb.UnreadReply
+	(*ContactRequest)(nil),          // 33: logic_pb.ContactRequest
+	(*ContactReply)(nil),            // 34: logic_pb.ContactReply
+	(*Contact)(nil),                 // 35: logic_pb.Contact
+	(*FriendRequest)(nil),           // 36: logic_pb.FriendRequest
+	(*ContactListRequest)(nil),      // 37: logic_pb.ContactListRequest
+	(*ContactListReply)(nil),        // 38: logic_pb.ContactListReply
+	(*ContactEventMsg)(nil),         // 39: logic_pb.ContactEventMsg
+	(*Room)(nil),                    // 40: logic_pb.Room
+	(*RoomRequest)(nil),             // 41: logic_pb.RoomRequest
+	(*RoomReply)(nil),               // 42: logic_pb.RoomReply
+	(*RoomListReply)(nil),           // 43: logic_pb.RoomListReply
+	(*HeartbeatRequest)(nil),        // 44: logic_pb.HeartbeatRequest
+	(*HeartbeatReply)(nil),          // 45: logic_pb.HeartbeatReply
+	(*Presence)(nil),                // 46: logic_pb.Presence
+	(*PresenceRequest)(nil),         // 47: logic_pb.PresenceRequest
+	(*PresenceReply)(nil),           // 48: logic_pb.PresenceReply
+	(*PresenceMsg)(nil),             // 49: logic_pb.PresenceMsg
+	(*TypingRequest)(nil),           // 50: logic_pb.TypingRequest
+	(*TypingReply)(nil),             // 51: logic_pb.TypingReply
+	(*TypingMsg)(nil),               // 52: logic_pb.TypingMsg
+	(*MsgUpdateRequest)(nil),        // 53: logic_pb.MsgUpdateRequest
+	(*MsgUpdateReply)(nil),          // 54: logic_pb.MsgUpdateReply
+	(*ReactionCount)(nil),           // 55: logic_pb.ReactionCount
+	(*ReactionRequest)(nil),         // 56: logic_pb.ReactionRequest
+	(*ReactionReply)(nil),           // 57: logic_pb.ReactionReply
+	(*ReactionMsg)(nil),             // 58: logic_pb.ReactionMsg
+	(*ThreadFollowRequest)(nil),     // 59: logic_pb.ThreadFollowRequest
+	(*ThreadFollowReply)(nil),       // 60: logic_pb.ThreadFollowReply
+	(*MentionRequest)(nil),          // 61: logic_pb.MentionRequest
+	(*Mention)(nil),                 // 62: logic_pb.Mention
+	(*MentionReply)(nil),            // 63: logic_pb.MentionReply
+	(*Attachment)(nil),              // 64: logic_pb.Attachment
+	(*AttachmentRequest)(nil),       // 65: logic_pb.AttachmentRequest
+	(*AttachmentReply)(nil),         // 66: logic_pb.AttachmentReply
+	(*OrphanAttachmentRequest)(nil), // 67: logic_pb.OrphanAttachmentRequest
+	(*OrphanAttachmentReply)(nil),   // 68: logic_pb.OrphanAttachmentReply
+	(*SearchRequest)(nil),           // 69: logic_pb.SearchRequest
+	(*SearchHit)(nil),               // 70: logic_pb.SearchHit
+	(*SearchReply)(nil),             // 71: logic_pb.SearchReply
+	(*MsgPayload)(nil),              // 72: logic_pb.MsgPayload
+	(*TextPayload)(nil),             // 73: logic_pb.TextPayload
+	(*MarkdownPayload)(nil),         // 74: logic_pb.MarkdownPayload
+	(*AttachmentPayload)(nil),       // 75: logic_pb.AttachmentPayload
+	(*SystemPayload)(nil),           // 76: logic_pb.SystemPayload
+	(*LocationPayload)(nil),         // 77: logic_pb.LocationPayload
+	(*CustomPayload)(nil),           // 78: logic_pb.CustomPayload
 }
 var file_logic_proto_depIdxs = []int32{
-	0, // [0:0] is the sub-list for method output_type
-	0, // [0:0] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	55, // 0: logic_pb.SendMsg.reactions:type_name -> logic_pb.ReactionCount
+	64, // 1: logic_pb.SendMsg.attachments:type_name -> logic_pb.Attachment
+	72, // 2: logic_pb.SendMsg.payload:type_name -> logic_pb.MsgPayload
+	16, // 3: logic_pb.HistoryReply.msgs:type_name -> logic_pb.SendMsg
+	16, // 4: logic_pb.HistoryReply.root:type_name -> logic_pb.SendMsg
+	31, // 5: logic_pb.UnreadReply.counts:type_name -> logic_pb.UnreadCount
+	35, // 6: logic_pb.ContactListReply.contacts:type_name -> logic_pb.Contact
+	36, // 7: logic_pb.ContactListReply.requests:type_name -> logic_pb.FriendRequest
+	36, // 8: logic_pb.ContactEventMsg.request:type_name -> logic_pb.FriendRequest
+	40, // 9: logic_pb.RoomReply.room:type_name -> logic_pb.Room
+	40, // 10: logic_pb.RoomListReply.rooms:type_name -> logic_pb.Room
+	46, // 11: logic_pb.PresenceReply.presences:type_name -> logic_pb.Presence
+	46, // 12: logic_pb.PresenceMsg.presence:type_name -> logic_pb.Presence
+	16, // 13: logic_pb.MsgUpdateReply.msg:type_name -> logic_pb.SendMsg
+	55, // 14: logic_pb.ReactionReply.reactions:type_name -> logic_pb.ReactionCount
+	55, // 15: logic_pb.ReactionMsg.reactions:type_name -> logic_pb.ReactionCount
+	16, // 16: logic_pb.Mention.msg:type_name -> logic_pb.SendMsg
+	62, // 17: logic_pb.MentionReply.mentions:type_name -> logic_pb.Mention
+	64, // 18: logic_pb.AttachmentRequest.attachment:type_name -> logic_pb.Attachment
+	64, // 19: logic_pb.AttachmentReply.attachment:type_name -> logic_pb.Attachment
+	16, // 20: logic_pb.SearchHit.msg:type_name -> logic_pb.SendMsg
+	70, // 21: logic_pb.SearchReply.hits:type_name -> logic_pb.SearchHit
+	73, // 22: logic_pb.MsgPayload.text:type_name -> logic_pb.TextPayload
+	74, // 23: logic_pb.MsgPayload.markdown:type_name -> logic_pb.MarkdownPayload
+	75, // 24: logic_pb.MsgPayload.attachment:type_name -> logic_pb.AttachmentPayload
+	76, // 25: logic_pb.MsgPayload.system:type_name -> logic_pb.SystemPayload
+	77, // 26: logic_pb.MsgPayload.location:type_name -> logic_pb.LocationPayload
+	78, // 27: logic_pb.MsgPayload.custom:type_name -> logic_pb.CustomPayload
+	28, // [28:28] is the sub-list for method output_type
+	28, // [28:28] is the sub-list for method input_type
+	28, // [28:28] is the sub-list for extension type_name
+	28, // [28:28] is the sub-list for extension extendee
+	0,  // [0:28] is the sub-list for field type_name
 }
 
 func init() { file_logic_proto_init() }
@@ -1091,13 +5913,21 @@ func file_logic_proto_init() {
 	if File_logic_proto != nil {
 		return
 	}
+	file_logic_proto_msgTypes[72].OneofWrappers = []any{
+		(*MsgPayload_Text)(nil),
+		(*MsgPayload_Markdown)(nil),
+		(*MsgPayload_Attachment)(nil),
+		(*MsgPayload_System)(nil),
+		(*MsgPayload_Location)(nil),
+		(*MsgPayload_Custom)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_logic_proto_rawDesc), len(file_logic_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   79,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
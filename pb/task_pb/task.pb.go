@@ -31,9 +31,12 @@ type RedisMsg struct {
 	Msg      []byte                 `protobuf:"bytes,5,opt,name=msg,proto3" json:"msg,omitempty"`                           // 消息内容
 	Count    int32                  `protobuf:"varint,6,opt,name=count,proto3" json:"count,omitempty"`                      // 计数
 	// 使用 map<string, string> 替代原始结构
-	RoomUserInfo  map[string]string `protobuf:"bytes,7,rep,name=room_user_info,json=roomUserInfo,proto3" json:"room_user_info,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	RoomUserInfo   map[string]string `protobuf:"bytes,7,rep,name=room_user_info,json=roomUserInfo,proto3" json:"room_user_info,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RoomEvent      *RoomEvent        `protobuf:"bytes,8,opt,name=room_event,json=roomEvent,proto3" json:"room_event,omitempty"`                   // 房间事件 (可选)
+	Ver            int32             `protobuf:"varint,9,opt,name=ver,proto3" json:"ver,omitempty"`                                               // 消息体的协议版本，原样带给connect层，为0的是老版本logic发的
+	RetryServerIds []string          `protobuf:"bytes,10,rep,name=retry_server_ids,json=retryServerIds,proto3" json:"retry_server_ids,omitempty"` // 广播重试时只推这些上次失败的connect层，为空推给所有
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *RedisMsg) Reset() {
@@ -115,6 +118,27 @@ func (x *RedisMsg) GetRoomUserInfo() map[string]string {
 	return nil
 }
 
+func (x *RedisMsg) GetRoomEvent() *RoomEvent {
+	if x != nil {
+		return x.RoomEvent
+	}
+	return nil
+}
+
+func (x *RedisMsg) GetVer() int32 {
+	if x != nil {
+		return x.Ver
+	}
+	return 0
+}
+
+func (x *RedisMsg) GetRetryServerIds() []string {
+	if x != nil {
+		return x.RetryServerIds
+	}
+	return nil
+}
+
 // RedisRoomInfo Redis 房间信息
 type RedisRoomInfo struct {
 	state  protoimpl.MessageState `protogen:"open.v1"`
@@ -123,6 +147,7 @@ type RedisRoomInfo struct {
 	Count  int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`                 // 计数 (可选)
 	// 使用 map<string, string> 替代原始结构
 	RoomUserInfo  map[string]string `protobuf:"bytes,4,rep,name=room_user_info,json=roomUserInfo,proto3" json:"room_user_info,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	RoomEvent     *RoomEvent        `protobuf:"bytes,5,opt,name=room_event,json=roomEvent,proto3" json:"room_event,omitempty"` // 房间事件 (可选)
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -185,6 +210,114 @@ func (x *RedisRoomInfo) GetRoomUserInfo() map[string]string {
 	return nil
 }
 
+func (x *RedisRoomInfo) GetRoomEvent() *RoomEvent {
+	if x != nil {
+		return x.RoomEvent
+	}
+	return nil
+}
+
+// RoomEvent 房间事件 (角色变更、踢人、禁言、封禁、改名、改话题)
+type RoomEvent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Event          string                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`                                           // 事件类型
+	OperatorId     int32                  `protobuf:"varint,2,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`              // 操作者用户ID
+	OperatorName   string                 `protobuf:"bytes,3,opt,name=operator_name,json=operatorName,proto3" json:"operator_name,omitempty"`         // 操作者用户名
+	TargetUserId   int32                  `protobuf:"varint,4,opt,name=target_user_id,json=targetUserId,proto3" json:"target_user_id,omitempty"`      // 被操作的用户ID (可选)
+	TargetUserName string                 `protobuf:"bytes,5,opt,name=target_user_name,json=targetUserName,proto3" json:"target_user_name,omitempty"` // 被操作的用户名 (可选)
+	Role           string                 `protobuf:"bytes,6,opt,name=role,proto3" json:"role,omitempty"`                                             // 变更后的角色 (可选)
+	RoomName       string                 `protobuf:"bytes,7,opt,name=room_name,json=roomName,proto3" json:"room_name,omitempty"`                     // 房间名
+	Topic          string                 `protobuf:"bytes,8,opt,name=topic,proto3" json:"topic,omitempty"`                                           // 房间话题
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RoomEvent) Reset() {
+	*x = RoomEvent{}
+	mi := &file_task_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoomEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoomEvent) ProtoMessage() {}
+
+func (x *RoomEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoomEvent.ProtoReflect.Descriptor instead.
+func (*RoomEvent) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RoomEvent) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetOperatorId() int32 {
+	if x != nil {
+		return x.OperatorId
+	}
+	return 0
+}
+
+func (x *RoomEvent) GetOperatorName() string {
+	if x != nil {
+		return x.OperatorName
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetTargetUserId() int32 {
+	if x != nil {
+		return x.TargetUserId
+	}
+	return 0
+}
+
+func (x *RoomEvent) GetTargetUserName() string {
+	if x != nil {
+		return x.TargetUserName
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetRoomName() string {
+	if x != nil {
+		return x.RoomName
+	}
+	return ""
+}
+
+func (x *RoomEvent) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
 // RedisRoomCountMsg Redis 房间计数消息
 type RedisRoomCountMsg struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -196,7 +329,7 @@ type RedisRoomCountMsg struct {
 
 func (x *RedisRoomCountMsg) Reset() {
 	*x = RedisRoomCountMsg{}
-	mi := &file_task_proto_msgTypes[2]
+	mi := &file_task_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -208,7 +341,7 @@ func (x *RedisRoomCountMsg) String() string {
 func (*RedisRoomCountMsg) ProtoMessage() {}
 
 func (x *RedisRoomCountMsg) ProtoReflect() protoreflect.Message {
-	mi := &file_task_proto_msgTypes[2]
+	mi := &file_task_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -221,7 +354,7 @@ func (x *RedisRoomCountMsg) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RedisRoomCountMsg.ProtoReflect.Descriptor instead.
 func (*RedisRoomCountMsg) Descriptor() ([]byte, []int) {
-	return file_task_proto_rawDescGZIP(), []int{2}
+	return file_task_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *RedisRoomCountMsg) GetCount() int32 {
@@ -249,7 +382,7 @@ type SuccessReply struct {
 
 func (x *SuccessReply) Reset() {
 	*x = SuccessReply{}
-	mi := &file_task_proto_msgTypes[3]
+	mi := &file_task_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -261,7 +394,7 @@ func (x *SuccessReply) String() string {
 func (*SuccessReply) ProtoMessage() {}
 
 func (x *SuccessReply) ProtoReflect() protoreflect.Message {
-	mi := &file_task_proto_msgTypes[3]
+	mi := &file_task_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -274,7 +407,7 @@ func (x *SuccessReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SuccessReply.ProtoReflect.Descriptor instead.
 func (*SuccessReply) Descriptor() ([]byte, []int) {
-	return file_task_proto_rawDescGZIP(), []int{3}
+	return file_task_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SuccessReply) GetCode() int32 {
@@ -291,12 +424,89 @@ func (x *SuccessReply) GetMsg() string {
 	return ""
 }
 
+// DeadLetter 重试多次仍推送失败的消息
+type DeadLetter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`                              // 死信ID
+	Msg           *RedisMsg              `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`                            // 原始队列消息
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`                      // 最后一次失败原因
+	Attempts      int32                  `protobuf:"varint,4,opt,name=attempts,proto3" json:"attempts,omitempty"`                 // 已尝试次数
+	FailTime      int64                  `protobuf:"varint,5,opt,name=fail_time,json=failTime,proto3" json:"fail_time,omitempty"` // 进入死信的时间（毫秒）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_task_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetter) ProtoMessage() {}
+
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_task_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_task_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeadLetter) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetMsg() *RedisMsg {
+	if x != nil {
+		return x.Msg
+	}
+	return nil
+}
+
+func (x *DeadLetter) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetFailTime() int64 {
+	if x != nil {
+		return x.FailTime
+	}
+	return 0
+}
+
 var File_task_proto protoreflect.FileDescriptor
 
 const file_task_proto_rawDesc = "" +
 	"\n" +
 	"\n" +
-	"task.proto\x12\atask_pb\"\x9d\x02\n" +
+	"task.proto\x12\atask_pb\"\x8c\x03\n" +
 	"\bRedisMsg\x12\x0e\n" +
 	"\x02op\x18\x01 \x01(\x05R\x02op\x12\x1b\n" +
 	"\tserver_id\x18\x02 \x01(\tR\bserverId\x12\x17\n" +
@@ -304,24 +514,48 @@ const file_task_proto_rawDesc = "" +
 	"\auser_id\x18\x04 \x01(\x05R\x06userId\x12\x10\n" +
 	"\x03msg\x18\x05 \x01(\fR\x03msg\x12\x14\n" +
 	"\x05count\x18\x06 \x01(\x05R\x05count\x12I\n" +
-	"\x0eroom_user_info\x18\a \x03(\v2#.task_pb.RedisMsg.RoomUserInfoEntryR\froomUserInfo\x1a?\n" +
+	"\x0eroom_user_info\x18\a \x03(\v2#.task_pb.RedisMsg.RoomUserInfoEntryR\froomUserInfo\x121\n" +
+	"\n" +
+	"room_event\x18\b \x01(\v2\x12.task_pb.RoomEventR\troomEvent\x12\x10\n" +
+	"\x03ver\x18\t \x01(\x05R\x03ver\x12(\n" +
+	"\x10retry_server_ids\x18\n" +
+	" \x03(\tR\x0eretryServerIds\x1a?\n" +
 	"\x11RoomUserInfoEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdf\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x92\x02\n" +
 	"\rRedisRoomInfo\x12\x0e\n" +
 	"\x02op\x18\x01 \x01(\x05R\x02op\x12\x17\n" +
 	"\aroom_id\x18\x02 \x01(\x05R\x06roomId\x12\x14\n" +
 	"\x05count\x18\x03 \x01(\x05R\x05count\x12N\n" +
-	"\x0eroom_user_info\x18\x04 \x03(\v2(.task_pb.RedisRoomInfo.RoomUserInfoEntryR\froomUserInfo\x1a?\n" +
+	"\x0eroom_user_info\x18\x04 \x03(\v2(.task_pb.RedisRoomInfo.RoomUserInfoEntryR\froomUserInfo\x121\n" +
+	"\n" +
+	"room_event\x18\x05 \x01(\v2\x12.task_pb.RoomEventR\troomEvent\x1a?\n" +
 	"\x11RoomUserInfoEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"9\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xfe\x01\n" +
+	"\tRoomEvent\x12\x14\n" +
+	"\x05event\x18\x01 \x01(\tR\x05event\x12\x1f\n" +
+	"\voperator_id\x18\x02 \x01(\x05R\n" +
+	"operatorId\x12#\n" +
+	"\roperator_name\x18\x03 \x01(\tR\foperatorName\x12$\n" +
+	"\x0etarget_user_id\x18\x04 \x01(\x05R\ftargetUserId\x12(\n" +
+	"\x10target_user_name\x18\x05 \x01(\tR\x0etargetUserName\x12\x12\n" +
+	"\x04role\x18\x06 \x01(\tR\x04role\x12\x1b\n" +
+	"\troom_name\x18\a \x01(\tR\broomName\x12\x14\n" +
+	"\x05topic\x18\b \x01(\tR\x05topic\"9\n" +
 	"\x11RedisRoomCountMsg\x12\x14\n" +
 	"\x05count\x18\x01 \x01(\x05R\x05count\x12\x0e\n" +
 	"\x02op\x18\x02 \x01(\x05R\x02op\"4\n" +
 	"\fSuccessReply\x12\x12\n" +
 	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x10\n" +
-	"\x03msg\x18\x02 \x01(\tR\x03msgB\x15Z\x13yoyichat/pb/task_pbb\x06proto3"
+	"\x03msg\x18\x02 \x01(\tR\x03msg\"\x92\x01\n" +
+	"\n" +
+	"DeadLetter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12#\n" +
+	"\x03msg\x18\x02 \x01(\v2\x11.task_pb.RedisMsgR\x03msg\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1a\n" +
+	"\battempts\x18\x04 \x01(\x05R\battempts\x12\x1b\n" +
+	"\tfail_time\x18\x05 \x01(\x03R\bfailTimeB\x15Z\x13yoyichat/pb/task_pbb\x06proto3"
 
 var (
 	file_task_proto_rawDescOnce sync.Once
@@ -335,23 +569,28 @@ func file_task_proto_rawDescGZIP() []byte {
 	return file_task_proto_rawDescData
 }
 
-var file_task_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_task_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_task_proto_goTypes = []any{
 	(*RedisMsg)(nil),          // 0: task_pb.RedisMsg
 	(*RedisRoomInfo)(nil),     // 1: task_pb.RedisRoomInfo
-	(*RedisRoomCountMsg)(nil), // 2: task_pb.RedisRoomCountMsg
-	(*SuccessReply)(nil),      // 3: task_pb.SuccessReply
-	nil,                       // 4: task_pb.RedisMsg.RoomUserInfoEntry
-	nil,                       // 5: task_pb.RedisRoomInfo.RoomUserInfoEntry
+	(*RoomEvent)(nil),         // 2: task_pb.RoomEvent
+	(*RedisRoomCountMsg)(nil), // 3: task_pb.RedisRoomCountMsg
+	(*SuccessReply)(nil),      // 4: task_pb.SuccessReply
+	(*DeadLetter)(nil),        // 5: task_pb.DeadLetter
+	nil,                       // 6: task_pb.RedisMsg.RoomUserInfoEntry
+	nil,                       // 7: task_pb.RedisRoomInfo.RoomUserInfoEntry
 }
 var file_task_proto_depIdxs = []int32{
-	4, // 0: task_pb.RedisMsg.room_user_info:type_name -> task_pb.RedisMsg.RoomUserInfoEntry
-	5, // 1: task_pb.RedisRoomInfo.room_user_info:type_name -> task_pb.RedisRoomInfo.RoomUserInfoEntry
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	6, // 0: task_pb.RedisMsg.room_user_info:type_name -> task_pb.RedisMsg.RoomUserInfoEntry
+	2, // 1: task_pb.RedisMsg.room_event:type_name -> task_pb.RoomEvent
+	7, // 2: task_pb.RedisRoomInfo.room_user_info:type_name -> task_pb.RedisRoomInfo.RoomUserInfoEntry
+	2, // 3: task_pb.RedisRoomInfo.room_event:type_name -> task_pb.RoomEvent
+	0, // 4: task_pb.DeadLetter.msg:type_name -> task_pb.RedisMsg
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_task_proto_init() }
@@ -365,7 +604,7 @@ func file_task_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_task_proto_rawDesc), len(file_task_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
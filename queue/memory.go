@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"errors"
+	"sync"
+	"yoyichat/config"
+)
+
+// 进程内channel实现，只能在logic和task跑在同一个进程里时使用（测试和小规模部署）
+// 进程退出时队列里的消息会丢失
+type memoryQueue struct {
+	ch chan []byte
+}
+
+var memoryIns *memoryQueue
+var memoryOnce sync.Once
+
+// 同一进程内的发布者和消费者共用一个channel
+func getMemoryQueue() *memoryQueue {
+	memoryOnce.Do(func() {
+		size := config.Conf.Common.CommonQueue.MemorySize
+		if size <= 0 {
+			size = 1024
+		}
+		memoryIns = &memoryQueue{ch: make(chan []byte, size)}
+	})
+	return memoryIns
+}
+
+// 缓冲满了直接报错，避免没有消费者时把logic层卡死
+func (q *memoryQueue) Publish(body []byte) error {
+	select {
+	case q.ch <- body:
+		return nil
+	default:
+		return errors.New("memory queue full")
+	}
+}
+
+func (q *memoryQueue) Consume(handler Handler) {
+	for body := range q.ch {
+		handler(&Message{Body: body})
+	}
+}
+
+func (q *memoryQueue) Ack(id string) error {
+	return nil
+}
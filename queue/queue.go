@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"yoyichat/config"
+)
+
+// logic->task 的消息队列抽象，logic层只管发布，task层只管消费
+// 具体用哪种实现由 common.toml 的 [common-queue] type 决定，以后换kafka之类的只需要加一个实现
+
+type Message struct {
+	Id          string // 队列内的消息ID，确认时使用；不需要确认的实现可以为空
+	Body        []byte
+	Redelivered bool // 从挂掉的消费者那里接管过来重投的消息，之前可能已经处理了一部分
+}
+
+type Handler func(msg *Message)
+
+type Publisher interface {
+	Publish(body []byte) error
+}
+
+type Consumer interface {
+	// 阻塞消费，每条消息交给handler处理
+	Consume(handler Handler)
+	// 消息处理成功后确认，不支持确认的实现直接返回nil
+	Ack(id string) error
+}
+
+func NewPublisher(redisClient *redis.Client) (Publisher, error) {
+	switch config.Conf.Common.CommonQueue.Type {
+	case config.QueueTypeRedisList:
+		return &redisListQueue{client: redisClient}, nil
+	case config.QueueTypeRedisStream, "":
+		return &redisStreamQueue{client: redisClient}, nil
+	case config.QueueTypeMemory:
+		return getMemoryQueue(), nil
+	}
+	return nil, fmt.Errorf("unknown queue type:%s", config.Conf.Common.CommonQueue.Type)
+}
+
+func NewConsumer(redisClient *redis.Client) (Consumer, error) {
+	switch config.Conf.Common.CommonQueue.Type {
+	case config.QueueTypeRedisList:
+		return &redisListQueue{client: redisClient}, nil
+	case config.QueueTypeRedisStream, "":
+		return newRedisStreamConsumer(redisClient)
+	case config.QueueTypeMemory:
+		return getMemoryQueue(), nil
+	}
+	return nil, fmt.Errorf("unknown queue type:%s", config.Conf.Common.CommonQueue.Type)
+}
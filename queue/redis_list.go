@@ -0,0 +1,40 @@
+package queue
+
+import (
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"time"
+	"yoyichat/config"
+)
+
+// redis list 实现：LPUSH 发布，BRPOP 消费
+// 弹出即删除，没有确认机制，task在推送前挂掉消息就丢了
+type redisListQueue struct {
+	client *redis.Client
+}
+
+func (q *redisListQueue) Publish(body []byte) error {
+	return q.client.LPush(config.QueueName, body).Err()
+}
+
+func (q *redisListQueue) Consume(handler Handler) {
+	for {
+		//10s timeout
+		result, err := q.client.BRPop(time.Second*10, config.QueueName).Result()
+		if err != nil {
+			if err != redis.Nil {
+				logrus.Errorf("queue BRPop err:%s", err.Error())
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		// 结果是 [key, value]
+		if len(result) >= 2 {
+			handler(&Message{Body: []byte(result[1])})
+		}
+	}
+}
+
+func (q *redisListQueue) Ack(id string) error {
+	return nil
+}
@@ -0,0 +1,169 @@
+package queue
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"os"
+	"strings"
+	"time"
+	"yoyichat/config"
+)
+
+// redis stream 消费组实现，多个task实例加入同一个消费组分摊消息
+// 处理成功后才XACK，没有ACK的消息留在pending列表里，消费者挂掉后由其他实例接管
+type redisStreamQueue struct {
+	client   *redis.Client
+	conf     config.TaskStream
+	consumer string // 当前实例在消费组里的名字
+}
+
+func newRedisStreamConsumer(redisClient *redis.Client) (*redisStreamQueue, error) {
+	q := &redisStreamQueue{
+		client:   redisClient,
+		conf:     config.Conf.Task.TaskStream,
+		consumer: config.Conf.Task.TaskStream.Consumer,
+	}
+	if q.consumer == "" {
+		hostname, _ := os.Hostname()
+		q.consumer = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	// 从头开始消费，组已经存在时忽略BUSYGROUP
+	err := redisClient.XGroupCreateMkStream(config.QueueStreamName, q.conf.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+	return q, nil
+}
+
+// 消息体放在固定字段里
+func (q *redisStreamQueue) Publish(body []byte) error {
+	return q.client.XAdd(&redis.XAddArgs{
+		Stream: config.QueueStreamName,
+		Values: map[string]interface{}{config.QueueStreamField: body},
+	}).Err()
+}
+
+// 无限循环，持续读取新消息
+func (q *redisStreamQueue) Consume(handler Handler) {
+	go q.reclaimPending(handler)
+	go q.trimStream()
+	for {
+		streams, err := q.client.XReadGroup(&redis.XReadGroupArgs{
+			Group:    q.conf.Group,
+			Consumer: q.consumer,
+			Streams:  []string{config.QueueStreamName, ">"},
+			Count:    q.conf.BatchSize,
+			Block:    time.Duration(q.conf.BlockMs) * time.Millisecond,
+		}).Result()
+		if err != nil {
+			if err != redis.Nil {
+				logrus.Errorf("queue XReadGroup err:%s", err.Error())
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		for _, stream := range streams {
+			for _, m := range stream.Messages {
+				q.handle(m, handler, false)
+			}
+		}
+	}
+}
+
+func (q *redisStreamQueue) Ack(id string) error {
+	return q.client.XAck(config.QueueStreamName, q.conf.Group, id).Err()
+}
+
+func (q *redisStreamQueue) handle(m redis.XMessage, handler Handler, redelivered bool) {
+	body, ok := m.Values[config.QueueStreamField].(string)
+	if !ok {
+		// 已经被裁剪掉或者格式不对，留在pending里也没用
+		logrus.Errorf("queue msg %s has no body", m.ID)
+		q.Ack(m.ID)
+		return
+	}
+	handler(&Message{Id: m.ID, Body: []byte(body), Redelivered: redelivered})
+}
+
+// 定期扫描pending列表，把空闲太久（原消费者大概率已经挂了）的消息接管过来重新投递
+func (q *redisStreamQueue) reclaimPending(handler Handler) {
+	if q.conf.ClaimInterval <= 0 {
+		return
+	}
+	minIdle := time.Duration(q.conf.ClaimMinIdle) * time.Second
+	ticker := time.NewTicker(time.Duration(q.conf.ClaimInterval) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		q.reclaimAll(handler, minIdle)
+	}
+}
+
+// 按BatchSize分页扫完整个pending列表，只看第一页的话前面那批一直失败会把后面的卡死
+// XPENDING 的起点是闭区间，从上一页最后一个id开始，跳过这个id本身
+func (q *redisStreamQueue) reclaimAll(handler Handler, minIdle time.Duration) {
+	start, reclaimed := "-", 0
+	for {
+		pending, err := q.client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: config.QueueStreamName,
+			Group:  q.conf.Group,
+			Start:  start,
+			End:    "+",
+			Count:  q.conf.BatchSize,
+		}).Result()
+		if err != nil {
+			logrus.Errorf("queue XPendingExt err:%s", err.Error())
+			return
+		}
+		var ids []string
+		for _, p := range pending {
+			if p.Id != start && p.Idle >= minIdle {
+				ids = append(ids, p.Id)
+			}
+		}
+		if len(ids) > 0 {
+			reclaimed += q.claim(handler, ids, minIdle)
+		}
+		// 最后一页，或者BatchSize为1时翻不动了
+		if int64(len(pending)) < q.conf.BatchSize || pending[len(pending)-1].Id == start {
+			break
+		}
+		start = pending[len(pending)-1].Id
+	}
+	if reclaimed > 0 {
+		logrus.Infof("queue reclaim %d pending msgs", reclaimed)
+	}
+}
+
+// XCLAIM 自己会再校验一次空闲时间，多个实例同时接管时只有一个能拿到
+func (q *redisStreamQueue) claim(handler Handler, ids []string, minIdle time.Duration) int {
+	msgs, err := q.client.XClaim(&redis.XClaimArgs{
+		Stream:   config.QueueStreamName,
+		Group:    q.conf.Group,
+		Consumer: q.consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		logrus.Errorf("queue XClaim err:%s", err.Error())
+		return 0
+	}
+	for _, m := range msgs {
+		q.handle(m, handler, true)
+	}
+	return len(msgs)
+}
+
+// 按配置定期裁剪stream，maxLen要足够大，否则还没消费的消息也会被裁掉
+func (q *redisStreamQueue) trimStream() {
+	if q.conf.MaxLen <= 0 || q.conf.TrimInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(q.conf.TrimInterval) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := q.client.XTrimApprox(config.QueueStreamName, q.conf.MaxLen).Err(); err != nil {
+			logrus.Errorf("queue XTrimApprox err:%s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package task
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"sort"
+	"strconv"
+	"time"
+	"yoyichat/config"
+	"yoyichat/pb/task_pb"
+	"yoyichat/queue"
+)
+
+// 死信存在redis hash里，id是进入死信时的纳秒时间戳，按id排序就是时间顺序
+func (task *Task) storeDeadLetter(m *task_pb.RedisMsg, attempts int, reason error) (err error) {
+	now := time.Now()
+	deadLetter := &task_pb.DeadLetter{
+		Id:       strconv.FormatInt(now.UnixNano(), 10),
+		Msg:      m,
+		Reason:   reason.Error(),
+		Attempts: int32(attempts),
+		FailTime: now.UnixMilli(),
+	}
+	body, err := proto.Marshal(deadLetter)
+	if err != nil {
+		logrus.Errorf("task dead letter marshal err:%s", err.Error())
+		return
+	}
+	if err = RedisClient.HSet(config.RedisDeadLetterKey, deadLetter.Id, body).Err(); err != nil {
+		logrus.Errorf("task store dead letter err:%s", err.Error())
+	}
+	return
+}
+
+func (task *Task) ListDeadLetters() (list []*task_pb.DeadLetter, err error) {
+	all, err := RedisClient.HGetAll(config.RedisDeadLetterKey).Result()
+	if err != nil {
+		return
+	}
+	for id, body := range all {
+		deadLetter := &task_pb.DeadLetter{}
+		if err := proto.Unmarshal([]byte(body), deadLetter); err != nil {
+			logrus.Errorf("task dead letter %s unmarshal err:%s", id, err.Error())
+			continue
+		}
+		list = append(list, deadLetter)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+	return
+}
+
+// 重新放回消息队列，由正在运行的task实例按正常流程再推一次
+func (task *Task) ReplayDeadLetter(publisher queue.Publisher, deadLetter *task_pb.DeadLetter) (err error) {
+	body, err := proto.Marshal(deadLetter.Msg)
+	if err != nil {
+		return
+	}
+	if err = publisher.Publish(body); err != nil {
+		return
+	}
+	return RedisClient.HDel(config.RedisDeadLetterKey, deadLetter.Id).Err()
+}
+
+// 死信管理命令：list 查看全部死信，replay 重放指定id的死信，不指定id时重放全部
+func (task *Task) RunDeadLetterAdmin(action string, id string) {
+	task.initRedisClient()
+	list, err := task.ListDeadLetters()
+	if err != nil {
+		fmt.Printf("list dead letters err:%s\n", err.Error())
+		return
+	}
+	switch action {
+	case "list":
+		for _, deadLetter := range list {
+			m := deadLetter.Msg
+			fmt.Printf("id:%s time:%s op:%d serverId:%s userId:%d roomId:%d attempts:%d reason:%s\n",
+				deadLetter.Id, time.UnixMilli(deadLetter.FailTime).Format(time.DateTime),
+				m.GetOp(), m.GetServerId(), m.GetUserId(), m.GetRoomId(), deadLetter.Attempts, deadLetter.Reason)
+		}
+		fmt.Printf("total %d dead letters\n", len(list))
+	case "replay":
+		if config.Conf.Common.CommonQueue.Type == config.QueueTypeMemory {
+			fmt.Println("memory queue can not be replayed from another process")
+			return
+		}
+		publisher, err := queue.NewPublisher(RedisClient)
+		if err != nil {
+			fmt.Printf("init queue publisher err:%s\n", err.Error())
+			return
+		}
+		replayed := 0
+		for _, deadLetter := range list {
+			if id != "" && deadLetter.Id != id {
+				continue
+			}
+			if err := task.ReplayDeadLetter(publisher, deadLetter); err != nil {
+				fmt.Printf("replay dead letter %s err:%s\n", deadLetter.Id, err.Error())
+				continue
+			}
+			replayed++
+		}
+		if id != "" && replayed == 0 {
+			fmt.Printf("dead letter %s not found or replay fail\n", id)
+			return
+		}
+		fmt.Printf("replayed %d dead letters\n", replayed)
+	default:
+		fmt.Println("action param error, use list or replay")
+	}
+}
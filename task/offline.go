@@ -0,0 +1,17 @@
+package task
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"yoyichat/config"
+)
+
+// 单聊消息推不到用户时（connect层宕机或者用户已断开），存进离线收件箱
+// logic层在用户下次Connect时取出来推送
+func (task *Task) storeOfflineMsg(userId int, msg []byte) (err error) {
+	offlineKey := fmt.Sprintf("%s%d", config.RedisOfflinePrefix, userId)
+	if err = RedisClient.RPush(offlineKey, msg).Err(); err != nil {
+		logrus.Errorf("task store offline msg err:%s", err.Error())
+	}
+	return
+}
@@ -11,8 +11,12 @@ import (
 type PushParams struct {
 	ServerId string // 与接受者连接的Connection层
 	UserId   int    // 接受者
+	Op       int    // 推给connect层的操作类型，单聊消息或者投递状态
 	Msg      []byte
 	RoomId   int
+	QueueId  string // 队列消息ID，推送成功后确认
+	Raw      *task_pb.RedisMsg
+	Attempt  int // 第几次尝试，从1开始
 }
 
 var pushChannel []chan *PushParams
@@ -37,41 +41,111 @@ func (task *Task) processSinglePush(ch chan *PushParams) {
 	var arg *PushParams
 	for {
 		arg = <-ch
-		//@todo when arg.ServerId server is down, user could be reconnect other serverId but msg in queue no consume
-		// TODO：急需解决的问题：消息队列中的ID可能比服务器活得都久，如果服务器ID S1宕机，那么这些标注S1的消息，岂不是无处可去吗
-		// 服务器宕机意味着Rooms中的所有房间都已经没了，为什么会没是因为Room中的用户全部下线了，既然都全下线了，那么单聊数据就无目的地了
-		// 可以存入离线数据库，作为离线消息，在用户上线的时候加载离线消息即可
+		// 消息队列中的ServerId可能比服务器活得都久，如果服务器S1宕机或者用户已经断开，
+		// pushSingleToConnect 会把消息存进离线收件箱，用户重新Connect时由logic层取出推送
 		// 还有一种情况：用户迁移服务器？但是不知道有没有这个功能？比较ServerID咋来的？似乎是开启的时候由作者自行添加的
 		// 好像没有自动添加，或者说是自动扩容的功能
 		// TODO：用户迁移，与服务器扩容
 		// 这是将消息给推送到 ServerId服务器 上的 UserId用户 ？
-		task.pushSingleToConnect(arg.ServerId, arg.UserId, arg.Msg)
+		err := task.pushSingleToConnect(arg.ServerId, arg.UserId, arg.Op, msgVersion(arg.Raw), arg.Msg)
+		task.afterDeliver(arg.QueueId, arg.Raw, arg.Attempt, err)
 	}
 }
 
 // 根据msg中的op类型推送消息到队列中，单聊消息推到channel中，然后消费者处理channel的消息
-func (task *Task) Push(msg string) {
+// 接管重投的广播消息跳过已经推成功的connect层，整个房间不会再收到一遍
+func (task *Task) Push(queueId string, msg []byte, redelivered bool) {
 	m := &task_pb.RedisMsg{}
-	if err := proto.Unmarshal([]byte(msg), m); err != nil {
-		logrus.Infof(" json.Unmarshal err:%v ", err)
+	if err := proto.Unmarshal(msg, m); err != nil {
+		// 解析不了的消息重试也没用，直接确认掉
+		logrus.Errorf("task queue msg %s unmarshal err:%v", queueId, err)
+		task.ackQueueMsg(queueId)
+		return
 	}
+	if redelivered && broadcastOp(m) {
+		serverIds, done := task.unpushedServerIds(queueId)
+		if done {
+			logrus.Infof("task queue msg %s already pushed to all connect", queueId)
+			task.ackQueueMsg(queueId)
+			return
+		}
+		m.RetryServerIds = serverIds
+	}
+	task.deliver(queueId, m, 1)
+}
+
+// 第attempt次推送，群聊等广播消息在这里推完，单聊消息由 processSinglePush 推完
+// 推完之后统一交给 afterDeliver 决定确认、重试还是进死信
+func (task *Task) deliver(queueId string, m *task_pb.RedisMsg, attempt int) {
 	logrus.Infof("push msg info %d,op is:%d", m.RoomId, m.Op)
 	// 群聊消息都是直接发送，而单聊消息都是入管道？是的，方便对单聊做点操作 TODO：加密？
 	// 我有个疑问，Connection都是在ServerID下；wait… 群聊消息并不是往一个房间丢消息，其他人去读取。而是往房间内的每个人发消息，
 	// 所以是实时的，那似乎可能会因为延迟，导致某些人收到了消息，某些人没有收到
 	// TODO：那么怎么解决这个问题，历史消息是要做的，公共历史消息吗，比如往公共筒子中放消息，然后由筒子去发给房间中的每个人，即使有延迟，短线重现的时候也可以通过筒子来复现历史消息
+	var (
+		failed []string
+		err    error
+	)
+	// 广播前先定下这次要推的connect层，推成功的记下来
+	serverIds := m.RetryServerIds
+	if len(serverIds) == 0 {
+		serverIds = RClient.GetServerIds()
+	}
 	switch m.Op {
-	case config.OpSingleSend:
-		pushChannel[rand.Int()%config.Conf.Task.TaskBase.PushChan] <- &PushParams{
-			ServerId: m.ServerId,
-			UserId:   int(m.UserId),
-			Msg:      m.Msg,
-		}
+	case config.OpSingleSend, config.OpDeliveryState, config.OpContact, config.OpThreadReply, config.OpMention:
+		task.pushSingle(queueId, m, attempt)
+		return
 	case config.OpRoomSend:
-		task.broadcastRoomToConnect(int(m.RoomId), m.Msg)
+		failed, err = task.broadcastRoomToConnect(serverIds, int(m.RoomId), config.OpRoomSend, msgVersion(m), m.Msg)
+	case config.OpReadReceipt, config.OpPresence, config.OpTyping, config.OpMsgEdit, config.OpMsgDelete, config.OpMsgReaction:
+		// 群聊回执、在线状态、输入状态、消息编辑删除、表情回应广播到房间，推给单个用户的和单聊消息一样入管道
+		if m.RoomId <= 0 {
+			task.pushSingle(queueId, m, attempt)
+			return
+		}
+		failed, err = task.broadcastRoomToConnect(serverIds, int(m.RoomId), int(m.Op), msgVersion(m), m.Msg)
 	case config.OpRoomCountSend:
-		task.broadcastRoomCountToConnect(int(m.RoomId), int(m.Count))
+		failed, err = task.broadcastRoomCountToConnect(serverIds, int(m.RoomId), int(m.Count))
 	case config.OpRoomInfoSend:
-		task.broadcastRoomInfoToConnect(int(m.RoomId), m.RoomUserInfo)
+		failed, err = task.broadcastRoomInfoToConnect(serverIds, int(m.RoomId), m.RoomUserInfo, m.RoomEvent)
+	}
+	task.markPushed(queueId, serverIds, failed)
+	// 广播只有部分connect层失败时，重试只推失败的那些，推成功的不会重复收到
+	if err != nil && len(failed) > 0 {
+		m = proto.Clone(m).(*task_pb.RedisMsg)
+		m.RetryServerIds = failed
+	}
+	task.afterDeliver(queueId, m, attempt, err)
+}
+
+// 广播到房间的消息，其余的推给单个用户
+func broadcastOp(m *task_pb.RedisMsg) bool {
+	switch m.Op {
+	case config.OpRoomSend, config.OpRoomCountSend, config.OpRoomInfoSend:
+		return true
+	case config.OpReadReceipt, config.OpPresence, config.OpTyping, config.OpMsgEdit, config.OpMsgDelete, config.OpMsgReaction:
+		return m.RoomId > 0
+	}
+	return false
+}
+
+// 推给单个用户的消息都丢进管道，由 processSinglePush 消费
+func (task *Task) pushSingle(queueId string, m *task_pb.RedisMsg, attempt int) {
+	pushChannel[rand.Int()%config.Conf.Task.TaskBase.PushChan] <- &PushParams{
+		ServerId: m.ServerId,
+		UserId:   int(m.UserId),
+		Op:       int(m.Op),
+		Msg:      m.Msg,
+		QueueId:  queueId,
+		Raw:      m,
+		Attempt:  attempt,
+	}
+}
+
+// 消息体的协议版本，升级前的logic不带版本号，发的都是纯文本消息
+func msgVersion(m *task_pb.RedisMsg) int32 {
+	if m == nil || m.Ver == 0 {
+		return config.MsgVersionText
 	}
+	return m.Ver
 }
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"github.com/sirupsen/logrus"
+	"slices"
+	"time"
+	"yoyichat/config"
+)
+
+// 广播去重：redis stream 是至少一次投递，task推完广播还没XACK就挂了的话，消息会被别的实例接管重投
+// 每个connect层推成功后记进 yoyichat_task_pushed_<queueId> 集合，重投时跳过这些connect层
+// 不支持重投的队列（queueId为空）和没开接管的配置不用记
+
+func pushedKey(queueId string) string {
+	return config.RedisTaskPushedPrefix + queueId
+}
+
+// 记录这次推成功的connect层，保留到接管重投不会再发生为止
+func (task *Task) markPushed(queueId string, serverIds []string, failed []string) {
+	claimMinIdle := config.Conf.Task.TaskStream.ClaimMinIdle
+	if queueId == "" || claimMinIdle <= 0 {
+		return
+	}
+	var pushed []interface{}
+	for _, serverId := range serverIds {
+		if !slices.Contains(failed, serverId) {
+			pushed = append(pushed, serverId)
+		}
+	}
+	if len(pushed) == 0 {
+		return
+	}
+	key := pushedKey(queueId)
+	pipe := RedisClient.Pipeline()
+	pipe.SAdd(key, pushed...)
+	pipe.Expire(key, 10*time.Duration(claimMinIdle)*time.Second)
+	if _, err := pipe.Exec(); err != nil {
+		logrus.Warnf("task mark queue msg %s pushed err:%s", queueId, err.Error())
+	}
+}
+
+// 重投的消息还没推成功的connect层，全部推过了 done 为 true
+// 一个都没推过时返回空，和第一次投递一样推给所有connect层
+func (task *Task) unpushedServerIds(queueId string) (serverIds []string, done bool) {
+	pushed, err := RedisClient.SMembers(pushedKey(queueId)).Result()
+	if err != nil || len(pushed) == 0 {
+		return
+	}
+	for _, serverId := range RClient.GetServerIds() {
+		if !slices.Contains(pushed, serverId) {
+			serverIds = append(serverIds, serverId)
+		}
+	}
+	return serverIds, len(serverIds) == 0
+}
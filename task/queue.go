@@ -3,18 +3,29 @@ package task
 import (
 	"github.com/go-redis/redis"
 	"github.com/sirupsen/logrus"
-	"time"
 	"yoyichat/config"
+	"yoyichat/queue"
 	"yoyichat/tools"
 )
 
-// 使用redis制作的消息队列，也许可以用kafka替代一波
+// 消息队列的具体实现见 queue 包，由配置选择 redis list / redis stream / 进程内channel
 
 // 我有个疑问，我在connect层似乎也有一个redis客户端，那么同时用的时候会冲突吗，我觉得应该不会？
 var RedisClient *redis.Client
 
+var QueueConsumer queue.Consumer
+
 // 开启消费者协程
 func (task *Task) InitQueueRedisClient() (err error) {
+	task.initRedisClient()
+	if QueueConsumer, err = queue.NewConsumer(RedisClient); err != nil {
+		return
+	}
+	go QueueConsumer.Consume(task.consumeQueueMsg)
+	return
+}
+
+func (task *Task) initRedisClient() {
 	redisOpt := tools.RedisOption{
 		Address:  config.Conf.Common.CommonRedis.RedisAddress,
 		Password: config.Conf.Common.CommonRedis.RedisPassword,
@@ -24,21 +35,15 @@ func (task *Task) InitQueueRedisClient() (err error) {
 	if pong, err := RedisClient.Ping().Result(); err != nil {
 		logrus.Infof("RedisClient Ping Result pong: %s,  err: %s", pong, err)
 	}
-	go func() {
-		// 无线循环，持续消费消息
-		for {
-			var result []string
-			//10s timeout
-			result, err = RedisClient.BRPop(time.Second*10, config.QueueName).Result()
-			if err != nil {
-				logrus.Infof("task queue block timeout,no msg err:%s", err.Error())
-			}
-
-			// 为什么结果要 >= 2 这个消费的消息是有什么形式吗
-			if len(result) >= 2 {
-				task.Push(result[1])
-			}
-		}
-	}()
-	return
+}
+
+func (task *Task) consumeQueueMsg(m *queue.Message) {
+	task.Push(m.Id, m.Body, m.Redelivered)
+}
+
+// 推送成功后确认消息
+func (task *Task) ackQueueMsg(id string) {
+	if err := QueueConsumer.Ack(id); err != nil {
+		logrus.Errorf("task ack queue msg %s err:%s", id, err.Error())
+	}
 }
@@ -0,0 +1,47 @@
+package task
+
+import (
+	"github.com/sirupsen/logrus"
+	"time"
+	"yoyichat/config"
+	"yoyichat/pb/task_pb"
+)
+
+// 推送结果处理：成功就确认；失败按指数退避重试，次数用完写进死信再确认
+// 重试期间消息不确认，task进程挂掉的话由队列（redis stream的pending接管）兜底
+func (task *Task) afterDeliver(queueId string, m *task_pb.RedisMsg, attempt int, err error) {
+	// 输入状态这种临时事件过期了也没意义，失败直接丢掉
+	if err == nil || m.Op == config.OpTyping {
+		task.ackQueueMsg(queueId)
+		return
+	}
+	retryConf := config.Conf.Task.TaskRetry
+	if attempt < retryConf.MaxAttempts {
+		delay := retryBackoff(attempt)
+		logrus.Warnf("task push msg %s op:%d attempt %d fail:%s, retry after %s", queueId, m.Op, attempt, err.Error(), delay)
+		time.AfterFunc(delay, func() {
+			task.deliver(queueId, m, attempt+1)
+		})
+		return
+	}
+	logrus.Errorf("task push msg %s op:%d fail after %d attempts:%s", queueId, m.Op, attempt, err.Error())
+	if dlErr := task.storeDeadLetter(m, attempt, err); dlErr != nil {
+		// 死信都存不进去就不确认了，留给队列重投
+		return
+	}
+	task.ackQueueMsg(queueId)
+}
+
+// 第n次失败后的等待时间：backoffBase * 2^(n-1)，不超过 backoffMax
+func retryBackoff(attempt int) time.Duration {
+	retryConf := config.Conf.Task.TaskRetry
+	delay := time.Duration(retryConf.BackoffBase) * time.Millisecond
+	max := time.Duration(retryConf.BackoffMax) * time.Millisecond
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -60,14 +60,29 @@ func (rc *RpcConnectClient) GetRpcClientByServerId(serverId string) (c client.XC
 }
 
 // 返回所有的客户端，所以这个客户端是用来调谁的RPC？
-func (rc *RpcConnectClient) GetAllConnectTypeRpcClient() (rpcClientList []client.XClient) {
-	for serverId, _ := range rc.ServerInsMap {
+// serverId => 客户端，指定了 serverIds 时只返回这些，已经下线的connect层跳过
+func (rc *RpcConnectClient) GetConnectRpcClients(serverIds []string) (rpcClients map[string]client.XClient) {
+	rpcClients = make(map[string]client.XClient)
+	if len(serverIds) == 0 {
+		serverIds = rc.GetServerIds()
+	}
+	for _, serverId := range serverIds {
 		c, err := rc.GetRpcClientByServerId(serverId)
 		if err != nil {
-			logrus.Infof("GetAllConnectTypeRpcClient err:%s", err.Error())
+			logrus.Infof("GetConnectRpcClients err:%s", err.Error())
 			continue
 		}
-		rpcClientList = append(rpcClientList, c)
+		rpcClients[serverId] = c
+	}
+	return
+}
+
+// 当前所有connect层的serverId
+func (rc *RpcConnectClient) GetServerIds() (serverIds []string) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+	for serverId := range rc.ServerInsMap {
+		serverIds = append(serverIds, serverId)
 	}
 	return
 }
@@ -205,14 +220,21 @@ func (task *Task) watchServicesChange(d client.ServiceDiscovery) {
 	}
 }
 
+// 推不到时需要转存离线的消息：单聊消息和@提醒
+func storeOfflineOp(op int) bool {
+	return op == config.OpSingleSend || op == config.OpMention
+}
+
 // 单聊消息发送
-func (task *Task) pushSingleToConnect(serverId string, userId int, msg []byte) {
+// 投递状态等事件推不到就算了，只有单聊消息和@提醒会转存离线
+// 返回错误表示这条消息需要重推，不能确认
+func (task *Task) pushSingleToConnect(serverId string, userId int, op int, ver int32, msg []byte) (err error) {
 	logrus.Infof("pushSingleToConnect Body %s", string(msg))
 	pushMsgReq := &connect_pb.PushMsgRequest{
 		UserId: int32(userId),
 		Msg: &connect_pb.Msg{
-			Ver:  config.MsgVersion,
-			Op:   config.OpSingleSend,
+			Ver:  ver,
+			Op:   int32(op),
 			Seq:  tools.GetSnowflakeId(),
 			Body: msg,
 		},
@@ -220,45 +242,49 @@ func (task *Task) pushSingleToConnect(serverId string, userId int, msg []byte) {
 	reply := &task_pb.SuccessReply{}
 	connectRpc, err := RClient.GetRpcClientByServerId(serverId)
 	if err != nil {
+		// 对应的connect层已经没了，用户重连时会落到别的connect层上，先存离线
 		logrus.Infof("get rpc client err %v", err)
+		if storeOfflineOp(op) {
+			return task.storeOfflineMsg(userId, msg)
+		}
+		return nil
 	}
 
 	// 调用Connection层的单聊消息发送
 	err = connectRpc.Call(context.Background(), "PushSingleMsg", pushMsgReq, reply)
 	if err != nil {
 		logrus.Infof("pushSingleToConnect Call err %v", err)
+		return
+	}
+	if reply.Code == config.OfflineReplyCode && storeOfflineOp(op) {
+		err = task.storeOfflineMsg(userId, msg)
 	}
 	logrus.Infof("reply %s", reply.Msg)
+	return
 }
 
 // 广播消息发送，话说RPC注册函数进去给人使用，这一块我还没有哦弄清楚？
-func (task *Task) broadcastRoomToConnect(roomId int, msg []byte) {
+// op 是推给connect层的操作类型，群聊消息和房间内的各种事件都走这里
+func (task *Task) broadcastRoomToConnect(serverIds []string, roomId int, op int, ver int32, msg []byte) (failed []string, err error) {
 	pushRoomMsgReq := &connect_pb.PushRoomMsgRequest{
 		RoomId: int32(roomId),
 		Msg: &connect_pb.Msg{
-			Ver:  config.MsgVersion,
-			Op:   config.OpRoomSend,
+			Ver:  ver,
+			Op:   int32(op),
 			Seq:  tools.GetSnowflakeId(),
 			Body: msg,
 		},
 	}
-	reply := &task_pb.SuccessReply{}
-	rpcList := RClient.GetAllConnectTypeRpcClient()
-	for _, rpc := range rpcList {
-		logrus.Infof("broadcastRoomToConnect rpc  %v", rpc)
-		rpc.Call(context.Background(), "PushRoomMsg", pushRoomMsgReq, reply)
-		logrus.Infof("reply %s", reply.Msg)
-	}
+	return task.callConnects(serverIds, "PushRoomMsg", pushRoomMsgReq)
 }
 
 // 广播房间人数
-func (task *Task) broadcastRoomCountToConnect(roomId, count int) {
+func (task *Task) broadcastRoomCountToConnect(serverIds []string, roomId, count int) (failed []string, err error) {
 	msg := &task_pb.RedisRoomCountMsg{
 		Count: int32(count),
 		Op:    config.OpRoomCountSend,
 	}
 	var body []byte
-	var err error
 	if body, err = proto.Marshal(msg); err != nil {
 		logrus.Warnf("broadcastRoomCountToConnect  proto.Marshal err :%s", err.Error())
 		return
@@ -272,25 +298,19 @@ func (task *Task) broadcastRoomCountToConnect(roomId, count int) {
 			Body: body,
 		},
 	}
-	reply := &task_pb.SuccessReply{}
-	rpcList := RClient.GetAllConnectTypeRpcClient()
-	for _, rpc := range rpcList {
-		logrus.Infof("broadcastRoomCountToConnect rpc  %v", rpc)
-		rpc.Call(context.Background(), "PushRoomCount", pushRoomMsgReq, reply)
-		logrus.Infof("reply %s", reply.Msg)
-	}
+	return task.callConnects(serverIds, "PushRoomCount", pushRoomMsgReq)
 }
 
-// 广播房间元信息
-func (task *Task) broadcastRoomInfoToConnect(roomId int, roomUserInfo map[string]string) {
+// 广播房间元信息，带上房间事件；被踢、被封禁、自己退出的人收到这条广播后由connect层移出房间
+func (task *Task) broadcastRoomInfoToConnect(serverIds []string, roomId int, roomUserInfo map[string]string, event *task_pb.RoomEvent) (failed []string, err error) {
 	msg := &task_pb.RedisRoomInfo{
 		Count:        int32(len(roomUserInfo)),
 		Op:           config.OpRoomInfoSend,
 		RoomUserInfo: roomUserInfo,
 		RoomId:       int32(roomId),
+		RoomEvent:    event,
 	}
 	var body []byte
-	var err error
 	if body, err = proto.Marshal(msg); err != nil {
 		logrus.Warnf("broadcastRoomInfoToConnect  proto.Marshal err :%s", err.Error())
 		return
@@ -304,12 +324,23 @@ func (task *Task) broadcastRoomInfoToConnect(roomId int, roomUserInfo map[string
 			Body: body,
 		},
 	}
-	reply := &task_pb.SuccessReply{}
-	// 所有的rpc客户端去调用connection方法
-	rpcList := RClient.GetAllConnectTypeRpcClient()
-	for _, rpc := range rpcList {
-		logrus.Infof("broadcastRoomInfoToConnect rpc  %v", rpc)
-		rpc.Call(context.Background(), "PushRoomInfo", pushRoomMsgReq, reply)
-		logrus.Infof("broadcastRoomInfoToConnect rpc  reply %v", reply)
+	if event != nil && (event.Event == config.RoomEventKick || event.Event == config.RoomEventBan || event.Event == config.RoomEventLeave) {
+		pushRoomMsgReq.EvictUserIds = []int32{event.TargetUserId}
 	}
+	return task.callConnects(serverIds, "PushRoomInfo", pushRoomMsgReq)
+}
+
+// 所有的rpc客户端去调用connection方法，返回调用失败的connect层，重试时只推这些
+func (task *Task) callConnects(serverIds []string, method string, pushRoomMsgReq *connect_pb.PushRoomMsgRequest) (failed []string, err error) {
+	for serverId, rpc := range RClient.GetConnectRpcClients(serverIds) {
+		logrus.Infof("%s to connect %s", method, serverId)
+		reply := &task_pb.SuccessReply{}
+		if callErr := rpc.Call(context.Background(), method, pushRoomMsgReq, reply); callErr != nil {
+			failed = append(failed, serverId)
+			err = callErr
+			continue
+		}
+		logrus.Infof("%s to connect %s reply %s", method, serverId, reply.Msg)
+	}
+	return
 }
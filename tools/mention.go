@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// @提及的解析，logic层解析群聊消息和客户端解析输入共用
+// @后面到空白或标点为止算用户名，@all 提醒房间里所有人
+
+const MentionAll = "all"
+
+// 用户名后面跟着这些标点时不算进用户名
+const mentionStopChars = ",.:;!?，。：；！？、)）"
+
+func isMentionStop(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(mentionStopChars, r)
+}
+
+// 解析消息里所有的@提及，返回去重后的用户名，@all 单独返回
+// @ 要在开头或者空白后面，避免把邮箱地址当成提及
+func ParseMentions(content string) (userNames []string, all bool) {
+	seen := make(map[string]bool)
+	runes := []rune(content)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && !unicode.IsSpace(runes[i-1])) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && runes[j] != '@' && !isMentionStop(runes[j]) {
+			j++
+		}
+		name := string(runes[i+1 : j])
+		i = j - 1
+		if name == "" {
+			continue
+		}
+		if strings.EqualFold(name, MentionAll) {
+			all = true
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			userNames = append(userNames, name)
+		}
+	}
+	return
+}
+
+// 拆出开头的 "@用户名 内容"，客户端私聊用
+func SplitLeadingMention(content string) (userName string, rest string, ok bool) {
+	if !strings.HasPrefix(content, "@") {
+		return "", content, false
+	}
+	parts := strings.SplitN(content[1:], " ", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", content, false
+	}
+	return parts[0], parts[1], true
+}
@@ -29,9 +29,9 @@ func CreateSessionId(sessionId string) string {
 	return SessionPrefix + sessionId
 }
 
-// 由用户ID 获取 用户key，用户key可以获取token，然后拼接token就能拿到会话key
-func GetSessionIdByUserId(userId int) string {
-	return fmt.Sprintf("%s_map_%d", SessionPrefix, userId)
+// 由用户ID 获取 用户各设备的令牌，设备 => token，拼接token就能拿到会话key
+func GetDeviceSessionKey(userId int) string {
+	return fmt.Sprintf("%s_devices_%d", SessionPrefix, userId)
 }
 
 // 用token获取会话key，使用会话key就能拿到用户元信息